@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"llmcloud/config"
 	"llmcloud/internal/controller"
 	"llmcloud/internal/dao"
 	"llmcloud/internal/database"
 	"llmcloud/internal/middleware"
+	"llmcloud/internal/rag"
 	"llmcloud/internal/router"
 	"llmcloud/internal/service"
+	"llmcloud/internal/storage"
+	"log"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,18 +21,79 @@ func main() {
 
 	db, _ := database.InitDB()
 
+	storageDriver, err := storage.NewDriver(config.AppConfigInstance.Storage)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage driver: %v", err)
+	}
+
 	userDao := dao.NewUserDao(db)
 	userService := service.NewUserService(userDao)
 	userController := controller.NewUserController(userService)
 	fileDao := dao.NewFileDao(db)
-	fileService := service.NewFileService(fileDao)
+	blobDao := dao.NewBlobDao(db)
+	trashDao := dao.NewTrashDao(db)
+	versionDao := dao.NewVersionDao(db)
+	changeDao := dao.NewChangeDao(db)
+	transactor := dao.NewTransactor(db)
+	changeService := service.NewChangeService(changeDao)
+	changeController := controller.NewChangeController(changeService)
+	permissionDao := dao.NewPermissionDao(db)
+	permissionService := service.NewPermissionService(permissionDao, fileDao, userDao)
+	permissionController := controller.NewPermissionController(permissionService)
+	quotaDao := dao.NewQuotaDao(db)
+	quotaService := service.NewQuotaService(quotaDao, userDao)
+	quotaController := controller.NewQuotaController(quotaService)
+	jobDao := dao.NewJobDao(db)
+	jobService := service.NewJobService(jobDao)
+	jobController := controller.NewJobController(jobService)
+	jobQueue := service.NewJobQueue(jobDao)
+	embedder, err := rag.NewEmbedder(config.AppConfigInstance.Embedder)
+	if err != nil {
+		log.Fatalf("Failed to initialize embedder: %v", err)
+	}
+	pipeline, err := rag.NewPipeline(config.AppConfigInstance.RAG, embedder)
+	if err != nil {
+		log.Fatalf("Failed to initialize RAG pipeline: %v", err)
+	}
+	ingestService := service.NewIngestService(fileDao, jobQueue, storageDriver, pipeline)
+	searchService := service.NewSearchService(fileDao, permissionDao, permissionService, pipeline)
+	searchController := controller.NewSearchController(searchService)
+	fileService := service.NewFileService(fileDao, blobDao, trashDao, versionDao, ingestService, jobQueue, transactor, permissionService, quotaService, storageDriver)
 	fileController := controller.NewFileController(fileService)
+	llm, err := rag.NewLLM(config.AppConfigInstance.LLM)
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM: %v", err)
+	}
+	chatService := service.NewChatService(searchService, fileService, llm)
+	chatController := controller.NewChatController(chatService)
+	uploadSessionDao := dao.NewUploadSessionDao(db)
+	uploadService := service.NewUploadService(uploadSessionDao, fileDao, blobDao, versionDao, transactor, ingestService, permissionService, quotaService, storageDriver)
+	uploadController := controller.NewUploadController(uploadService)
+	trashService := service.NewTrashService(trashDao, fileDao, blobDao, versionDao, transactor, ingestService, quotaService, storageDriver)
+	trashController := controller.NewTrashController(trashService)
+	versionService := service.NewVersionService(fileDao, versionDao, permissionService, blobDao, transactor, ingestService, quotaService, storageDriver)
+	versionController := controller.NewVersionController(versionService)
+	shareDao := dao.NewShareDao(db)
+	shareService := service.NewShareService(shareDao, fileDao, permissionService, blobDao, versionDao, transactor, ingestService, quotaService, storageDriver)
+	shareController := controller.NewShareController(shareService)
+
+	// 后台任务工作协程
+	go jobQueue.Run(context.Background())
+	// 后台清理过期的分片上传会话
+	go uploadService.RunCleaner(context.Background())
+	// 后台彻底删除超过保留期的回收站条目
+	go trashService.RunPurger(context.Background())
+	// 后台将内存向量库的快照写入磁盘
+	if store, ok := pipeline.Store.(*rag.MemoryStore); ok {
+		go store.RunSnapshots(context.Background())
+	}
 
 	r := gin.Default()
 	// 配置跨域
 	r.Use(middleware.SetupCORS())
 	// 配置路由
-	router.SetUpRouters(r, userController, fileController)
+	router.SetUpRouters(r, userController, fileController, uploadController, trashController, versionController, shareController,
+		permissionController, jobController, quotaController, changeController, searchController, chatController)
 
 	r.Run(":8080")
 }
@@ -50,6 +50,111 @@ type OSSConfig struct {
 	AccessKeySecret string `mapstructure:"access_key_secret"`
 }
 
+type UploadConfig struct {
+	ChunkSize       int64  `mapstructure:"chunk_size"`       // 分片大小（字节），MinIO/OSS 要求除最后一片外不小于 5MB
+	SessionTTL      string `mapstructure:"session_ttl"`      // 分片上传会话有效期，如 24h
+	CleanupInterval string `mapstructure:"cleanup_interval"` // 过期会话清理间隔，如 10m
+}
+
+type TrashConfig struct {
+	Retention      string `mapstructure:"retention"`       // 回收站保留时长，如 720h
+	PurgeInterval  string `mapstructure:"purge_interval"`  // 过期条目清理间隔，如 1h
+	AsyncThreshold int    `mapstructure:"async_threshold"` // 删除的条目数（含子树）超过该值时转为后台任务执行
+}
+
+type VersionConfig struct {
+	Enabled     bool `mapstructure:"enabled"`      // 新用户默认是否开启版本管理
+	MaxVersions int  `mapstructure:"max_versions"` // 默认最多保留的版本数（含当前版本）
+}
+
+type CopyConfig struct {
+	AsyncThreshold int `mapstructure:"async_threshold"` // 复制的文件数超过该值时转为后台任务执行
+}
+
+type ArchiveConfig struct {
+	MaxSize           int64 `mapstructure:"max_size"`            // 打包下载允许的最大内容总大小（字节）
+	ExtractMaxEntries int   `mapstructure:"extract_max_entries"` // 解压时允许的最大条目数
+	ExtractMaxSize    int64 `mapstructure:"extract_max_size"`    // 解压后允许的最大内容总大小（字节）
+	ExtractMaxRatio   int64 `mapstructure:"extract_max_ratio"`   // 允许的最大压缩比（解压后大小/压缩后大小）
+}
+
+type QuotaConfig struct {
+	Default  int64  `mapstructure:"default"`   // 默认用户配额（字节），0 表示不限
+	AdminIDs []uint `mapstructure:"admin_ids"` // 可以调整他人配额的管理员用户ID
+}
+
+type JobConfig struct {
+	Workers      int    `mapstructure:"workers"`       // 执行后台任务的工作协程数
+	PollInterval string `mapstructure:"poll_interval"` // 空闲时查询新任务的间隔，如 2s
+	MaxAttempts  int    `mapstructure:"max_attempts"`  // 任务默认最多执行的次数（含首次）
+	RetryBackoff string `mapstructure:"retry_backoff"` // 首次重试的等待时间，之后每次翻倍，如 10s
+	StaleTimeout string `mapstructure:"stale_timeout"` // 执行中的任务超过该时长没有心跳时视为中断并重新执行，如 1m
+}
+
+type ChangeConfig struct {
+	PollInterval string `mapstructure:"poll_interval"` // 长轮询等待期间查询新变更的间隔，如 1s
+	MaxWait      string `mapstructure:"max_wait"`      // 长轮询最长等待时间，如 60s
+}
+
+type RAGConfig struct {
+	Enabled      bool  `mapstructure:"enabled"`       // 是否为上传的文件建立检索索引
+	MaxFileSize  int64 `mapstructure:"max_file_size"` // 建立索引的文件大小上限（字节）
+	ChunkSize    int   `mapstructure:"chunk_size"`    // 片段长度（字符）
+	ChunkOverlap int   `mapstructure:"chunk_overlap"` // 相邻片段重叠的字符数
+	ChatTopK     int   `mapstructure:"chat_top_k"`    // 问答时检索的片段数
+	ChatContext  int   `mapstructure:"chat_context"`  // 问答时提供给模型的资料总长度上限（字符）
+
+	VectorStore VectorStoreConfig `mapstructure:"vector_store"`
+}
+
+type VectorStoreConfig struct {
+	Type   string            `mapstructure:"type"` // memory/milvus
+	Memory MemoryStoreConfig `mapstructure:"memory"`
+	Milvus MilvusConfig      `mapstructure:"milvus"`
+}
+
+type MemoryStoreConfig struct {
+	Index            string `mapstructure:"index"`             // flat（精确的暴力检索）/ hnsw
+	SnapshotPath     string `mapstructure:"snapshot_path"`     // 快照文件路径，为空时不持久化
+	SnapshotInterval string `mapstructure:"snapshot_interval"` // 有变化时写入快照的间隔，如 1m
+	HNSWM            int    `mapstructure:"hnsw_m"`            // HNSW 每个节点的邻居数
+	EfConstruction   int    `mapstructure:"ef_construction"`   // HNSW 建图时的候选集大小
+	EfSearch         int    `mapstructure:"ef_search"`         // HNSW 检索时的候选集大小
+}
+
+type MilvusConfig struct {
+	Endpoint   string `mapstructure:"endpoint"`   // RESTful 地址，如 http://localhost:19530
+	Token      string `mapstructure:"token"`      // 认证信息，如 root:Milvus，未开启认证时留空
+	Database   string `mapstructure:"database"`   // 数据库名，为空时使用 default
+	Collection string `mapstructure:"collection"` // 集合名，不存在时自动创建
+	Timeout    string `mapstructure:"timeout"`    // 单次请求超时，如 10s
+}
+
+type EmbedderConfig struct {
+	Provider     string `mapstructure:"provider"`      // hash（本地哈希，离线可用）/openai（兼容 OpenAI 的 /v1/embeddings）/ollama
+	BaseURL      string `mapstructure:"base_url"`      // 接口地址，如 https://api.openai.com/v1、http://localhost:11434
+	APIKey       string `mapstructure:"api_key"`       // OpenAI 兼容接口的密钥
+	Model        string `mapstructure:"model"`         // 模型名
+	Dimension    int    `mapstructure:"dimension"`     // 向量维度，须与模型输出一致
+	BatchSize    int    `mapstructure:"batch_size"`    // 每次请求的文本数
+	MaxTokens    int    `mapstructure:"max_tokens"`    // 单条文本的最大 token 数（估算），超出部分截断
+	Concurrency  int    `mapstructure:"concurrency"`   // 同时进行的请求数上限
+	MaxRetries   int    `mapstructure:"max_retries"`   // 请求失败（网络错误、429、5xx）后的重试次数
+	RetryBackoff string `mapstructure:"retry_backoff"` // 首次重试的等待时间，之后按指数增长，如 1s
+	Timeout      string `mapstructure:"timeout"`       // 单次请求超时，如 30s
+}
+
+type LLMConfig struct {
+	Provider    string   `mapstructure:"provider"`    // scripted（按脚本输出，离线可用）/openai（兼容 OpenAI 的 /v1/chat/completions）/ollama
+	BaseURL     string   `mapstructure:"base_url"`    // 接口地址，如 https://api.openai.com/v1、http://localhost:11434
+	APIKey      string   `mapstructure:"api_key"`     // OpenAI 兼容接口的密钥
+	Model       string   `mapstructure:"model"`       // 模型名
+	Temperature float64  `mapstructure:"temperature"` // 采样温度
+	MaxTokens   int      `mapstructure:"max_tokens"`  // 回答的最大 token 数，0 表示由模型决定
+	Timeout     string   `mapstructure:"timeout"`     // 生成一次回答的超时，如 5m
+	Script      []string `mapstructure:"script"`      // scripted：依次输出的回答片段
+}
+
 type CORSConfig struct {
 	AllowOrigins     []string `mapstructure:"allow_origins"`
 	AllowMethods     []string `mapstructure:"allow_methods"`
@@ -64,6 +169,17 @@ type AppConfig struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	Storage  StorageConfig  `mapstructure:"storage"`
+	Upload   UploadConfig   `mapstructure:"upload"`
+	Trash    TrashConfig    `mapstructure:"trash"`
+	Version  VersionConfig  `mapstructure:"version"`
+	Copy     CopyConfig     `mapstructure:"copy"`
+	Archive  ArchiveConfig  `mapstructure:"archive"`
+	Quota    QuotaConfig    `mapstructure:"quota"`
+	Job      JobConfig      `mapstructure:"job"`
+	Change   ChangeConfig   `mapstructure:"change"`
+	RAG      RAGConfig      `mapstructure:"rag"`
+	Embedder EmbedderConfig `mapstructure:"embedder"`
+	LLM      LLMConfig      `mapstructure:"llm"`
 	CORS     CORSConfig     `mapstructure:"cors"`
 }
 
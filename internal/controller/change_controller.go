@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"errors"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChangeController struct {
+	changeService service.ChangeService
+}
+
+func NewChangeController(changeService service.ChangeService) *ChangeController {
+	return &ChangeController{changeService: changeService}
+}
+
+// Cursor 获取当前最新的游标，客户端完成全量列举前调用，之后从该游标开始增量同步
+func (cc *ChangeController) Cursor(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	resp, err := cc.changeService.LatestCursor(userID)
+	if err != nil {
+		response.InternalError(ctx, errcode.InternalServerError, "获取游标失败")
+		return
+	}
+	response.Success(ctx, resp)
+}
+
+// Delta 获取游标之后的变更，has_more 为 true 时应以返回的游标继续请求
+func (cc *ChangeController) Delta(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	cursor, limit, ok := parseDeltaParams(ctx)
+	if !ok {
+		return
+	}
+	resp, err := cc.changeService.Delta(userID, cursor, limit)
+	if err != nil {
+		changeError(ctx, err)
+		return
+	}
+	response.Success(ctx, resp)
+}
+
+// Poll 长轮询：游标之后没有变更时等待，直到有新变更或超时（timeout 秒，默认取配置的上限）
+func (cc *ChangeController) Poll(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	cursor, limit, ok := parseDeltaParams(ctx)
+	if !ok {
+		return
+	}
+	var wait time.Duration
+	if v := ctx.Query("timeout"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds <= 0 {
+			response.ParamError(ctx, errcode.ParamValidateError, "超时参数错误")
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	resp, err := cc.changeService.Poll(ctx.Request.Context(), userID, cursor, limit, wait)
+	if err != nil {
+		changeError(ctx, err)
+		return
+	}
+	response.Success(ctx, resp)
+}
+
+// parseDeltaParams 解析游标与条数参数，参数错误时已写入响应
+func parseDeltaParams(ctx *gin.Context) (int64, int, bool) {
+	cursor, err := strconv.ParseInt(ctx.Query("cursor"), 10, 64)
+	if err != nil {
+		response.ParamError(ctx, errcode.ParamValidateError, service.ErrInvalidCursor.Error())
+		return 0, 0, false
+	}
+	limit := 0
+	if v := ctx.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			response.ParamError(ctx, errcode.ParamValidateError, "条数参数错误")
+			return 0, 0, false
+		}
+	}
+	return cursor, limit, true
+}
+
+func changeError(ctx *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidCursor) {
+		response.ParamError(ctx, errcode.ParamValidateError, err.Error())
+		return
+	}
+	response.InternalError(ctx, errcode.InternalServerError, "获取变更失败")
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ChatController struct {
+	chatService service.ChatService
+}
+
+func NewChatController(chatService service.ChatService) *ChatController {
+	return &ChatController{chatService: chatService}
+}
+
+// Chat 基于调用方的文件回答问题，以 Server-Sent Events 返回：
+// 回答文本以若干 delta 事件流式输出，最后以 citations 事件给出引用的文件与片段；生成中途失败时输出 error 事件
+// 检索阶段的错误（参数、权限等）在开始输出事件前以普通 JSON 响应返回
+func (cc *ChatController) Chat(ctx *gin.Context) {
+	var req model.ChatReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+
+	started := false
+	start := func() {
+		started = true
+		ctx.Header("Content-Type", "text/event-stream")
+		ctx.Header("Cache-Control", "no-cache")
+		ctx.Header("Connection", "keep-alive")
+		ctx.Header("X-Accel-Buffering", "no") // 禁止反向代理缓冲
+		ctx.Status(http.StatusOK)
+	}
+	citations, err := cc.chatService.Chat(ctx.Request.Context(), userID, &req, func(delta string) error {
+		if !started {
+			start()
+		}
+		ctx.SSEvent("delta", gin.H{"content": delta})
+		ctx.Writer.Flush()
+		// 客户端断开后停止生成
+		return ctx.Request.Context().Err()
+	})
+	if err != nil {
+		if !started {
+			searchError(ctx, err, "问答失败")
+			return
+		}
+		log.Printf("问答中途失败: %v", err)
+		ctx.SSEvent("error", gin.H{"message": "生成回答失败"})
+		ctx.Writer.Flush()
+		return
+	}
+	if !started {
+		start()
+	}
+	ctx.SSEvent("citations", gin.H{"citations": citations})
+	ctx.Writer.Flush()
+}
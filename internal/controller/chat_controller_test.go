@@ -0,0 +1,216 @@
+package controller
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"llmcloud/config"
+	"llmcloud/internal/model"
+	"llmcloud/internal/rag"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// chatSearch 返回固定的检索结果
+type chatSearch struct {
+	results []model.SemanticSearchResult
+	err     error
+}
+
+func (s *chatSearch) SemanticSearch(ctx context.Context, userID uint, query string, topK int, folderID string) ([]model.SemanticSearchResult, error) {
+	return s.results, s.err
+}
+
+// chatFiles 只实现问答用到的 GetFilePath，其余方法调用时 panic
+type chatFiles struct {
+	service.FileService
+}
+
+func (chatFiles) GetFilePath(userID uint, fileID string) (string, error) {
+	return "/root/" + fileID + ".md", nil
+}
+
+// failingLLM 先按脚本输出，再以错误结束，模拟生成中途失败
+type failingLLM struct {
+	*rag.ScriptedLLM
+}
+
+func (l failingLLM) Stream(ctx context.Context, messages []rag.Message, onDelta func(delta string) error) error {
+	if err := l.ScriptedLLM.Stream(ctx, messages, onDelta); err != nil {
+		return err
+	}
+	return errors.New("connection reset by model server")
+}
+
+// sseEvent 一个 Server-Sent Event
+type sseEvent struct {
+	name string
+	data string
+}
+
+func parseSSE(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.name != "" {
+				events = append(events, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "event:"):
+			current.name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			current.data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if current.name != "" {
+		events = append(events, current)
+	}
+	return events
+}
+
+// serveChat 以用户 1 的身份请求问答接口
+func serveChat(t *testing.T, search service.SearchService, llm rag.LLM, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	previous := config.AppConfigInstance
+	config.AppConfigInstance = &config.AppConfig{}
+	t.Cleanup(func() { config.AppConfigInstance = previous })
+
+	gin.SetMode(gin.TestMode)
+	cc := NewChatController(service.NewChatService(search, chatFiles{}, llm))
+	r := gin.New()
+	r.POST("/chat", func(ctx *gin.Context) {
+		ctx.Set(utils.UserIDKey, uint(1))
+		cc.Chat(ctx)
+	})
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/chat", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func searchResults() []model.SemanticSearchResult {
+	return []model.SemanticSearchResult{{
+		File:  model.File{ID: "guide", UserID: 1, Name: "guide.md"},
+		Score: 0.9,
+		Chunks: []model.ChunkMatch{
+			{Index: 2, Snippet: "分片默认保存三份副本。", Score: 0.9, Start: 10, End: 21},
+			{Index: 5, Snippet: "副本分布在不同机架上。", Score: 0.7, Start: 40, End: 51},
+		},
+	}}
+}
+
+func TestChatStreamsDeltasThenCitations(t *testing.T) {
+	llm := rag.NewScriptedLLM("每个分片", "保存三份 [1]。")
+	w := serveChat(t, &chatSearch{results: searchResults()}, llm, `{"question":"分片有几份副本？"}`)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	events := parseSSE(t, w.Body.String())
+	if len(events) != 3 {
+		t.Fatalf("got %d events: %+v", len(events), events)
+	}
+	var answer strings.Builder
+	for _, e := range events[:2] {
+		var delta struct {
+			Content string `json:"content"`
+		}
+		if e.name != "delta" || json.Unmarshal([]byte(e.data), &delta) != nil {
+			t.Fatalf("event %+v, want delta", e)
+		}
+		answer.WriteString(delta.Content)
+	}
+	if answer.String() != "每个分片保存三份 [1]。" {
+		t.Errorf("answer = %q", answer.String())
+	}
+
+	last := events[2]
+	var payload struct {
+		Citations []model.Citation `json:"citations"`
+	}
+	if last.name != "citations" || json.Unmarshal([]byte(last.data), &payload) != nil {
+		t.Fatalf("last event %+v, want citations", last)
+	}
+	if len(payload.Citations) != 1 || payload.Citations[0].FileID != "guide" || payload.Citations[0].Path != "/root/guide.md" {
+		t.Fatalf("citations = %+v", payload.Citations)
+	}
+	if chunks := payload.Citations[0].Chunks; len(chunks) != 2 || chunks[0].Ref != 1 || chunks[0].Index != 2 {
+		t.Fatalf("cited chunks = %+v", chunks)
+	}
+
+	// 资料随问题一起交给模型
+	calls := llm.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("LLM called %d times, want 1", len(calls))
+	}
+	prompt := calls[0][len(calls[0])-1].Content
+	if !strings.Contains(prompt, "分片默认保存三份副本。") || !strings.Contains(prompt, "分片有几份副本？") {
+		t.Errorf("prompt does not contain the sources and question: %q", prompt)
+	}
+}
+
+func TestChatReportsMidStreamFailure(t *testing.T) {
+	llm := failingLLM{rag.NewScriptedLLM("部分回答")}
+	w := serveChat(t, &chatSearch{results: searchResults()}, llm, `{"question":"q"}`)
+
+	events := parseSSE(t, w.Body.String())
+	if len(events) != 2 || events[0].name != "delta" || events[1].name != "error" {
+		t.Fatalf("events = %+v, want delta then error", events)
+	}
+	if strings.Contains(events[1].data, "connection reset") {
+		t.Errorf("error event leaks the upstream error: %s", events[1].data)
+	}
+	if strings.Contains(w.Body.String(), "citations") {
+		t.Error("citations sent after a failed answer")
+	}
+}
+
+func TestChatWithoutSourcesSkipsLLM(t *testing.T) {
+	llm := rag.NewScriptedLLM("不应输出")
+	w := serveChat(t, &chatSearch{}, llm, `{"question":"q"}`)
+
+	events := parseSSE(t, w.Body.String())
+	if len(events) != 2 || events[0].name != "delta" || events[1].name != "citations" {
+		t.Fatalf("events = %+v, want delta then citations", events)
+	}
+	if strings.Contains(events[0].data, "不应输出") {
+		t.Error("answer came from the LLM")
+	}
+	if events[1].data != `{"citations":[]}` {
+		t.Errorf("citations = %s, want empty list", events[1].data)
+	}
+	if n := len(llm.Calls()); n != 0 {
+		t.Fatalf("LLM called %d times, want 0", n)
+	}
+}
+
+func TestChatSearchErrorBeforeStream(t *testing.T) {
+	w := serveChat(t, &chatSearch{err: service.ErrSearchDisabled}, rag.NewScriptedLLM(), `{"question":"q"}`)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	var resp struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != errcode.SearchDisabled {
+		t.Fatalf("body = %s, want code %d", w.Body.String(), errcode.SearchDisabled)
+	}
+}
@@ -1,14 +1,16 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"llmcloud/internal/model"
 	"llmcloud/internal/service"
 	"llmcloud/internal/utils"
 	"llmcloud/pkgs/errcode"
 	"llmcloud/pkgs/response"
+	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -42,17 +44,77 @@ func (fc *FileController) Upload(ctx *gin.Context) {
 	}
 	defer file.Close()
 
-	// 4. 获取父目录ID（可选参数）
+	// 4. 获取父目录ID与同名冲突策略（可选参数）
 	parentID := ctx.PostForm("parent_id") // 空字符串表示根目录
+	onConflict := ctx.PostForm("on_conflict")
+	if !model.IsConflictPolicy(onConflict) {
+		response.ParamError(ctx, errcode.ParamValidateError, "冲突策略参数错误")
+		return
+	}
 	// 调用 Service 层处理文件上传
-	err = fc.fileService.UploadFile(userID, fileHeader, file, parentID)
+	err = fc.fileService.UploadFile(ctx.Request.Context(), userID, fileHeader, file, parentID, onConflict)
 	if err != nil {
-		response.InternalError(ctx, errcode.FileUploadFailed, "上传失败")
+		fileError(ctx, err, errcode.FileUploadFailed, "上传失败")
 		return
 	}
 	response.SuccessWithMessage(ctx, "文件上传成功", nil)
 }
 
+// UploadExtract 上传压缩包并在后台解压到目标目录，返回可轮询的任务
+func (fc *FileController) UploadExtract(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "上传失败")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.ParamError(ctx, errcode.FileParseFailed, "上传失败")
+		return
+	}
+	defer file.Close()
+
+	job, err := fc.fileService.UploadAndExtract(ctx.Request.Context(), userID, fileHeader, file, ctx.PostForm("parent_id"), ctx.PostForm("format"))
+	if err != nil {
+		if errors.Is(err, service.ErrArchiveFormat) {
+			response.ParamError(ctx, errcode.ArchiveFormatInvalid, err.Error())
+			return
+		}
+		fileError(ctx, err, errcode.FileUploadFailed, "上传失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "已创建解压任务", job)
+}
+
+// PreCheck 秒传预检，内容已存在时直接创建文件
+func (fc *FileController) PreCheck(ctx *gin.Context) {
+	var req model.PreCheckReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	result, err := fc.fileService.PreCheckUpload(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		fileError(ctx, err, errcode.FileUploadFailed, "预检失败")
+		return
+	}
+	if result.Instant {
+		response.SuccessWithMessage(ctx, "秒传成功", result)
+		return
+	}
+	response.SuccessWithMessage(ctx, "需要上传文件内容", result)
+}
+
 func (fc *FileController) PageList(ctx *gin.Context) {
 	// 获取用户ID并验证
 	userID, err := utils.GetUserIDFromContext(ctx)
@@ -80,7 +142,7 @@ func (fc *FileController) PageList(ctx *gin.Context) {
 
 	total, files, err := fc.fileService.PageList(userID, parentIDPtr, page, pageSize, sort)
 	if err != nil {
-		response.InternalError(ctx, errcode.FileListFailed, "获取文件列表失败")
+		fileError(ctx, err, errcode.FileListFailed, "获取文件列表失败")
 		return
 	}
 	response.PageSuccess(ctx, files, total)
@@ -94,19 +156,73 @@ func (fc *FileController) Download(ctx *gin.Context) {
 		return
 	}
 
-	fileMeta, fileData, err := fc.fileService.DownloadFile(fileID)
+	fileMeta, reader, err := fc.fileService.DownloadFile(ctx.Request.Context(), userID, fileID)
 	if err != nil {
-		response.InternalError(ctx, errcode.FileNotFound, "文件不存在")
+		fileError(ctx, err, errcode.FileNotFound, "文件不存在")
 		return
 	}
-	if userID != fileMeta.UserID {
-		response.UnauthorizedError(ctx, errcode.ForbiddenError, "权限不足")
+	defer reader.Close()
+	serveFile(ctx, fileMeta, reader)
+}
+
+// serveFile 以附件形式写回文件内容
+func serveFile(ctx *gin.Context, fileMeta *model.File, reader io.ReadSeeker) {
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileMeta.Name))
+	if fileMeta.MIMEType != "" {
+		ctx.Header("Content-Type", fileMeta.MIMEType)
+	}
+	ctx.Header("ETag", fileETag(fileMeta))
+	// ServeContent 负责处理 Range/If-Range（206 及多区间响应）
+	// 以及 If-None-Match/If-Modified-Since（304），文件内容以流的方式写回
+	http.ServeContent(ctx.Writer, ctx.Request, fileMeta.Name, fileMeta.UpdatedAt, reader)
+}
+
+// DownloadArchive 将文件夹或多个文件打包为 zip/tar.gz 以流的方式下载
+func (fc *FileController) DownloadArchive(ctx *gin.Context) {
+	fileIDs := ctx.QueryArray("file_id")
+	format := ctx.DefaultQuery("format", model.ArchiveFormatZip)
+	if len(fileIDs) == 0 || (format != model.ArchiveFormatZip && format != model.ArchiveFormatTarGz) {
+		response.ParamError(ctx, errcode.ParamValidateError, "参数错误")
 		return
 	}
-	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileMeta.Name))
-	ctx.Header("Content-Type", fileMeta.MIMEType)
-	ctx.Header("Content-Length", strconv.FormatInt(fileMeta.Size, 10))
-	ctx.Data(http.StatusOK, fileMeta.MIMEType, fileData)
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	archive, err := fc.fileService.PrepareArchive(userID, fileIDs, format)
+	if err != nil {
+		if errors.Is(err, service.ErrArchiveTooLarge) {
+			response.ParamError(ctx, errcode.ArchiveTooLarge, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrArchiveName) {
+			response.ParamError(ctx, errcode.FileNameInvalid, err.Error())
+			return
+		}
+		fileError(ctx, err, errcode.FileNotFound, "打包下载失败")
+		return
+	}
+	contentType := "application/zip"
+	if format == model.ArchiveFormatTarGz {
+		contentType = "application/gzip"
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", archive.Name))
+	ctx.Header("Content-Type", contentType)
+	ctx.Status(http.StatusOK)
+	// 响应头已发出，之后的错误只能中断连接，客户端会得到不完整的归档
+	if err := fc.fileService.WriteArchive(ctx.Request.Context(), archive, ctx.Writer); err != nil {
+		log.Printf("打包下载失败: %v", err)
+		ctx.Abort()
+	}
+}
+
+// fileETag 生成文件的 ETag：有内容哈希时使用强校验值，否则使用基于元数据的弱校验值
+func fileETag(file *model.File) string {
+	if file.Hash != "" {
+		return fmt.Sprintf("\"%s\"", file.Hash)
+	}
+	return fmt.Sprintf("W/\"%s-%d-%d\"", file.ID, file.UpdatedAt.Unix(), file.Size)
 }
 
 func (fc *FileController) Delete(ctx *gin.Context) {
@@ -120,8 +236,13 @@ func (fc *FileController) Delete(ctx *gin.Context) {
 		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户未认证")
 		return
 	}
-	if err := fc.fileService.DeleteFileOrFolder(userID, fileID); err != nil {
-		response.InternalError(ctx, errcode.FileDeleteFailed, "删除失败")
+	job, err := fc.fileService.DeleteFileOrFolder(ctx.Request.Context(), userID, fileID)
+	if err != nil {
+		fileError(ctx, err, errcode.FileDeleteFailed, "删除失败")
+		return
+	}
+	if job != nil {
+		response.SuccessWithMessage(ctx, "已创建删除任务", job)
 		return
 	}
 	response.SuccessWithMessage(ctx, "删除成功", nil)
@@ -145,7 +266,7 @@ func (fc *FileController) CreateFolder(ctx *gin.Context) {
 
 	err = fc.fileService.CreateFolder(userID, req.Name, req.ParentID)
 	if err != nil {
-		response.InternalError(ctx, errcode.InternalServerError, "文件夹创建失败")
+		fileError(ctx, err, errcode.InternalServerError, "文件夹创建失败")
 		return
 	}
 	response.SuccessWithMessage(ctx, "创建成功", nil)
@@ -167,12 +288,56 @@ func (fc *FileController) BatchMove(ctx *gin.Context) {
 	}
 
 	// 执行批量移动
-	if err := fc.fileService.BatchMoveFiles(userID, req.FileIDs, req.TargetParentID); err != nil {
-		response.InternalError(ctx, errcode.InternalServerError, "移动失败")
+	resp, err := fc.fileService.BatchMoveFiles(userID, req.FileIDs, req.TargetParentID, req.Mode, req.OnConflict)
+	batchResponse(ctx, resp, err, errcode.InternalServerError, "移动失败")
+}
+
+// BatchCopy 批量复制文件/文件夹，条目较多时返回后台任务
+func (fc *FileController) BatchCopy(ctx *gin.Context) {
+	var req model.BatchCopyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
 		return
 	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	resp, err := fc.fileService.BatchCopyFiles(ctx.Request.Context(), userID, req.FileIDs, req.TargetParentID, req.Mode, req.OnConflict)
+	batchResponse(ctx, resp, err, errcode.InternalServerError, "复制失败")
+}
 
-	response.SuccessWithMessage(ctx, "移动成功", nil)
+// BatchDelete 批量将文件/文件夹移入回收站
+func (fc *FileController) BatchDelete(ctx *gin.Context) {
+	var req model.BatchDeleteRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户未认证")
+		return
+	}
+	resp, err := fc.fileService.BatchDeleteFiles(userID, req.FileIDs, req.Mode)
+	batchResponse(ctx, resp, err, errcode.FileDeleteFailed, "删除失败")
+}
+
+// BatchRename 批量重命名
+func (fc *FileController) BatchRename(ctx *gin.Context) {
+	var req model.BatchRenameRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	resp, err := fc.fileService.BatchRename(userID, req.Items, req.Mode, req.OnConflict)
+	batchResponse(ctx, resp, err, errcode.InternalServerError, "重命名失败")
 }
 
 func (fc *FileController) Search(ctx *gin.Context) {
@@ -213,8 +378,8 @@ func (fc *FileController) Rename(ctx *gin.Context) {
 		return
 	}
 
-	if err = fc.fileService.Rename(userID, req.FileID, req.NewName); err != nil {
-		response.InternalError(ctx, errcode.InternalServerError, fmt.Sprintf("重命名失败 %s", err))
+	if err = fc.fileService.Rename(userID, req.FileID, req.NewName, req.OnConflict); err != nil {
+		fileError(ctx, err, errcode.InternalServerError, fmt.Sprintf("重命名失败 %s", err))
 		return
 	}
 	response.SuccessWithMessage(ctx, "重命名成功", nil)
@@ -227,10 +392,15 @@ func (fc *FileController) GetPath(ctx *gin.Context) {
 		response.ParamError(ctx, errcode.ParamValidateError, "文件ID不能为空")
 		return
 	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
 	// 获取文件路径
-	path, err := fc.fileService.GetFilePath(fileID)
+	path, err := fc.fileService.GetFilePath(userID, fileID)
 	if err != nil {
-		response.InternalError(ctx, errcode.FileNotFound, "获取文件路径失败")
+		fileError(ctx, err, errcode.FileNotFound, "获取文件路径失败")
 		return
 	}
 	response.SuccessWithMessage(ctx, "获取文件路径成功", gin.H{
@@ -245,11 +415,16 @@ func (fc *FileController) GetIDPath(ctx *gin.Context) {
 		response.ParamError(ctx, errcode.ParamValidateError, "文件ID不能为空")
 		return
 	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
 
 	// 获取文件ID路径
-	path, err := fc.fileService.GetFileIDPath(fileID)
+	path, err := fc.fileService.GetFileIDPath(userID, fileID)
 	if err != nil {
-		response.InternalError(ctx, errcode.FileNotFound, "获取文件路径失败")
+		fileError(ctx, err, errcode.FileNotFound, "获取文件路径失败")
 		return
 	}
 
@@ -257,3 +432,73 @@ func (fc *FileController) GetIDPath(ctx *gin.Context) {
 		"id_path": path,
 	})
 }
+
+// Stats 获取文件夹的大小、文件数与文件夹数，以及各子项的占用情况；file_id 为空时统计根目录
+func (fc *FileController) Stats(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	var folderID *string
+	if fileID := ctx.Query("file_id"); fileID != "" {
+		folderID = &fileID
+	}
+	stats, err := fc.fileService.GetFolderStats(userID, folderID)
+	if err != nil {
+		fileError(ctx, err, errcode.FileNotFound, "获取文件夹统计失败")
+		return
+	}
+	response.Success(ctx, stats)
+}
+
+// fileError 将权限与配额相关的错误映射为对应的错误码，其余错误按 code 返回
+func fileError(ctx *gin.Context, err error, code int, msg string) {
+	status, code := fileErrorCode(err, code)
+	if status == http.StatusInternalServerError {
+		response.InternalError(ctx, code, msg)
+		return
+	}
+	response.ErrorCustom(ctx, status, code, err.Error(), nil)
+}
+
+// fileErrorCode 将服务层错误映射为 HTTP 状态码与业务码，未识别的错误使用 code
+func fileErrorCode(err error, code int) (int, int) {
+	switch {
+	case errors.Is(err, service.ErrFileNotFound):
+		return http.StatusBadRequest, errcode.FileNotFound
+	case errors.Is(err, service.ErrPermissionDenied):
+		return http.StatusUnauthorized, errcode.ForbiddenError
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusBadRequest, errcode.FileSizeExceeded
+	case errors.Is(err, service.ErrNameExists):
+		return http.StatusBadRequest, errcode.FileNameExists
+	case errors.Is(err, service.ErrInvalidName):
+		return http.StatusBadRequest, errcode.FileNameInvalid
+	case errors.Is(err, service.ErrConflictType):
+		return http.StatusBadRequest, errcode.ParamValidateError
+	default:
+		return http.StatusInternalServerError, code
+	}
+}
+
+// batchResponse 输出批量操作结果，并为每个失败条目填写业务码与原因
+// 全部成功模式下失败时整体返回错误，data 中仍包含各条目的状态
+func batchResponse(ctx *gin.Context, resp *model.BatchResp, err error, code int, msg string) {
+	if resp == nil {
+		fileError(ctx, err, code, msg)
+		return
+	}
+	for i := range resp.Items {
+		if item := &resp.Items[i]; item.Err != nil {
+			_, item.Code = fileErrorCode(item.Err, code)
+			item.Message = item.Err.Error()
+		}
+	}
+	if err != nil {
+		status, code := fileErrorCode(err, code)
+		response.ErrorCustom(ctx, status, code, msg, resp)
+		return
+	}
+	response.Success(ctx, resp)
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 基于路径的文件操作，路径参数形如 "/root/datasets/v2/train.csv"
+
+// StatByPath 获取路径对应条目的元数据
+func (fc *FileController) StatByPath(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	file, err := fc.fileService.StatPath(userID, ctx.Query("path"))
+	if err != nil {
+		pathError(ctx, err, errcode.FileNotFound, "获取文件信息失败")
+		return
+	}
+	response.Success(ctx, file)
+}
+
+// ListByPath 分页列出路径对应文件夹的内容
+func (fc *FileController) ListByPath(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	page, pageSize, err := utils.ParsePaginationParams(ctx)
+	if err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "分页参数错误")
+		return
+	}
+	sort := ctx.DefaultQuery("sort", "name:asc")
+	if err := utils.ValidateSortParameter(sort, []string{"name", "update_at"}); err != nil {
+		response.ParamError(ctx, errcode.ParamValidateError, "排序参数错误")
+		return
+	}
+	folder, err := fc.fileService.ResolvePath(userID, ctx.Query("path"))
+	if err != nil {
+		pathError(ctx, err, errcode.FileListFailed, "获取文件列表失败")
+		return
+	}
+	var parentID *string
+	if folder != nil {
+		parentID = &folder.ID
+	}
+	total, files, err := fc.fileService.PageList(userID, parentID, page, pageSize, sort)
+	if err != nil {
+		fileError(ctx, err, errcode.FileListFailed, "获取文件列表失败")
+		return
+	}
+	response.PageSuccess(ctx, files, total)
+}
+
+// DownloadByPath 下载路径对应的文件，支持 Range 与条件请求
+func (fc *FileController) DownloadByPath(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	file, err := fc.fileService.ResolvePath(userID, ctx.Query("path"))
+	if err != nil {
+		pathError(ctx, err, errcode.FileNotFound, "文件不存在")
+		return
+	}
+	if file == nil || file.IsDir {
+		response.ParamError(ctx, errcode.ParamValidateError, "只能下载文件")
+		return
+	}
+	fileMeta, reader, err := fc.fileService.DownloadFile(ctx.Request.Context(), userID, file.ID)
+	if err != nil {
+		fileError(ctx, err, errcode.FileNotFound, "文件不存在")
+		return
+	}
+	defer reader.Close()
+	serveFile(ctx, fileMeta, reader)
+}
+
+// UploadByPath 上传文件到指定路径，路径最后一级为文件名
+func (fc *FileController) UploadByPath(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "上传失败")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.ParamError(ctx, errcode.FileParseFailed, "上传失败")
+		return
+	}
+	defer file.Close()
+
+	parents, _ := strconv.ParseBool(ctx.DefaultPostForm("parents", "false"))
+	onConflict := ctx.PostForm("on_conflict")
+	if !model.IsConflictPolicy(onConflict) {
+		response.ParamError(ctx, errcode.ParamValidateError, "冲突策略参数错误")
+		return
+	}
+	if err := fc.fileService.UploadByPath(ctx.Request.Context(), userID, fileHeader, file, ctx.PostForm("path"), parents, onConflict); err != nil {
+		pathError(ctx, err, errcode.FileUploadFailed, "上传失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "文件上传成功", nil)
+}
+
+// MkdirByPath 按路径创建文件夹
+func (fc *FileController) MkdirByPath(ctx *gin.Context) {
+	var req model.MkdirPathReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	folder, err := fc.fileService.MakeDirs(userID, req.Path, req.Parents)
+	if err != nil {
+		pathError(ctx, err, errcode.InternalServerError, "文件夹创建失败")
+		return
+	}
+	response.Success(ctx, folder)
+}
+
+// MoveByPath 按路径移动或改名
+func (fc *FileController) MoveByPath(ctx *gin.Context) {
+	var req model.MovePathReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := fc.fileService.MovePath(userID, req.From, req.To, req.Parents, req.OnConflict); err != nil {
+		pathError(ctx, err, errcode.InternalServerError, "移动失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "移动成功", nil)
+}
+
+// DeleteByPath 将路径对应的条目移入回收站
+func (fc *FileController) DeleteByPath(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户未认证")
+		return
+	}
+	file, err := fc.fileService.ResolvePath(userID, ctx.Query("path"))
+	if err != nil {
+		pathError(ctx, err, errcode.FileDeleteFailed, "删除失败")
+		return
+	}
+	if file == nil {
+		response.ParamError(ctx, errcode.ParamValidateError, "不能删除根目录")
+		return
+	}
+	job, err := fc.fileService.DeleteFileOrFolder(ctx.Request.Context(), userID, file.ID)
+	if err != nil {
+		fileError(ctx, err, errcode.FileDeleteFailed, "删除失败")
+		return
+	}
+	if job != nil {
+		response.SuccessWithMessage(ctx, "已创建删除任务", job)
+		return
+	}
+	response.SuccessWithMessage(ctx, "删除成功", nil)
+}
+
+func pathError(ctx *gin.Context, err error, code int, msg string) {
+	if errors.Is(err, service.ErrInvalidPath) {
+		response.ParamError(ctx, errcode.ParamValidateError, err.Error())
+		return
+	}
+	fileError(ctx, err, code, msg)
+}
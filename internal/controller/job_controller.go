@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JobController struct {
+	jobService service.JobService
+}
+
+func NewJobController(jobService service.JobService) *JobController {
+	return &JobController{jobService: jobService}
+}
+
+// Get 查询后台任务进度
+func (jc *JobController) Get(ctx *gin.Context) {
+	jobID := ctx.Query("job_id")
+	if jobID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "任务ID不能为空")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	job, err := jc.jobService.GetJob(userID, jobID)
+	if err != nil {
+		jobError(ctx, err, "获取任务失败")
+		return
+	}
+	response.Success(ctx, job)
+}
+
+// List 分页列出当前用户的后台任务，可按状态过滤
+func (jc *JobController) List(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	page, pageSize, err := utils.ParsePaginationParams(ctx)
+	if err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "分页参数错误")
+		return
+	}
+	status := ctx.Query("status")
+	switch status {
+	case "", model.JobStatusPending, model.JobStatusRunning, model.JobStatusSucceeded, model.JobStatusFailed, model.JobStatusCanceled:
+	default:
+		response.ParamError(ctx, errcode.ParamValidateError, "任务状态参数错误")
+		return
+	}
+	total, jobs, err := jc.jobService.ListJobs(userID, status, page, pageSize)
+	if err != nil {
+		response.InternalError(ctx, errcode.InternalServerError, "获取任务列表失败")
+		return
+	}
+	response.PageSuccess(ctx, jobs, total)
+}
+
+// Cancel 取消后台任务
+func (jc *JobController) Cancel(ctx *gin.Context) {
+	var req model.CancelJobReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	job, err := jc.jobService.CancelJob(userID, req.JobID)
+	if err != nil {
+		jobError(ctx, err, "取消任务失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "已取消任务", job)
+}
+
+func jobError(ctx *gin.Context, err error, msg string) {
+	if errors.Is(err, service.ErrJobNotFound) {
+		response.ParamError(ctx, errcode.JobNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrJobFinished) {
+		response.ParamError(ctx, errcode.JobFinished, err.Error())
+		return
+	}
+	response.InternalError(ctx, errcode.InternalServerError, msg)
+}
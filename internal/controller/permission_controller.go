@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PermissionController struct {
+	permissionService service.PermissionService
+}
+
+func NewPermissionController(permissionService service.PermissionService) *PermissionController {
+	return &PermissionController{permissionService: permissionService}
+}
+
+// Grant 将文件或文件夹授权给其他用户
+func (pc *PermissionController) Grant(ctx *gin.Context) {
+	var req model.GrantPermissionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	permission, err := pc.permissionService.GrantPermission(userID, &req)
+	if err != nil {
+		permissionError(ctx, err, "授权失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "授权成功", permission)
+}
+
+// List 列出文件的协作者
+func (pc *PermissionController) List(ctx *gin.Context) {
+	fileID := ctx.Query("file_id")
+	if fileID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "文件ID不能为空")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	permissions, err := pc.permissionService.ListPermissions(userID, fileID)
+	if err != nil {
+		permissionError(ctx, err, "获取协作者失败")
+		return
+	}
+	response.Success(ctx, permissions)
+}
+
+// Revoke 撤销授权或退出共享
+func (pc *PermissionController) Revoke(ctx *gin.Context) {
+	permissionID := ctx.Query("permission_id")
+	if permissionID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "授权ID不能为空")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := pc.permissionService.RevokePermission(userID, permissionID); err != nil {
+		permissionError(ctx, err, "撤销授权失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "撤销授权成功", nil)
+}
+
+// SharedWithMe 列出其他用户共享给我的文件
+func (pc *PermissionController) SharedWithMe(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	files, err := pc.permissionService.SharedWithMe(userID)
+	if err != nil {
+		permissionError(ctx, err, "获取共享文件失败")
+		return
+	}
+	response.Success(ctx, files)
+}
+
+func permissionError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrPermissionNotFound):
+		response.ParamError(ctx, errcode.PermissionNotFound, err.Error())
+	case errors.Is(err, service.ErrGranteeNotFound):
+		response.ParamError(ctx, errcode.UserNotFound, err.Error())
+	default:
+		fileError(ctx, err, errcode.InternalServerError, msg)
+	}
+}
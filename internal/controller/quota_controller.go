@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QuotaController struct {
+	quotaService service.QuotaService
+}
+
+func NewQuotaController(quotaService service.QuotaService) *QuotaController {
+	return &QuotaController{quotaService: quotaService}
+}
+
+// GetUsage 获取当前用户的空间使用情况
+func (qc *QuotaController) GetUsage(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	usage, err := qc.quotaService.GetUsage(userID)
+	if err != nil {
+		quotaError(ctx, err, "获取空间使用情况失败")
+		return
+	}
+	response.Success(ctx, usage)
+}
+
+// SetQuota 管理员设置用户配额
+func (qc *QuotaController) SetQuota(ctx *gin.Context) {
+	var req model.SetQuotaReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := qc.quotaService.SetQuota(userID, &req); err != nil {
+		quotaError(ctx, err, "设置配额失败")
+		return
+	}
+	response.Success(ctx, nil)
+}
+
+func quotaError(ctx *gin.Context, err error, msg string) {
+	if errors.Is(err, service.ErrGranteeNotFound) {
+		response.ParamError(ctx, errcode.UserNotFound, "用户不存在")
+		return
+	}
+	fileError(ctx, err, errcode.InternalServerError, msg)
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"errors"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchController struct {
+	searchService service.SearchService
+}
+
+func NewSearchController(searchService service.SearchService) *SearchController {
+	return &SearchController{searchService: searchService}
+}
+
+// SemanticSearch 按语义检索文件内容，返回命中的文件及片段；folder_id 非空时只检索该文件夹的子树
+func (sc *SearchController) SemanticSearch(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	query := strings.TrimSpace(ctx.Query("query"))
+	if query == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "查询内容不能为空")
+		return
+	}
+	topK := 0
+	if v := ctx.Query("top_k"); v != "" {
+		topK, err = strconv.Atoi(v)
+		if err != nil || topK <= 0 {
+			response.ParamError(ctx, errcode.ParamValidateError, "top_k 参数错误")
+			return
+		}
+	}
+	results, err := sc.searchService.SemanticSearch(ctx.Request.Context(), userID, query, topK, ctx.Query("folder_id"))
+	if err != nil {
+		searchError(ctx, err, "语义搜索失败")
+		return
+	}
+	response.Success(ctx, results)
+}
+
+// searchError 输出检索相关的错误，未识别的错误按文件错误处理
+func searchError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrSearchDisabled):
+		response.ErrorCustom(ctx, http.StatusBadRequest, errcode.SearchDisabled, err.Error(), nil)
+	case errors.Is(err, service.ErrNotFolder):
+		response.ParamError(ctx, errcode.ParamValidateError, err.Error())
+	default:
+		fileError(ctx, err, errcode.FileSearchFailed, msg)
+	}
+}
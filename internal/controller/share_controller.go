@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// shareTokenHeader 访客访问令牌所在的请求头，也可通过 access_token 查询参数传递
+	shareTokenHeader = "X-Share-Token"
+	// shareTicketHeader 下载票据：下载响应中返回，续传时放在请求头（或 ticket 查询参数）中
+	shareTicketHeader = "X-Share-Download-Ticket"
+)
+
+type ShareController struct {
+	shareService service.ShareService
+}
+
+func NewShareController(shareService service.ShareService) *ShareController {
+	return &ShareController{shareService: shareService}
+}
+
+// Create 创建分享链接
+func (sc *ShareController) Create(ctx *gin.Context) {
+	var req model.ShareLinkReq
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.FileID == "" {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	share, err := sc.shareService.CreateShare(userID, &req)
+	if err != nil {
+		shareError(ctx, err, "创建分享失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "创建分享成功", share)
+}
+
+// List 列出当前用户的分享链接
+func (sc *ShareController) List(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	shares, err := sc.shareService.ListShares(userID)
+	if err != nil {
+		shareError(ctx, err, "获取分享列表失败")
+		return
+	}
+	response.Success(ctx, shares)
+}
+
+// Update 修改分享链接
+func (sc *ShareController) Update(ctx *gin.Context) {
+	var req model.ShareLinkReq
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.ShareID == "" {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := sc.shareService.UpdateShare(userID, &req); err != nil {
+		shareError(ctx, err, "修改分享失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "修改分享成功", nil)
+}
+
+// Revoke 撤销分享链接
+func (sc *ShareController) Revoke(ctx *gin.Context) {
+	shareID := ctx.Query("share_id")
+	if shareID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "分享ID不能为空")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := sc.shareService.RevokeShare(userID, shareID); err != nil {
+		shareError(ctx, err, "撤销分享失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "撤销分享成功", nil)
+}
+
+// Meta 获取分享链接的公开信息
+func (sc *ShareController) Meta(ctx *gin.Context) {
+	meta, err := sc.shareService.GetShareMeta(ctx.Param("token"))
+	if err != nil {
+		shareError(ctx, err, "获取分享信息失败")
+		return
+	}
+	response.Success(ctx, meta)
+}
+
+// Auth 校验提取码，返回访客访问令牌
+func (sc *ShareController) Auth(ctx *gin.Context) {
+	var req model.ShareAuthReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	accessToken, err := sc.shareService.AuthShare(ctx.Param("token"), req.Password)
+	if err != nil {
+		shareError(ctx, err, "校验提取码失败")
+		return
+	}
+	response.Success(ctx, gin.H{"access_token": accessToken})
+}
+
+// ListFiles 浏览分享的文件夹
+func (sc *ShareController) ListFiles(ctx *gin.Context) {
+	page, pageSize, err := utils.ParsePaginationParams(ctx)
+	if err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "分页参数错误")
+		return
+	}
+	total, files, err := sc.shareService.ListShareFiles(ctx.Param("token"), shareAccessToken(ctx), ctx.Query("parent_id"), page, pageSize)
+	if err != nil {
+		shareError(ctx, err, "获取文件列表失败")
+		return
+	}
+	response.PageSuccess(ctx, files, total)
+}
+
+// Download 下载分享范围内的文件
+// 下载次数按实际发送的内容计算：携带下载票据的续传请求在累计发送量不超过一个文件时不重复计数，
+// 其余请求各计一次；不支持多段 Range
+func (sc *ShareController) Download(ctx *gin.Context) {
+	share, file, reader, err := sc.shareService.OpenShareFile(ctx.Request.Context(), ctx.Param("token"), shareAccessToken(ctx), ctx.Query("file_id"))
+	if err != nil {
+		shareError(ctx, err, "下载失败")
+		return
+	}
+	defer reader.Close()
+
+	etag := fileETag(file)
+	// 先自行判断 If-Range，使计数所用的长度与 ServeContent 实际发送的范围一致
+	if ifRange := ctx.GetHeader("If-Range"); ifRange != "" {
+		if ifRange != etag {
+			ctx.Request.Header.Del("Range")
+		}
+		ctx.Request.Header.Del("If-Range")
+	}
+	length, err := rangeLength(ctx.GetHeader("Range"), file.Size)
+	if err != nil {
+		ctx.Header("Content-Range", fmt.Sprintf("bytes */%d", file.Size))
+		response.ErrorCustom(ctx, http.StatusRequestedRangeNotSatisfiable, errcode.ParamValidateError, err.Error(), nil)
+		return
+	}
+	ticket := ctx.GetHeader(shareTicketHeader)
+	if ticket == "" {
+		ticket = ctx.Query("ticket")
+	}
+	ticket, err = sc.shareService.StartShareDownload(share, file, ticket, length)
+	if err != nil {
+		shareError(ctx, err, "下载失败")
+		return
+	}
+
+	ctx.Header(shareTicketHeader, ticket)
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", file.Name))
+	if file.MIMEType != "" {
+		ctx.Header("Content-Type", file.MIMEType)
+	}
+	ctx.Header("ETag", etag)
+	w := &countingWriter{ResponseWriter: ctx.Writer}
+	http.ServeContent(w, ctx.Request, file.Name, file.UpdatedAt, reader)
+	sc.shareService.FinishShareDownload(ticket, length-w.written)
+}
+
+// rangeLength 计算 Range 请求头要求的字节数，没有 Range 时为整个文件；多段或无法满足的范围返回错误
+func rangeLength(header string, size int64) (int64, error) {
+	if header == "" {
+		return size, nil
+	}
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, errors.New("不支持的下载范围")
+	}
+	if strings.Contains(spec, ",") {
+		return 0, errors.New("不支持多段下载")
+	}
+	startStr, endStr, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, errors.New("下载范围格式错误")
+	}
+	if startStr == "" {
+		// 后缀范围：最后 n 个字节
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 || size == 0 {
+			return 0, errors.New("下载范围格式错误")
+		}
+		return min(n, size), nil
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, errors.New("下载范围超出文件大小")
+	}
+	end := size - 1
+	if endStr != "" {
+		end, err = strconv.ParseInt(endStr, 10, 64)
+		if err != nil || end < start {
+			return 0, errors.New("下载范围格式错误")
+		}
+		end = min(end, size-1)
+	}
+	return end - start + 1, nil
+}
+
+// countingWriter 统计写出的响应体字节数
+type countingWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Upload 访客向允许上传的分享文件夹上传文件
+func (sc *ShareController) Upload(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "上传失败")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.ParamError(ctx, errcode.FileParseFailed, "上传失败")
+		return
+	}
+	defer file.Close()
+	_, err = sc.shareService.UploadToShare(ctx.Request.Context(), ctx.Param("token"), shareAccessToken(ctx),
+		ctx.PostForm("parent_id"), fileHeader.Filename, file, fileHeader.Size)
+	if err != nil {
+		shareError(ctx, err, "上传失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "文件上传成功", nil)
+}
+
+func shareAccessToken(ctx *gin.Context) string {
+	if token := ctx.GetHeader(shareTokenHeader); token != "" {
+		return token
+	}
+	return ctx.Query("access_token")
+}
+
+func shareError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrShareNotFound):
+		response.ParamError(ctx, errcode.ShareLinkNotFound, err.Error())
+	case errors.Is(err, service.ErrShareExpired):
+		response.ParamError(ctx, errcode.ShareLinkExpired, err.Error())
+	case errors.Is(err, service.ErrSharePasswordRequired):
+		response.UnauthorizedError(ctx, errcode.SharePasswordRequired, err.Error())
+	case errors.Is(err, service.ErrSharePasswordInvalid):
+		response.UnauthorizedError(ctx, errcode.SharePasswordInvalid, err.Error())
+	case errors.Is(err, service.ErrShareDownloadLimit):
+		response.ParamError(ctx, errcode.ShareDownloadLimit, err.Error())
+	case errors.Is(err, service.ErrShareReadOnly), errors.Is(err, service.ErrShareOutOfScope):
+		response.UnauthorizedError(ctx, errcode.ForbiddenError, err.Error())
+	case errors.Is(err, service.ErrQuotaExceeded):
+		response.ParamError(ctx, errcode.FileSizeExceeded, err.Error())
+	default:
+		fileError(ctx, err, errcode.FileNotFound, msg)
+	}
+}
@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TrashController struct {
+	trashService service.TrashService
+}
+
+func NewTrashController(trashService service.TrashService) *TrashController {
+	return &TrashController{trashService: trashService}
+}
+
+// List 分页列出回收站条目
+func (tc *TrashController) List(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	page, pageSize, err := utils.ParsePaginationParams(ctx)
+	if err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "分页参数错误")
+		return
+	}
+	total, items, err := tc.trashService.ListTrash(userID, page, pageSize)
+	if err != nil {
+		response.InternalError(ctx, errcode.FileListFailed, "获取回收站列表失败")
+		return
+	}
+	response.PageSuccess(ctx, items, total)
+}
+
+// Restore 恢复回收站条目
+func (tc *TrashController) Restore(ctx *gin.Context) {
+	var req model.TrashIDsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := tc.trashService.RestoreTrash(userID, req.TrashIDs); err != nil {
+		trashError(ctx, err, "恢复失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "恢复成功", nil)
+}
+
+// Delete 彻底删除回收站条目
+func (tc *TrashController) Delete(ctx *gin.Context) {
+	var req model.TrashIDsReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := tc.trashService.DeleteTrash(ctx.Request.Context(), userID, req.TrashIDs); err != nil {
+		trashError(ctx, err, "删除失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "删除成功", nil)
+}
+
+// Empty 清空回收站
+func (tc *TrashController) Empty(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := tc.trashService.EmptyTrash(ctx.Request.Context(), userID); err != nil {
+		response.InternalError(ctx, errcode.FileDeleteFailed, "清空回收站失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "回收站已清空", nil)
+}
+
+func trashError(ctx *gin.Context, err error, msg string) {
+	if errors.Is(err, service.ErrTrashItemNotFound) {
+		response.ParamError(ctx, errcode.TrashItemNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, service.ErrNameExists) {
+		response.ParamError(ctx, errcode.FileNameExists, err.Error())
+		return
+	}
+	response.InternalError(ctx, errcode.FileDeleteFailed, msg)
+}
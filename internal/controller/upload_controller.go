@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UploadController struct {
+	uploadService service.UploadService
+}
+
+func NewUploadController(uploadService service.UploadService) *UploadController {
+	return &UploadController{uploadService: uploadService}
+}
+
+// InitUpload 创建分片上传会话
+func (uc *UploadController) InitUpload(ctx *gin.Context) {
+	var req model.InitUploadReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	session, err := uc.uploadService.InitUpload(ctx.Request.Context(), userID, &req)
+	if err != nil {
+		uploadError(ctx, err, "初始化上传失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "上传会话创建成功", session)
+}
+
+// UploadChunk 上传单个分片，请求体为分片原始数据
+func (uc *UploadController) UploadChunk(ctx *gin.Context) {
+	uploadID := ctx.Query("upload_id")
+	index, err := strconv.Atoi(ctx.Query("index"))
+	if uploadID == "" || err != nil {
+		response.ParamError(ctx, errcode.ParamValidateError, "参数错误")
+		return
+	}
+	if ctx.Request.ContentLength < 0 {
+		response.ParamError(ctx, errcode.UploadChunkInvalid, "缺少 Content-Length")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	err = uc.uploadService.UploadChunk(ctx.Request.Context(), userID, uploadID, index, ctx.Request.Body, ctx.Request.ContentLength)
+	if err != nil {
+		uploadError(ctx, err, "分片上传失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "分片上传成功", nil)
+}
+
+// GetUploadStatus 查询已上传的分片
+func (uc *UploadController) GetUploadStatus(ctx *gin.Context) {
+	uploadID := ctx.Query("upload_id")
+	if uploadID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	status, err := uc.uploadService.GetUploadStatus(userID, uploadID)
+	if err != nil {
+		uploadError(ctx, err, "获取上传状态失败")
+		return
+	}
+	response.Success(ctx, status)
+}
+
+// CompleteUpload 合并分片，生成文件
+func (uc *UploadController) CompleteUpload(ctx *gin.Context) {
+	var req model.CompleteUploadReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	file, err := uc.uploadService.CompleteUpload(ctx.Request.Context(), userID, req.UploadID)
+	if err != nil {
+		uploadError(ctx, err, "合并文件失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "文件上传成功", file)
+}
+
+// AbortUpload 取消上传会话
+func (uc *UploadController) AbortUpload(ctx *gin.Context) {
+	uploadID := ctx.Query("upload_id")
+	if uploadID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := uc.uploadService.AbortUpload(ctx.Request.Context(), userID, uploadID); err != nil {
+		uploadError(ctx, err, "取消上传失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "已取消上传", nil)
+}
+
+// uploadError 将分片上传相关错误映射为业务错误码
+func uploadError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrUploadSessionNotFound):
+		response.ParamError(ctx, errcode.UploadSessionNotFound, err.Error())
+	case errors.Is(err, service.ErrUploadSessionExpired):
+		response.ParamError(ctx, errcode.UploadSessionExpired, err.Error())
+	case errors.Is(err, service.ErrUploadChunkInvalid):
+		response.ParamError(ctx, errcode.UploadChunkInvalid, err.Error())
+	case errors.Is(err, service.ErrUploadIncomplete):
+		response.ParamError(ctx, errcode.UploadIncomplete, err.Error())
+	case errors.Is(err, service.ErrFileHashMismatch):
+		response.ParamError(ctx, errcode.FileHashMismatch, err.Error())
+	default:
+		fileError(ctx, err, errcode.FileUploadFailed, msg)
+	}
+}
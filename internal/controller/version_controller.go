@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"llmcloud/internal/model"
+	"llmcloud/internal/service"
+	"llmcloud/internal/utils"
+	"llmcloud/pkgs/errcode"
+	"llmcloud/pkgs/response"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VersionController struct {
+	versionService service.VersionService
+}
+
+func NewVersionController(versionService service.VersionService) *VersionController {
+	return &VersionController{versionService: versionService}
+}
+
+// List 列出文件的全部版本
+func (vc *VersionController) List(ctx *gin.Context) {
+	fileID := ctx.Query("file_id")
+	if fileID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "文件ID不能为空")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	versions, err := vc.versionService.ListVersions(userID, fileID)
+	if err != nil {
+		versionError(ctx, err, "获取版本列表失败")
+		return
+	}
+	response.Success(ctx, versions)
+}
+
+// Download 下载指定历史版本
+func (vc *VersionController) Download(ctx *gin.Context) {
+	fileID := ctx.Query("file_id")
+	versionID := ctx.Query("version_id")
+	if fileID == "" || versionID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	file, version, reader, err := vc.versionService.OpenVersion(ctx.Request.Context(), userID, fileID, versionID)
+	if err != nil {
+		versionError(ctx, err, "下载失败")
+		return
+	}
+	defer reader.Close()
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", file.Name))
+	if version.MIMEType != "" {
+		ctx.Header("Content-Type", version.MIMEType)
+	}
+	if version.Hash != "" {
+		ctx.Header("ETag", fmt.Sprintf("\"%s\"", version.Hash))
+	}
+	http.ServeContent(ctx.Writer, ctx.Request, file.Name, version.CreatedAt, reader)
+}
+
+// Restore 将历史版本恢复为当前版本
+func (vc *VersionController) Restore(ctx *gin.Context) {
+	var req model.RestoreVersionReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	file, err := vc.versionService.RestoreVersion(ctx.Request.Context(), userID, req.FileID, req.VersionID)
+	if err != nil {
+		versionError(ctx, err, "恢复版本失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "恢复成功", file)
+}
+
+// Delete 删除历史版本
+func (vc *VersionController) Delete(ctx *gin.Context) {
+	fileID := ctx.Query("file_id")
+	versionID := ctx.Query("version_id")
+	if fileID == "" || versionID == "" {
+		response.ParamError(ctx, errcode.ParamValidateError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := vc.versionService.DeleteVersion(ctx.Request.Context(), userID, fileID, versionID); err != nil {
+		versionError(ctx, err, "删除版本失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "删除成功", nil)
+}
+
+// GetPolicy 获取当前用户的版本策略
+func (vc *VersionController) GetPolicy(ctx *gin.Context) {
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	policy, err := vc.versionService.GetPolicy(userID)
+	if err != nil {
+		response.InternalError(ctx, errcode.InternalServerError, "获取版本策略失败")
+		return
+	}
+	response.Success(ctx, gin.H{
+		"enabled":      policy.Enabled,
+		"max_versions": policy.MaxVersions,
+	})
+}
+
+// UpdatePolicy 更新当前用户的版本策略
+func (vc *VersionController) UpdatePolicy(ctx *gin.Context) {
+	var req model.VersionPolicyReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		response.ParamError(ctx, errcode.ParamBindError, "参数错误")
+		return
+	}
+	userID, err := utils.GetUserIDFromContext(ctx)
+	if err != nil {
+		response.UnauthorizedError(ctx, errcode.UnauthorizedError, "用户验证失败")
+		return
+	}
+	if err := vc.versionService.UpdatePolicy(userID, &req); err != nil {
+		response.InternalError(ctx, errcode.InternalServerError, "更新版本策略失败")
+		return
+	}
+	response.SuccessWithMessage(ctx, "更新成功", nil)
+}
+
+func versionError(ctx *gin.Context, err error, msg string) {
+	switch {
+	case errors.Is(err, service.ErrFileVersionNotFound):
+		response.ParamError(ctx, errcode.FileVersionNotFound, err.Error())
+	default:
+		fileError(ctx, err, errcode.FileNotFound, msg)
+	}
+}
@@ -0,0 +1,112 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBlobNotFound 表示对象未登记引用计数（例如启用去重之前上传的文件）
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobDao 定义了内容寻址对象及其引用计数的数据访问接口
+type BlobDao interface {
+	GetBlob(hash string) (*model.Blob, error)
+	AcquireOrCreate(blob *model.Blob) (*model.Blob, error)
+	AcquireExisting(hash string, size int64) (*model.Blob, error)
+	Release(hash string) (*model.Blob, error)
+}
+
+type blobDao struct {
+	db *gorm.DB
+}
+
+// GetBlob 根据哈希获取对象，不存在时返回 nil
+func (bd *blobDao) GetBlob(hash string) (*model.Blob, error) {
+	var blob model.Blob
+	if err := bd.db.Where("hash = ?", hash).First(&blob).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// AcquireOrCreate 登记一个新写入的对象：哈希不存在时插入并计数为 1，已存在时引用计数加 1
+// 返回数据库中实际生效的记录，调用方通过比较 StorageKey 判断自己写入的对象是否重复
+func (bd *blobDao) AcquireOrCreate(blob *model.Blob) (*model.Blob, error) {
+	var result model.Blob
+	err := bd.db.Transaction(func(tx *gorm.DB) error {
+		blob.RefCount = 1
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hash"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("ref_count + 1")}),
+		}).Create(blob).Error; err != nil {
+			return err
+		}
+		return tx.Where("hash = ?", blob.Hash).First(&result).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AcquireExisting 仅当对象已存在且大小一致时引用计数加 1，用于秒传；对象不存在时返回 nil
+func (bd *blobDao) AcquireExisting(hash string, size int64) (*model.Blob, error) {
+	var result model.Blob
+	found := false
+	err := bd.db.Transaction(func(tx *gorm.DB) error {
+		update := tx.Model(&model.Blob{}).
+			Where("hash = ? AND size = ? AND ref_count > 0", hash, size).
+			Update("ref_count", gorm.Expr("ref_count + 1"))
+		if update.Error != nil {
+			return update.Error
+		}
+		if update.RowsAffected == 0 {
+			return nil
+		}
+		found = true
+		return tx.Where("hash = ?", hash).First(&result).Error
+	})
+	if err != nil || !found {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Release 引用计数减 1；当最后一个引用释放时删除记录并返回该对象，由调用方删除存储
+func (bd *blobDao) Release(hash string) (*model.Blob, error) {
+	var removed *model.Blob
+	err := bd.db.Transaction(func(tx *gorm.DB) error {
+		var blob model.Blob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("hash = ?", hash).First(&blob).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBlobNotFound
+			}
+			return err
+		}
+		if blob.RefCount > 1 {
+			return tx.Model(&model.Blob{}).Where("hash = ?", hash).
+				Update("ref_count", gorm.Expr("ref_count - 1")).Error
+		}
+		if err := tx.Where("hash = ?", hash).Delete(&model.Blob{}).Error; err != nil {
+			return err
+		}
+		removed = &blob
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// NewBlobDao 创建并返回一个新的BlobDao实例
+func NewBlobDao(db *gorm.DB) BlobDao {
+	return &blobDao{db: db}
+}
@@ -0,0 +1,102 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChangeRecorder 写入变更日志
+type ChangeRecorder interface {
+	Record(changes ...*model.Change) error
+}
+
+// ChangeDao 定义了变更日志的数据访问接口
+type ChangeDao interface {
+	ChangeRecorder
+	ListChanges(userID uint, after int64, limit int) ([]model.Change, error)
+	LatestSeq(userID uint) (int64, error)
+}
+
+type changeDao struct {
+	db *gorm.DB
+}
+
+// Record 为变更按用户分配连续的序号并写入日志
+// 分配序号时锁定用户的序号行直到事务提交，序号较大的变更不会先于较小的变更可见，客户端按游标读取不会遗漏
+func (cd *changeDao) Record(changes ...*model.Change) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	byUser := make(map[uint][]*model.Change)
+	var users []uint
+	for _, c := range changes {
+		if byUser[c.UserID] == nil {
+			users = append(users, c.UserID)
+		}
+		byUser[c.UserID] = append(byUser[c.UserID], c)
+	}
+	return cd.db.Transaction(func(tx *gorm.DB) error {
+		for _, userID := range users {
+			userChanges := byUser[userID]
+			if err := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&model.ChangeSeq{UserID: userID}).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&model.ChangeSeq{}).Where("user_id = ?", userID).
+				Update("seq", gorm.Expr("seq + ?", len(userChanges))).Error; err != nil {
+				return err
+			}
+			var seq model.ChangeSeq
+			if err := tx.Where("user_id = ?", userID).First(&seq).Error; err != nil {
+				return err
+			}
+			first := seq.Seq - int64(len(userChanges)) + 1
+			for i, c := range userChanges {
+				c.Seq = first + int64(i)
+			}
+			if err := tx.Create(userChanges).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListChanges 按序号升序列出序号大于 after 的变更
+func (cd *changeDao) ListChanges(userID uint, after int64, limit int) ([]model.Change, error) {
+	var changes []model.Change
+	if err := cd.db.Where("user_id = ? AND seq > ?", userID, after).Order("seq").
+		Limit(limit).Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// LatestSeq 返回用户当前最大的变更序号，没有变更时为 0
+func (cd *changeDao) LatestSeq(userID uint) (int64, error) {
+	var seq model.ChangeSeq
+	if err := cd.db.Where("user_id = ?", userID).First(&seq).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return seq.Seq, nil
+}
+
+// changeBuffer 事务内的变更先暂存，在事务提交前统一写入，缩短持有序号行锁的时间
+type changeBuffer struct {
+	changes []*model.Change
+}
+
+func (cb *changeBuffer) Record(changes ...*model.Change) error {
+	cb.changes = append(cb.changes, changes...)
+	return nil
+}
+
+// NewChangeDao 创建并返回一个新的ChangeDao实例
+func NewChangeDao(db *gorm.DB) ChangeDao {
+	return &changeDao{db: db}
+}
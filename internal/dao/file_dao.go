@@ -5,21 +5,35 @@ import (
 	"fmt"
 	"llmcloud/internal/model"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
+// ErrNameExists 同一目录下已存在同名条目，由唯一索引 model.FileSiblingNameIndex 保证
+var ErrNameExists = errors.New("目标位置已存在同名条目")
+
 // FileDao 定义了文件操作的接口
 type FileDao interface {
 	CreateFile(file *model.File) error
 	GetFilesByParentID(userID uint, parentID *string) ([]model.File, error)
 	GetFileMetaByFileID(id string) (*model.File, error)
+	GetFileByName(userID uint, parentID *string, name string) (*model.File, error)
 	DeleteFile(id string) error
+	PurgeFile(id string) error
 	ListFiles(userID uint, parentID *string, page int, pageSize int, sort string) ([]model.File, error)
 	CountFilesByParentID(parentID *string, userID uint) (int64, error)
 	UpdateFile(file *model.File) error
 	CountFilesByKeyword(key string, userID uint) (int64, error)
 	GetFilesByKeyword(userID uint, key string, page int, pageSize int, sort string) ([]model.File, error)
+	AddFolderStats(ids []string, delta model.FolderStats) error
+	GetFilesByIDs(ids []string) ([]model.File, error)
+	GetSubtree(root *model.File) ([]model.File, error)
+	MoveFile(file *model.File, name string, parentID *string) error
+	UpdateIngestStatus(id string, status string, errMsg string) error
+	HasContent(userID uint, hash string, size int64) (bool, error)
 }
 
 // fileDao 实现了FileDao接口，提供文件相关操作
@@ -70,7 +84,15 @@ func (fd *fileDao) CreateFile(file *model.File) error {
 	if fd.db == nil {
 		return errors.New("数据库未初始化")
 	}
-	return fd.db.Create(file).Error
+	// 调用方未给出 Path 时按父目录计算
+	if file.Path == "" {
+		path, err := childPathOf(fd.db, file.ParentID)
+		if err != nil {
+			return err
+		}
+		file.Path = path
+	}
+	return nameConflict(fd.db.Create(file).Error)
 }
 
 // GetFilesByParentID 根据父ID获取文件列表
@@ -121,6 +143,24 @@ func (fd *fileDao) GetFileMetaByFileID(id string) (*model.File, error) {
 	return &file, nil
 }
 
+// GetFileByName 获取指定目录下的同名文件，不存在时返回 nil
+func (fd *fileDao) GetFileByName(userID uint, parentID *string, name string) (*model.File, error) {
+	var file model.File
+	query := fd.db.Where("user_id = ? AND name = ?", userID, name)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	if err := query.First(&file).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &file, nil
+}
+
 // DeleteFile 根据文件ID删除文件记录
 // 参数:
 //
@@ -136,6 +176,11 @@ func (fd *fileDao) DeleteFile(id string) error {
 	return nil
 }
 
+// PurgeFile 彻底删除文件记录，用于不经过回收站、也无需恢复的条目
+func (fd *fileDao) PurgeFile(id string) error {
+	return fd.db.Unscoped().Where("id = ?", id).Delete(&model.File{}).Error
+}
+
 // ListFiles 列出文件列表，根据指定的排序方式和分页参数
 // 参数:
 //
@@ -214,7 +259,122 @@ func (fd *fileDao) UpdateFile(file *model.File) error {
 	if fd.db == nil {
 		return errors.New("数据库未初始化")
 	}
-	return fd.db.Save(file).Error
+	return nameConflict(fd.db.Save(file).Error)
+}
+
+// AddFolderStats 将增量累加到指定文件夹的子树统计上
+// 统计列不随 Save 写回（见 model.File），只能通过原子的增量更新修改，避免并发覆盖
+func (fd *fileDao) AddFolderStats(ids []string, delta model.FolderStats) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return fd.db.Exec("UPDATE files SET tree_size = tree_size + ?, file_count = file_count + ?, folder_count = folder_count + ? WHERE id IN ?",
+		delta.Size, delta.FileCount, delta.FolderCount, ids).Error
+}
+
+// UpdateIngestStatus 更新文件的检索索引状态，不改变文件的修改时间
+func (fd *fileDao) UpdateIngestStatus(id string, status string, errMsg string) error {
+	if len(errMsg) > 255 {
+		errMsg = errMsg[:255]
+		for !utf8.ValidString(errMsg) {
+			errMsg = errMsg[:len(errMsg)-1]
+		}
+	}
+	return fd.db.Exec("UPDATE files SET ingest_status = ?, ingest_error = ? WHERE id = ?", status, errMsg, id).Error
+}
+
+// HasContent 判断用户是否已持有指定内容：用户拥有或上传过的文件（含回收站中的文件与历史版本）
+func (fd *fileDao) HasContent(userID uint, hash string, size int64) (bool, error) {
+	var count int64
+	if err := fd.db.Unscoped().Model(&model.File{}).
+		Where("(user_id = ? OR uploader_id = ?) AND hash = ? AND size = ? AND is_dir = ?", userID, userID, hash, size, false).
+		Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+	if err := fd.db.Table("file_versions").
+		Joins("JOIN files ON files.id = file_versions.file_id").
+		Where("(files.user_id = ? OR file_versions.uploader_id = ?) AND file_versions.hash = ? AND file_versions.size = ?", userID, userID, hash, size).
+		Limit(1).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetFilesByIDs 批量获取文件，不存在的ID被忽略
+func (fd *fileDao) GetFilesByIDs(ids []string) ([]model.File, error) {
+	var files []model.File
+	if len(ids) == 0 {
+		return files, nil
+	}
+	if err := fd.db.Where("id IN ?", ids).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// GetSubtree 返回 root 及其全部后代，按深度排列，保证父目录先于其子项
+func (fd *fileDao) GetSubtree(root *model.File) ([]model.File, error) {
+	if !root.IsDir {
+		return []model.File{*root}, nil
+	}
+	var files []model.File
+	if err := fd.db.Where("id = ? OR (user_id = ? AND path LIKE ?)", root.ID, root.UserID, model.ChildPath(root)+"%").
+		Order("CHAR_LENGTH(path)").Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// MoveFile 将文件移动到 parentID 下并重命名为 name，文件夹的子树随之移动
+func (fd *fileDao) MoveFile(file *model.File, name string, parentID *string) error {
+	return nameConflict(fd.db.Transaction(func(tx *gorm.DB) error {
+		return relocate(tx, file, name, parentID)
+	}))
+}
+
+// childPathOf 返回 parentID 下新条目的 Path，parentID 为 nil 表示根目录
+func childPathOf(tx *gorm.DB, parentID *string) (string, error) {
+	if parentID == nil {
+		return model.RootPath, nil
+	}
+	var parent model.File
+	if err := tx.Select("id", "path").Where("id = ?", *parentID).First(&parent).Error; err != nil {
+		return "", fmt.Errorf("获取父目录失败: %w", err)
+	}
+	return model.ChildPath(&parent), nil
+}
+
+// relocate 将 file 挂到 parentID 下，并以一次更新改写其子树的 Path
+// 子树中已单独移入回收站的后代也一并改写，恢复时才能找到正确的位置
+func relocate(tx *gorm.DB, file *model.File, name string, parentID *string) error {
+	newPath, err := childPathOf(tx, parentID)
+	if err != nil {
+		return err
+	}
+	oldPrefix := model.ChildPath(file)
+	// 父目录与名称在同一条语句中修改，避免中间状态与目标目录或原目录中的同名条目冲突
+	now := time.Now()
+	if err := tx.Exec("UPDATE files SET parent_id = ?, path = ?, name = ?, updated_at = ? WHERE id = ?", parentID, newPath, name, now, file.ID).Error; err != nil {
+		return err
+	}
+	file.ParentID, file.Path, file.Name, file.UpdatedAt = parentID, newPath, name, now
+	if !file.IsDir {
+		return nil
+	}
+	return tx.Exec("UPDATE files SET path = CONCAT(?, SUBSTRING(path, ?)) WHERE user_id = ? AND path LIKE ?",
+		model.ChildPath(file), len(oldPrefix)+1, file.UserID, oldPrefix+"%").Error
+}
+
+// nameConflict 将违反同名唯一索引的错误转换为 ErrNameExists，其他错误原样返回
+func nameConflict(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 && strings.Contains(mysqlErr.Message, model.FileSiblingNameIndex) {
+		return ErrNameExists
+	}
+	return err
 }
 
 // NewFileDao 创建并返回一个新的FileDao实例
@@ -0,0 +1,267 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrJobFinished 任务已结束，不能再取消
+var ErrJobFinished = errors.New("任务已结束")
+
+// jobExhaustedError 执行次数用完的任务在中断后被标记为失败时记录的原因
+const jobExhaustedError = "任务执行中断，且已达到最大执行次数"
+
+// jobExhausted 执行次数已用完的条件，max_attempts <= 0 表示不限次数
+const jobExhausted = "max_attempts > 0 AND attempts >= max_attempts"
+
+// JobDao 定义了后台任务的数据访问接口
+type JobDao interface {
+	CreateJob(job *model.Job) error
+	GetJob(id string) (*model.Job, error)
+	CountJobs(userID uint, status string) (int64, error)
+	ListJobs(userID uint, status string, page int, pageSize int) ([]model.Job, error)
+	ClaimJob(workerID string, now time.Time) (*model.Job, error)
+	Heartbeat(id string) (bool, error)
+	UpdateProgress(id string, done int64, total int64) error
+	RetryJob(id string, errMsg string, runAt time.Time) error
+	ReleaseJob(id string) error
+	FinishJob(id string, status string, errMsg string) error
+	CancelJob(id string) (*model.Job, error)
+	RequeueStale(before time.Time) (int64, []model.Job, error)
+}
+
+type jobDao struct {
+	db *gorm.DB
+}
+
+// CreateJob 创建任务
+func (jd *jobDao) CreateJob(job *model.Job) error {
+	return jd.db.Create(job).Error
+}
+
+// GetJob 根据ID获取任务，不存在时返回 nil
+func (jd *jobDao) GetJob(id string) (*model.Job, error) {
+	var job model.Job
+	if err := jd.db.Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (jd *jobDao) userJobs(userID uint, status string) *gorm.DB {
+	query := jd.db.Model(&model.Job{}).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	return query
+}
+
+// CountJobs 统计用户的任务数量，status 为空时不按状态过滤
+func (jd *jobDao) CountJobs(userID uint, status string) (int64, error) {
+	var total int64
+	if err := jd.userJobs(userID, status).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// ListJobs 按创建时间倒序分页列出用户的任务
+func (jd *jobDao) ListJobs(userID uint, status string, page int, pageSize int) ([]model.Job, error) {
+	var jobs []model.Job
+	offset := (page - 1) * pageSize
+	if err := jd.userJobs(userID, status).Order("created_at desc").
+		Offset(offset).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ClaimJob 领取一个已到执行时间的等待中任务并置为执行中，没有可执行的任务时返回 nil
+// SKIP LOCKED 使多个工作协程（或多个实例）并发领取时互不阻塞，也不会领到同一个任务
+// 执行次数已用完的等待中任务不再执行，直接标记为失败
+func (jd *jobDao) ClaimJob(workerID string, now time.Time) (*model.Job, error) {
+	for {
+		claimed, skipped, err := jd.claim(workerID, now)
+		if err != nil || !skipped {
+			return claimed, err
+		}
+	}
+}
+
+// claim 尝试领取一个任务，skipped 表示领到的任务执行次数已用完并已标记为失败
+func (jd *jobDao) claim(workerID string, now time.Time) (claimed *model.Job, skipped bool, err error) {
+	err = jd.db.Transaction(func(tx *gorm.DB) error {
+		var job model.Job
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", model.JobStatusPending, now).
+			Order("run_at").First(&job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		if job.MaxAttempts > 0 && job.Attempts >= job.MaxAttempts {
+			skipped = true
+			return tx.Model(&model.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+				"status":      model.JobStatusFailed,
+				"error":       jobExhaustedError,
+				"finished_at": now,
+			}).Error
+		}
+		job.Status = model.JobStatusRunning
+		job.Attempts++
+		job.WorkerID = workerID
+		job.HeartbeatAt = &now
+		if err := tx.Model(&model.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"worker_id":    workerID,
+			"heartbeat_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		claimed = &job
+		return nil
+	})
+	return claimed, skipped, err
+}
+
+// Heartbeat 刷新执行中任务的心跳时间，返回用户是否已请求取消
+func (jd *jobDao) Heartbeat(id string) (bool, error) {
+	if err := jd.db.Model(&model.Job{}).Where("id = ? AND status = ?", id, model.JobStatusRunning).
+		Update("heartbeat_at", time.Now()).Error; err != nil {
+		return false, err
+	}
+	var job model.Job
+	if err := jd.db.Select("cancel_requested").Where("id = ?", id).First(&job).Error; err != nil {
+		return false, err
+	}
+	return job.CancelRequested, nil
+}
+
+// UpdateProgress 更新任务进度，total 用于执行前无法确定条目数的任务
+func (jd *jobDao) UpdateProgress(id string, done int64, total int64) error {
+	return jd.db.Model(&model.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"done":  done,
+		"total": total,
+	}).Error
+}
+
+// RetryJob 记录本次失败原因，任务回到等待状态并在 runAt 之后重新执行
+func (jd *jobDao) RetryJob(id string, errMsg string, runAt time.Time) error {
+	return jd.db.Model(&model.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       model.JobStatusPending,
+		"error":        errMsg,
+		"run_at":       runAt,
+		"worker_id":    "",
+		"heartbeat_at": nil,
+	}).Error
+}
+
+// ReleaseJob 服务停止时归还执行中的任务，不计入执行次数
+// 只能用于可以重复执行的任务，不可重复执行的任务中断后应直接结束
+func (jd *jobDao) ReleaseJob(id string) error {
+	return jd.db.Model(&model.Job{}).Where("id = ? AND status = ?", id, model.JobStatusRunning).Updates(map[string]interface{}{
+		"status":       model.JobStatusPending,
+		"attempts":     gorm.Expr("GREATEST(attempts - 1, 0)"),
+		"worker_id":    "",
+		"heartbeat_at": nil,
+	}).Error
+}
+
+// FinishJob 记录任务的最终状态
+func (jd *jobDao) FinishJob(id string, status string, errMsg string) error {
+	return jd.db.Model(&model.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       status,
+		"error":        errMsg,
+		"worker_id":    "",
+		"heartbeat_at": nil,
+		"finished_at":  time.Now(),
+	}).Error
+}
+
+// CancelJob 取消任务：等待中的任务直接结束，执行中的任务标记取消请求，由工作协程在下次心跳时停止
+// 任务已结束时返回 ErrJobFinished；返回更新后的任务
+func (jd *jobDao) CancelJob(id string) (*model.Job, error) {
+	var canceled model.Job
+	err := jd.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&canceled).Error; err != nil {
+			return err
+		}
+		switch canceled.Status {
+		case model.JobStatusPending:
+			now := time.Now()
+			canceled.Status = model.JobStatusCanceled
+			canceled.FinishedAt = &now
+			return tx.Model(&model.Job{}).Where("id = ?", id).Updates(map[string]interface{}{
+				"status":      model.JobStatusCanceled,
+				"finished_at": now,
+			}).Error
+		case model.JobStatusRunning:
+			canceled.CancelRequested = true
+			return tx.Model(&model.Job{}).Where("id = ?", id).Update("cancel_requested", true).Error
+		}
+		return ErrJobFinished
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &canceled, nil
+}
+
+// RequeueStale 回收心跳早于 before 的执行中任务（所在实例已退出）
+// 还有剩余执行次数的任务放回等待队列，执行次数已用完的任务标记为失败，
+// 避免不可重复执行的任务被再次执行，或每次都导致进程退出的任务被无限重试
+// 返回放回队列的数量与被标记为失败的任务
+func (jd *jobDao) RequeueStale(before time.Time) (int64, []model.Job, error) {
+	var requeued int64
+	var failed []model.Job
+	err := jd.db.Transaction(func(tx *gorm.DB) error {
+		stale := func() *gorm.DB {
+			return tx.Model(&model.Job{}).Where("status = ? AND heartbeat_at < ?", model.JobStatusRunning, before)
+		}
+		if err := stale().Clauses(clause.Locking{Strength: "UPDATE"}).Where(jobExhausted).Find(&failed).Error; err != nil {
+			return err
+		}
+		if len(failed) > 0 {
+			ids := make([]string, len(failed))
+			for i := range failed {
+				ids[i] = failed[i].ID
+			}
+			if err := tx.Model(&model.Job{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"status":       model.JobStatusFailed,
+				"error":        jobExhaustedError,
+				"worker_id":    "",
+				"heartbeat_at": nil,
+				"finished_at":  time.Now(),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		result := stale().Where("NOT (" + jobExhausted + ")").Updates(map[string]interface{}{
+			"status":       model.JobStatusPending,
+			"run_at":       time.Now(),
+			"worker_id":    "",
+			"heartbeat_at": nil,
+		})
+		requeued = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	return requeued, failed, nil
+}
+
+// NewJobDao 创建并返回一个新的JobDao实例
+func NewJobDao(db *gorm.DB) JobDao {
+	return &jobDao{db: db}
+}
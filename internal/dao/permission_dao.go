@@ -0,0 +1,82 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PermissionDao 定义了文件授权的数据访问接口
+type PermissionDao interface {
+	SavePermission(permission *model.FilePermission) error
+	GetPermission(id string) (*model.FilePermission, error)
+	ListPermissionsByFile(fileID string) ([]model.FilePermission, error)
+	ListPermissionsForGrantee(granteeID uint, fileIDs []string) ([]model.FilePermission, error)
+	ListPermissionsByGrantee(granteeID uint) ([]model.FilePermission, error)
+	DeletePermission(id string) error
+}
+
+type permissionDao struct {
+	db *gorm.DB
+}
+
+// SavePermission 创建授权，同一文件对同一用户已有授权时更新其级别
+func (pd *permissionDao) SavePermission(permission *model.FilePermission) error {
+	return pd.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"role", "granted_by", "updated_at"}),
+	}).Create(permission).Error
+}
+
+// GetPermission 根据ID获取授权，不存在时返回 nil
+func (pd *permissionDao) GetPermission(id string) (*model.FilePermission, error) {
+	var permission model.FilePermission
+	if err := pd.db.Where("id = ?", id).First(&permission).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &permission, nil
+}
+
+// ListPermissionsByFile 列出直接授予在某个文件上的全部授权
+func (pd *permissionDao) ListPermissionsByFile(fileID string) ([]model.FilePermission, error) {
+	var permissions []model.FilePermission
+	if err := pd.db.Where("file_id = ?", fileID).Order("created_at asc").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// ListPermissionsForGrantee 列出用户在给定文件集合（通常是一条祖先链）上的授权
+func (pd *permissionDao) ListPermissionsForGrantee(granteeID uint, fileIDs []string) ([]model.FilePermission, error) {
+	var permissions []model.FilePermission
+	if len(fileIDs) == 0 {
+		return permissions, nil
+	}
+	if err := pd.db.Where("grantee_id = ? AND file_id IN ?", granteeID, fileIDs).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// ListPermissionsByGrantee 列出授予某用户的全部授权
+func (pd *permissionDao) ListPermissionsByGrantee(granteeID uint) ([]model.FilePermission, error) {
+	var permissions []model.FilePermission
+	if err := pd.db.Where("grantee_id = ?", granteeID).Order("created_at desc").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// DeletePermission 删除授权
+func (pd *permissionDao) DeletePermission(id string) error {
+	return pd.db.Where("id = ?", id).Delete(&model.FilePermission{}).Error
+}
+
+// NewPermissionDao 创建并返回一个新的PermissionDao实例
+func NewPermissionDao(db *gorm.DB) PermissionDao {
+	return &permissionDao{db: db}
+}
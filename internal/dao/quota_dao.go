@@ -0,0 +1,71 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuotaDao 定义了用户配额与空间统计的数据访问接口
+type QuotaDao interface {
+	GetQuota(userID uint) (*model.UserQuota, error)
+	SetQuota(userID uint, quota *int64) error
+	AddUsage(userID uint, category string, bytes int64, files int64) error
+	ListCategories(userID uint) ([]model.UsageCategory, error)
+}
+
+type quotaDao struct {
+	db *gorm.DB
+}
+
+// GetQuota 获取用户配额记录，不存在时返回 nil
+func (qd *quotaDao) GetQuota(userID uint) (*model.UserQuota, error) {
+	var quota model.UserQuota
+	if err := qd.db.Where("user_id = ?", userID).First(&quota).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// SetQuota 设置用户配额，不影响已用空间
+func (qd *quotaDao) SetQuota(userID uint, quota *int64) error {
+	return qd.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"quota", "updated_at"}),
+	}).Create(&model.UserQuota{UserID: userID, Quota: quota}).Error
+}
+
+// AddUsage 在同一事务中增量更新总用量与类别用量，delta 可为负
+func (qd *quotaDao) AddUsage(userID uint, category string, bytes int64, files int64) error {
+	return qd.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{"used": gorm.Expr("used + ?", bytes)}),
+		}).Create(&model.UserQuota{UserID: userID, Used: bytes}).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"bytes": gorm.Expr("bytes + ?", bytes),
+				"files": gorm.Expr("files + ?", files),
+			}),
+		}).Create(&model.UsageCategory{UserID: userID, Category: category, Bytes: bytes, Files: files}).Error
+	})
+}
+
+// ListCategories 列出用户各类别的用量
+func (qd *quotaDao) ListCategories(userID uint) ([]model.UsageCategory, error) {
+	var categories []model.UsageCategory
+	if err := qd.db.Where("user_id = ?", userID).Order("bytes desc").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// NewQuotaDao 创建并返回一个新的QuotaDao实例
+func NewQuotaDao(db *gorm.DB) QuotaDao {
+	return &quotaDao{db: db}
+}
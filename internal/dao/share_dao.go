@@ -0,0 +1,129 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShareDao 定义了分享链接的数据访问接口
+type ShareDao interface {
+	CreateShare(share *model.ShareLink) error
+	GetShareByID(id string) (*model.ShareLink, error)
+	GetShareByToken(token string) (*model.ShareLink, error)
+	ListSharesByUser(userID uint) ([]model.ShareLink, error)
+	UpdateShare(share *model.ShareLink) error
+	DeleteShare(id string) error
+	IncrAccessCount(id string) error
+	TryIncrDownloadCount(id string) (bool, error)
+
+	CreateDownload(download *model.ShareDownload) error
+	ReserveDownload(id string, shareID string, fileID string, n int64, limit int64, now time.Time) (bool, error)
+	ReleaseDownload(id string, n int64) error
+	DeleteExpiredDownloads(now time.Time) error
+}
+
+type shareDao struct {
+	db *gorm.DB
+}
+
+// CreateShare 创建分享链接
+func (sd *shareDao) CreateShare(share *model.ShareLink) error {
+	return sd.db.Create(share).Error
+}
+
+// GetShareByID 根据ID获取分享链接，不存在时返回 nil
+func (sd *shareDao) GetShareByID(id string) (*model.ShareLink, error) {
+	return sd.first("id = ?", id)
+}
+
+// GetShareByToken 根据访问令牌获取分享链接，不存在时返回 nil
+func (sd *shareDao) GetShareByToken(token string) (*model.ShareLink, error) {
+	return sd.first("token = ?", token)
+}
+
+// ListSharesByUser 按创建时间倒序列出用户的分享链接
+func (sd *shareDao) ListSharesByUser(userID uint) ([]model.ShareLink, error) {
+	var shares []model.ShareLink
+	if err := sd.db.Where("user_id = ?", userID).Order("created_at desc").Find(&shares).Error; err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// UpdateShare 更新分享链接的可编辑字段，计数字段由原子操作维护，不在此覆盖
+func (sd *shareDao) UpdateShare(share *model.ShareLink) error {
+	return sd.db.Model(&model.ShareLink{}).Where("id = ?", share.ID).Updates(map[string]interface{}{
+		"password_hash": share.PasswordHash,
+		"expires_at":    share.ExpiresAt,
+		"max_downloads": share.MaxDownloads,
+		"mode":          share.Mode,
+	}).Error
+}
+
+// DeleteShare 删除（撤销）分享链接
+func (sd *shareDao) DeleteShare(id string) error {
+	return sd.db.Where("id = ?", id).Delete(&model.ShareLink{}).Error
+}
+
+// IncrAccessCount 访问次数加 1
+func (sd *shareDao) IncrAccessCount(id string) error {
+	return sd.db.Model(&model.ShareLink{}).Where("id = ?", id).
+		UpdateColumn("access_count", gorm.Expr("access_count + 1")).Error
+}
+
+// TryIncrDownloadCount 在未达到下载上限时下载次数加 1，返回是否成功
+func (sd *shareDao) TryIncrDownloadCount(id string) (bool, error) {
+	result := sd.db.Model(&model.ShareLink{}).
+		Where("id = ? AND (max_downloads = 0 OR download_count < max_downloads)", id).
+		UpdateColumn("download_count", gorm.Expr("download_count + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreateDownload 创建下载票据
+func (sd *shareDao) CreateDownload(download *model.ShareDownload) error {
+	return sd.db.Create(download).Error
+}
+
+// ReserveDownload 在票据有效且已发送字节数加 n 不超过 limit 时预占 n 字节，返回是否成功
+func (sd *shareDao) ReserveDownload(id string, shareID string, fileID string, n int64, limit int64, now time.Time) (bool, error) {
+	result := sd.db.Model(&model.ShareDownload{}).
+		Where("id = ? AND share_id = ? AND file_id = ? AND expires_at > ? AND served + ? <= ?", id, shareID, fileID, now, n, limit).
+		UpdateColumn("served", gorm.Expr("served + ?", n))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ReleaseDownload 归还预占但未发送的 n 字节
+func (sd *shareDao) ReleaseDownload(id string, n int64) error {
+	return sd.db.Model(&model.ShareDownload{}).Where("id = ?", id).
+		UpdateColumn("served", gorm.Expr("GREATEST(served - ?, 0)", n)).Error
+}
+
+// DeleteExpiredDownloads 删除过期的下载票据
+func (sd *shareDao) DeleteExpiredDownloads(now time.Time) error {
+	return sd.db.Where("expires_at <= ?", now).Delete(&model.ShareDownload{}).Error
+}
+
+func (sd *shareDao) first(query string, args ...interface{}) (*model.ShareLink, error) {
+	var share model.ShareLink
+	if err := sd.db.Where(query, args...).First(&share).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &share, nil
+}
+
+// NewShareDao 创建并返回一个新的ShareDao实例
+func NewShareDao(db *gorm.DB) ShareDao {
+	return &shareDao{db: db}
+}
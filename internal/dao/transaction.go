@@ -0,0 +1,36 @@
+package dao
+
+import "gorm.io/gorm"
+
+// Tx 绑定到同一个数据库事务的 DAO 集合
+type Tx struct {
+	Files    FileDao
+	Trash    TrashDao
+	Versions VersionDao
+	Changes  ChangeRecorder // 变更日志在 fn 成功返回后、事务提交前写入
+}
+
+// Transactor 在数据库事务中执行 fn：fn 返回错误时回滚，否则提交
+// fn 内只能通过 Tx 中的 DAO 写入，才能与事务一起提交或回滚
+type Transactor interface {
+	Transaction(fn func(tx *Tx) error) error
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func (t *transactor) Transaction(fn func(tx *Tx) error) error {
+	return t.db.Transaction(func(db *gorm.DB) error {
+		changes := &changeBuffer{}
+		if err := fn(&Tx{Files: NewFileDao(db), Trash: NewTrashDao(db), Versions: NewVersionDao(db), Changes: changes}); err != nil {
+			return err
+		}
+		return NewChangeDao(db).Record(changes.changes...)
+	})
+}
+
+// NewTransactor 创建并返回一个新的Transactor实例
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db: db}
+}
@@ -0,0 +1,138 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TrashDao 定义了回收站的数据访问接口
+type TrashDao interface {
+	MoveToTrash(item *model.TrashItem, root *model.File) error
+	GetTrashItem(id string) (*model.TrashItem, error)
+	CountTrashItems(userID uint) (int64, error)
+	ListTrashItems(userID uint, page int, pageSize int) ([]model.TrashItem, error)
+	ListAllTrashItems(userID uint) ([]model.TrashItem, error)
+	ListTrashedFiles(trashID string) ([]model.File, error)
+	RestoreTrashItem(item *model.TrashItem, name string, parentID *string) error
+	DeleteTrashItem(item *model.TrashItem) error
+	ListExpiredTrashItems(before time.Time, offset int, limit int) ([]model.TrashItem, error)
+}
+
+type trashDao struct {
+	db *gorm.DB
+}
+
+// MoveToTrash 创建回收站条目，并将 root 及其子树软删除
+func (td *trashDao) MoveToTrash(item *model.TrashItem, root *model.File) error {
+	return td.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		query := tx.Model(&model.File{}).Where("id = ?", root.ID)
+		if root.IsDir {
+			query = tx.Model(&model.File{}).Where("id = ? OR (user_id = ? AND path LIKE ?)", root.ID, root.UserID, model.ChildPath(root)+"%")
+		}
+		return query.Updates(map[string]interface{}{
+			"trash_id":   item.ID,
+			"deleted_at": item.DeletedAt,
+		}).Error
+	})
+}
+
+// GetTrashItem 根据ID获取回收站条目，不存在时返回 nil
+func (td *trashDao) GetTrashItem(id string) (*model.TrashItem, error) {
+	var item model.TrashItem
+	if err := td.db.Where("id = ?", id).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+// CountTrashItems 统计用户回收站条目数量
+func (td *trashDao) CountTrashItems(userID uint) (int64, error) {
+	var total int64
+	if err := td.db.Model(&model.TrashItem{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// ListTrashItems 按删除时间倒序分页列出回收站条目
+func (td *trashDao) ListTrashItems(userID uint, page int, pageSize int) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	offset := (page - 1) * pageSize
+	if err := td.db.Where("user_id = ?", userID).Order("deleted_at desc").
+		Offset(offset).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListAllTrashItems 列出用户全部回收站条目
+func (td *trashDao) ListAllTrashItems(userID uint) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	if err := td.db.Where("user_id = ?", userID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListTrashedFiles 列出属于某个回收站条目的全部文件（含已软删除的记录）
+func (td *trashDao) ListTrashedFiles(trashID string) ([]model.File, error) {
+	var files []model.File
+	if err := td.db.Unscoped().Where("trash_id = ?", trashID).Find(&files).Error; err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// RestoreTrashItem 恢复条目下的全部文件，并将顶层文件放回指定目录
+func (td *trashDao) RestoreTrashItem(item *model.TrashItem, name string, parentID *string) error {
+	return nameConflict(td.db.Transaction(func(tx *gorm.DB) error {
+		// 先在回收站中改名并移动到恢复位置，再取消删除标记，恢复时才参与同名约束
+		var file model.File
+		if err := tx.Unscoped().Where("id = ?", item.FileID).First(&file).Error; err != nil {
+			return err
+		}
+		if err := relocate(tx, &file, name, parentID); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.File{}).Where("trash_id = ?", item.ID).Updates(map[string]interface{}{
+			"trash_id":   nil,
+			"deleted_at": nil,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", item.ID).Delete(&model.TrashItem{}).Error
+	}))
+}
+
+// DeleteTrashItem 彻底删除条目及其下全部文件记录
+func (td *trashDao) DeleteTrashItem(item *model.TrashItem) error {
+	return td.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("trash_id = ?", item.ID).Delete(&model.File{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", item.ID).Delete(&model.TrashItem{}).Error
+	})
+}
+
+// ListExpiredTrashItems 按删除时间顺序列出删除时间早于 before 的回收站条目，跳过前 offset 条
+func (td *trashDao) ListExpiredTrashItems(before time.Time, offset int, limit int) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	if err := td.db.Where("deleted_at < ?", before).Order("deleted_at asc, id asc").Offset(offset).Limit(limit).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// NewTrashDao 创建并返回一个新的TrashDao实例
+func NewTrashDao(db *gorm.DB) TrashDao {
+	return &trashDao{db: db}
+}
@@ -0,0 +1,109 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UploadSessionDao 定义了分片上传会话的数据访问接口
+type UploadSessionDao interface {
+	CreateSession(session *model.UploadSession) error
+	GetSession(id string) (*model.UploadSession, error)
+	TouchSession(id string, expiresAt time.Time) error
+	CompareAndSetStatus(id string, from string, to string) (bool, error)
+	DeleteSession(id string) error
+	SaveChunk(chunk *model.UploadChunk) error
+	ListChunks(sessionID string) ([]model.UploadChunk, error)
+	ListExpiredSessions(before time.Time, limit int) ([]model.UploadSession, error)
+	SumPendingSize(userID uint) (int64, error)
+}
+
+type uploadSessionDao struct {
+	db *gorm.DB
+}
+
+// CreateSession 创建上传会话
+func (ud *uploadSessionDao) CreateSession(session *model.UploadSession) error {
+	return ud.db.Create(session).Error
+}
+
+// GetSession 根据ID获取上传会话，不存在时返回 nil
+func (ud *uploadSessionDao) GetSession(id string) (*model.UploadSession, error) {
+	var session model.UploadSession
+	if err := ud.db.Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// TouchSession 延长会话有效期，只更新 expires_at 以免覆盖并发写入的状态
+func (ud *uploadSessionDao) TouchSession(id string, expiresAt time.Time) error {
+	return ud.db.Model(&model.UploadSession{}).Where("id = ?", id).Update("expires_at", expiresAt).Error
+}
+
+// CompareAndSetStatus 仅当会话处于 from 状态时将其切换为 to 状态，用于防止重复合并
+func (ud *uploadSessionDao) CompareAndSetStatus(id string, from string, to string) (bool, error) {
+	result := ud.db.Model(&model.UploadSession{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteSession 删除上传会话及其分片记录
+func (ud *uploadSessionDao) DeleteSession(id string) error {
+	return ud.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", id).Delete(&model.UploadChunk{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&model.UploadSession{}).Error
+	})
+}
+
+// SaveChunk 记录已接收的分片，重复上传时覆盖旧记录
+func (ud *uploadSessionDao) SaveChunk(chunk *model.UploadChunk) error {
+	return ud.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(chunk).Error
+}
+
+// ListChunks 按序号列出会话已接收的分片
+func (ud *uploadSessionDao) ListChunks(sessionID string) ([]model.UploadChunk, error) {
+	var chunks []model.UploadChunk
+	if err := ud.db.Where("session_id = ?", sessionID).Order("chunk_index asc").Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// ListExpiredSessions 列出已过期的上传会话
+func (ud *uploadSessionDao) ListExpiredSessions(before time.Time, limit int) ([]model.UploadSession, error) {
+	var sessions []model.UploadSession
+	if err := ud.db.Where("expires_at < ?", before).Limit(limit).Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// SumPendingSize 统计用户未过期的上传会话声明的总大小，用于配额预留
+func (ud *uploadSessionDao) SumPendingSize(userID uint) (int64, error) {
+	var total int64
+	if err := ud.db.Model(&model.UploadSession{}).
+		Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Select("COALESCE(SUM(size), 0)").Scan(&total).Error; err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// NewUploadSessionDao 创建并返回一个新的UploadSessionDao实例
+func NewUploadSessionDao(db *gorm.DB) UploadSessionDao {
+	return &uploadSessionDao{db: db}
+}
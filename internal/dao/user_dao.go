@@ -10,6 +10,7 @@ type UserDao interface {
 	CheckFieldExists(fied string, value interface{}) (bool, error)
 	CreateUser(user *model.User) error
 	GetUserByName(name string) (*model.User, error)
+	GetUsersByIDs(ids []uint) ([]model.User, error)
 }
 
 type userDao struct {
@@ -42,6 +43,18 @@ func (ud *userDao) GetUserByName(name string) (*model.User, error) {
 	return &user, nil
 }
 
+// GetUsersByIDs 批量获取用户信息
+func (ud *userDao) GetUsersByIDs(ids []uint) ([]model.User, error) {
+	var users []model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := ud.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func NewUserDao(db *gorm.DB) UserDao {
 	return &userDao{db: db}
 }
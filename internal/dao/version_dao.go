@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"errors"
+	"llmcloud/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VersionDao 定义了文件历史版本及版本策略的数据访问接口
+type VersionDao interface {
+	ArchiveAndUpdate(snapshot *model.FileVersion, file *model.File, consumedVersionID string) error
+	GetVersion(id string) (*model.FileVersion, error)
+	ListVersions(fileID string) ([]model.FileVersion, error)
+	DeleteVersion(id string) error
+	DeleteVersionsByFileID(fileID string) error
+	GetPolicy(userID uint) (*model.VersionPolicy, error)
+	SavePolicy(policy *model.VersionPolicy) error
+}
+
+type versionDao struct {
+	db *gorm.DB
+}
+
+// ArchiveAndUpdate 在同一事务中将文件当前内容存为历史版本并更新文件记录
+// consumedVersionID 非空时同时删除该历史版本（其内容已转移为当前版本）
+func (vd *versionDao) ArchiveAndUpdate(snapshot *model.FileVersion, file *model.File, consumedVersionID string) error {
+	return vd.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(snapshot).Error; err != nil {
+			return err
+		}
+		if consumedVersionID != "" {
+			if err := tx.Where("id = ?", consumedVersionID).Delete(&model.FileVersion{}).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(file).Error
+	})
+}
+
+// GetVersion 根据ID获取历史版本，不存在时返回 nil
+func (vd *versionDao) GetVersion(id string) (*model.FileVersion, error) {
+	var version model.FileVersion
+	if err := vd.db.Where("id = ?", id).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+// ListVersions 按版本号倒序列出文件的历史版本
+func (vd *versionDao) ListVersions(fileID string) ([]model.FileVersion, error) {
+	var versions []model.FileVersion
+	if err := vd.db.Where("file_id = ?", fileID).Order("version desc").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// DeleteVersion 删除历史版本记录
+func (vd *versionDao) DeleteVersion(id string) error {
+	return vd.db.Where("id = ?", id).Delete(&model.FileVersion{}).Error
+}
+
+// DeleteVersionsByFileID 删除文件的全部历史版本记录
+func (vd *versionDao) DeleteVersionsByFileID(fileID string) error {
+	return vd.db.Where("file_id = ?", fileID).Delete(&model.FileVersion{}).Error
+}
+
+// GetPolicy 获取用户的版本策略，未设置时返回 nil
+func (vd *versionDao) GetPolicy(userID uint) (*model.VersionPolicy, error) {
+	var policy model.VersionPolicy
+	if err := vd.db.Where("user_id = ?", userID).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SavePolicy 保存用户的版本策略
+func (vd *versionDao) SavePolicy(policy *model.VersionPolicy) error {
+	return vd.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(policy).Error
+}
+
+// NewVersionDao 创建并返回一个新的VersionDao实例
+func NewVersionDao(db *gorm.DB) VersionDao {
+	return &versionDao{db: db}
+}
@@ -21,10 +21,77 @@ func InitDB() (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := truncateFileNames(db); err != nil {
+		return nil, err
+	}
 	// 自动迁移
-	if err := db.AutoMigrate(&model.User{}, &model.File{}); err != nil {
+	if err := db.AutoMigrate(
+		&model.User{},
+		&model.File{},
+		&model.UploadSession{},
+		&model.UploadChunk{},
+		&model.Blob{},
+		&model.TrashItem{},
+		&model.FileVersion{},
+		&model.VersionPolicy{},
+		&model.ShareLink{},
+		&model.ShareDownload{},
+		&model.FilePermission{},
+		&model.Job{},
+		&model.UserQuota{},
+		&model.UsageCategory{},
+		&model.Change{},
+		&model.ChangeSeq{},
+	); err != nil {
+		return nil, err
+	}
+	if err := backfillFilePaths(db); err != nil {
+		return nil, err
+	}
+	if err := ensureSiblingNameIndex(db); err != nil {
 		return nil, err
 	}
 
 	return db, nil
 }
+
+// backfillFilePaths 为引入 Path 之前创建的文件补全祖先路径，每轮补全一层，直到没有可补全的记录
+func backfillFilePaths(db *gorm.DB) error {
+	if err := db.Exec("UPDATE files SET path = ? WHERE path = '' AND parent_id IS NULL", model.RootPath).Error; err != nil {
+		return err
+	}
+	for {
+		result := db.Exec("UPDATE files c JOIN files p ON c.parent_id = p.id SET c.path = CONCAT(p.path, p.id, '/') WHERE c.path = '' AND p.path <> ''")
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+	}
+}
+
+// truncateFileNames 文件名列改为 varchar(255) 之前，截断历史数据中过长的名称，否则迁移会失败
+func truncateFileNames(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&model.File{}) {
+		return nil
+	}
+	return db.Exec("UPDATE files SET name = LEFT(name, 255) WHERE CHAR_LENGTH(name) > 255").Error
+}
+
+// ensureSiblingNameIndex 创建同一目录下名称唯一的索引
+// 创建前为历史数据中的重名条目追加ID前缀，每组最早创建的条目保留原名
+func ensureSiblingNameIndex(db *gorm.DB) error {
+	if db.Migrator().HasIndex(&model.File{}, model.FileSiblingNameIndex) {
+		return nil
+	}
+	if err := db.Exec(`UPDATE files f JOIN (
+		SELECT id, ROW_NUMBER() OVER (PARTITION BY user_id, parent_key, name ORDER BY created_at, id) AS rn
+		FROM files WHERE parent_key IS NOT NULL
+	) d ON f.id = d.id
+	SET f.name = CONCAT(LEFT(f.name, 244), ' (', LEFT(f.id, 8), ')')
+	WHERE d.rn > 1`).Error; err != nil {
+		return err
+	}
+	return db.Exec("CREATE UNIQUE INDEX " + model.FileSiblingNameIndex + " ON files (user_id, parent_key, name)").Error
+}
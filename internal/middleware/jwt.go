@@ -55,3 +55,43 @@ func ParseToken(tokenString string) (*Claims, error) {
 
 	return nil, jwt.ErrInvalidKey
 }
+
+// ShareClaims 访客通过分享链接密码校验后获得的访问令牌声明
+// PasswordTag 为提取码哈希的摘要，分享者修改密码后旧令牌随之失效
+type ShareClaims struct {
+	ShareID     string `json:"share_id"`
+	PasswordTag string `json:"pwd"`
+	jwt.RegisteredClaims
+}
+
+// GenerateShareToken 为分享链接生成访客访问令牌
+func GenerateShareToken(shareID string, passwordTag string, ttl time.Duration) (string, error) {
+	cfg := config.AppConfigInstance.JWT
+	claims := &ShareClaims{
+		ShareID:     shareID,
+		PasswordTag: passwordTag,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(cfg.Secret))
+}
+
+// ParseShareToken 解析并验证访客访问令牌
+func ParseShareToken(tokenString string) (*ShareClaims, error) {
+	cfg := config.AppConfigInstance.JWT
+
+	token, err := jwt.ParseWithClaims(tokenString, &ShareClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(cfg.Secret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if claims, ok := token.Claims.(*ShareClaims); ok && token.Valid && claims.ShareID != "" {
+		return claims, nil
+	}
+	return nil, jwt.ErrInvalidKey
+}
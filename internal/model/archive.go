@@ -0,0 +1,21 @@
+package model
+
+const (
+	ArchiveFormatZip   = "zip"
+	ArchiveFormatTarGz = "tar.gz"
+	ArchiveFormatTar   = "tar"
+)
+
+// ArchiveEntry 归档中的一个条目，Path 为相对于归档根的路径，目录以 "/" 结尾
+type ArchiveEntry struct {
+	Path string
+	File File
+}
+
+// Archive 待打包下载的文件集合
+type Archive struct {
+	Name    string         // 下载文件名
+	Format  string         // 归档格式
+	Size    int64          // 未压缩的内容总大小
+	Entries []ArchiveEntry // 按目录层级排列的条目，父目录总在其内容之前
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Blob 按内容（SHA-256）寻址的存储对象，多个文件记录可以引用同一个 Blob
+type Blob struct {
+	Hash        string    `gorm:"primaryKey;size:64"` // 内容哈希（SHA-256）
+	Size        int64     // 对象大小
+	StorageType string    // 存储类型
+	StorageKey  string    // 存储唯一标识
+	RefCount    int64     `gorm:"not null;default:0"` // 引用该对象的文件数量
+	CreatedAt   time.Time `gorm:"autoCreateTime"`     // 创建时间
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"`     // 更新时间
+}
+
+type PreCheckReq struct {
+	FileName string  `json:"file_name" binding:"required"`
+	Size     int64   `json:"size" binding:"gte=0"`
+	Hash     string  `json:"hash" binding:"required,len=64,hexadecimal"`
+	ParentID *string `json:"parent_id,omitempty"`
+	// OnConflict 同名冲突策略，默认开启版本管理时 overwrite，否则 rename
+	OnConflict string `json:"on_conflict" binding:"omitempty,oneof=rename overwrite skip fail"`
+}
+
+// PreCheckResp 秒传预检结果，Instant 为 true 时文件已直接创建
+type PreCheckResp struct {
+	Instant bool  `json:"instant"`
+	File    *File `json:"file,omitempty"`
+}
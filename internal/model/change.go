@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+const (
+	ChangeCreate = "create" // 新建文件或文件夹（含复制、解压、从回收站恢复）
+	ChangeUpdate = "update" // 文件内容变化（上传覆盖、新版本、版本回滚）
+	ChangeRename = "rename" // 在原目录中改名
+	ChangeMove   = "move"   // 移动到其他目录（可能同时改名）
+	ChangeDelete = "delete" // 移入回收站，子树随之删除
+)
+
+// Change 变更日志中的一条记录，Seq 在同一用户内单调递增，作为增量同步的游标
+// 记录的是变更后条目的状态；文件夹的删除、移动与从回收站恢复只记录文件夹本身，子树随之变化
+type Change struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"`
+	UserID    uint      `gorm:"uniqueIndex:idx_changes_user_seq;not null"` // 文件所有者
+	Seq       int64     `gorm:"uniqueIndex:idx_changes_user_seq;not null"` // 用户内的变更序号
+	Type      string    `gorm:"size:16"`                                   // 变更类型
+	FileID    string    `gorm:"type:char(36);index"`                       // 条目ID
+	ParentID  *string   `gorm:"type:char(36)"`                             // 变更后的父目录ID
+	Name      string    `gorm:"type:varchar(255)"`                         // 变更后的名称
+	IsDir     bool      // 是否为目录
+	Size      int64     // 文件大小
+	Hash      string    `gorm:"size:64"` // 文件哈希
+	Version   int       // 文件版本号
+	CreatedAt time.Time `gorm:"autoCreateTime"` // 变更时间
+}
+
+// ChangeSeq 用户当前的变更序号，分配序号时锁定该行，保证序号的提交顺序与大小一致
+type ChangeSeq struct {
+	UserID uint `gorm:"primaryKey;autoIncrement:false"`
+	Seq    int64
+}
+
+// DeltaResp 增量同步结果：Cursor 为下次请求使用的游标，HasMore 为 true 时应立即继续请求
+type DeltaResp struct {
+	Changes []Change `json:"changes"`
+	Cursor  int64    `json:"cursor"`
+	HasMore bool     `json:"has_more"`
+}
+
+// CursorResp 当前最新的游标，客户端完成全量列举后从这里开始增量同步
+type CursorResp struct {
+	Cursor int64 `json:"cursor"`
+}
@@ -0,0 +1,31 @@
+package model
+
+// ChatMessage 对话历史中的一条消息
+type ChatMessage struct {
+	Role    string `json:"role" binding:"required,oneof=user assistant"`
+	Content string `json:"content" binding:"required"`
+}
+
+// ChatReq 基于文档的问答请求
+type ChatReq struct {
+	Question string        `json:"question" binding:"required"`
+	FolderID string        `json:"folder_id"` // 非空时只检索该文件夹的子树
+	TopK     int           `json:"top_k"`     // 检索的片段数，默认取配置
+	History  []ChatMessage `json:"history" binding:"omitempty,dive"`
+}
+
+// ChunkRange 回答引用的一个片段
+type ChunkRange struct {
+	Ref   int     `json:"ref"`   // 提示词与回答中的资料编号，如 [1]
+	Index int     `json:"index"` // 片段序号
+	Start int     `json:"start"` // 片段在提取出的文本中的字符偏移，左闭右开
+	End   int     `json:"end"`
+	Score float32 `json:"score"`
+}
+
+// Citation 回答引用的一个文件
+type Citation struct {
+	FileID string       `json:"file_id"`
+	Path   string       `json:"path"` // 同 GetFilePath
+	Chunks []ChunkRange `json:"chunks"`
+}
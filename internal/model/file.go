@@ -1,20 +1,91 @@
 package model
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type File struct {
-	ID          string    `gorm:"primaryKey;type:char(36)"` // UUID
-	UserID      uint      `gorm:"index"`                    // 用户ID
-	Name        string    `gorm:"not null"`                 // 文件名
-	Size        int64     // 文件大小
-	Hash        string    `gorm:"index;size:64"` // 文件哈希（SHA-256）
-	MIMEType    string    // MIME类型
-	IsDir       bool      `gorm:"default:false"`       // 是否为目录
-	ParentID    *string   `gorm:"type:char(36);index"` // 父目录ID
-	StorageType string    `gorm:"default:'local'"`     // 存储类型：local/oss
-	StorageKey  string    // 存储唯一标识（路径或OSS Key）
-	CreatedAt   time.Time `gorm:"autoCreateTime"` // 创建时间
-	UpdatedAt   time.Time `gorm:"autoUpdateTime"` // 更新时间
+	ID          string         `gorm:"primaryKey;type:char(36)"`   // UUID
+	UserID      uint           `gorm:"index"`                      // 用户ID
+	Name        string         `gorm:"type:varchar(255);not null"` // 文件名
+	Size        int64          // 文件大小
+	Hash        string         `gorm:"index;size:64"` // 文件哈希（SHA-256）
+	MIMEType    string         // MIME类型
+	IsDir       bool           `gorm:"default:false"`       // 是否为目录
+	ParentID    *string        `gorm:"type:char(36);index"` // 父目录ID
+	StorageType string         `gorm:"default:'local'"`     // 存储类型：local/oss
+	StorageKey  string         // 存储唯一标识（路径或OSS Key）
+	TreeSize    int64          `gorm:"<-:create"` // 文件夹：子树内全部文件的总大小
+	FileCount   int64          `gorm:"<-:create"` // 文件夹：子树内的文件数
+	FolderCount int64          `gorm:"<-:create"` // 文件夹：子树内的文件夹数
+	Version     int            `gorm:"default:1"` // 当前版本号
+	UploaderID  uint           // 当前版本的上传者
+	CreatedAt   time.Time      `gorm:"autoCreateTime"`      // 创建时间
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime"`      // 更新时间
+	TrashID     *string        `gorm:"type:char(36);index"` // 所属回收站条目ID
+	DeletedAt   gorm.DeletedAt `gorm:"index"`               // 移入回收站的时间
+
+	// Path 全部祖先文件夹的ID路径（见 ChildPath），在创建与移动时维护，使祖先与子树查询只需一次查询
+	Path string `gorm:"type:varchar(3000) CHARACTER SET ascii COLLATE ascii_bin;not null;default:'';index;<-:create"`
+	// ParentKey 由数据库生成的只读列：未删除的条目为父目录ID（根目录为空字符串），回收站中的条目为 NULL。
+	// MySQL 唯一索引不约束 NULL，直接使用 parent_id 无法限制根目录下的同名条目；
+	// 唯一索引 (user_id, parent_key, name) 借助该列保证同一目录下未删除的条目名称唯一，回收站中的条目不参与约束
+	ParentKey *string `gorm:"->;type:char(36) GENERATED ALWAYS AS (IF(deleted_at IS NULL, IFNULL(parent_id, ''), NULL)) STORED" json:"-"`
+
+	// IngestStatus 检索索引状态（见 IngestPending 等），为空表示该文件不建立索引；IngestError 为失败原因
+	// 两列不随 Save 写回，只由索引流程单独更新，避免被其他修改以旧值覆盖
+	IngestStatus string `gorm:"size:16;index;<-:create"`
+	IngestError  string `gorm:"size:255;<-:create"`
+}
+
+const (
+	IngestPending  = "pending"  // 等待建立索引
+	IngestIndexing = "indexing" // 正在建立索引
+	IngestIndexed  = "indexed"  // 已建立索引
+	IngestFailed   = "failed"   // 建立索引失败
+)
+
+// FileSiblingNameIndex 保证同一目录下名称唯一的索引
+const FileSiblingNameIndex = "idx_files_sibling_name"
+
+// RootPath 根目录下条目的 Path
+const RootPath = "/"
+
+// ChildPath 返回 parent 直接子项的 Path，形如 "/<祖先ID>/.../<parentID>/"，
+// 同时也是 parent 子树内全部条目 Path 的公共前缀
+func ChildPath(parent *File) string {
+	return parent.Path + parent.ID + "/"
+}
+
+// AncestorIDs 解析 Path，按从根到父的顺序返回全部祖先文件夹的ID
+func AncestorIDs(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+}
+
+// FolderStats 子树聚合统计
+type FolderStats struct {
+	Size        int64 `json:"size"`
+	FileCount   int64 `json:"file_count"`
+	FolderCount int64 `json:"folder_count"`
+}
+
+// ChildStatsResp 文件夹下直接子项的占用情况
+type ChildStatsResp struct {
+	FileID string `json:"file_id"`
+	Name   string `json:"name"`
+	IsDir  bool   `json:"is_dir"`
+	FolderStats
+}
+
+// FolderStatsResp 文件夹（或根目录）的聚合统计，Children 按占用空间从大到小排列
+type FolderStatsResp struct {
+	FileID   string           `json:"file_id,omitempty"`
+	Name     string           `json:"name"`
+	Children []ChildStatsResp `json:"children"`
+	FolderStats
 }
 
 type CreateFolderReq struct {
@@ -25,9 +96,97 @@ type CreateFolderReq struct {
 type BatchMoveRequest struct {
 	FileIDs        []string `json:"files_pid" binding:"required"`
 	TargetParentID string   `json:"target_pid"`
+	Mode           string   `json:"mode" binding:"omitempty,oneof=atomic best_effort"`                // 默认 atomic
+	OnConflict     string   `json:"on_conflict" binding:"omitempty,oneof=rename overwrite skip fail"` // 默认 rename
+}
+
+// BatchCopyRequest 批量复制，参数与批量移动一致
+type BatchCopyRequest struct {
+	FileIDs        []string `json:"files_pid" binding:"required"`
+	TargetParentID string   `json:"target_pid"`
+	Mode           string   `json:"mode" binding:"omitempty,oneof=atomic best_effort"`                // 默认 atomic
+	OnConflict     string   `json:"on_conflict" binding:"omitempty,oneof=rename overwrite skip fail"` // 默认 rename
 }
 
 type RenameRequest struct {
+	FileID     string `json:"file_id" binding:"required"`
+	NewName    string `json:"new_name" binding:"required"`
+	OnConflict string `json:"on_conflict" binding:"omitempty,oneof=rename overwrite skip fail"` // 默认 fail
+}
+
+// MkdirPathReq 按路径创建文件夹，Parents 为 true 时创建缺失的上级目录
+type MkdirPathReq struct {
+	Path    string `json:"path" binding:"required"`
+	Parents bool   `json:"parents"`
+}
+
+// MovePathReq 按路径移动，To 为已存在的文件夹时移入其中，否则移动并改名为 To
+type MovePathReq struct {
+	From       string `json:"from" binding:"required"`
+	To         string `json:"to" binding:"required"`
+	Parents    bool   `json:"parents"`
+	OnConflict string `json:"on_conflict" binding:"omitempty,oneof=rename overwrite skip fail"` // 默认 fail
+}
+
+const (
+	BatchModeAtomic     = "atomic"      // 全部成功，或全部不生效
+	BatchModeBestEffort = "best_effort" // 逐项执行，互不影响
+)
+
+const (
+	BatchItemSucceeded = "succeeded"
+	BatchItemFailed    = "failed"
+	BatchItemSkipped   = "skipped" // 未执行：全部成功模式下其他条目失败，或按冲突策略跳过
+)
+
+// 目标位置已有同名条目时的处理策略
+const (
+	ConflictRename    = "rename"    // 自动改名为 "name (1).ext"
+	ConflictOverwrite = "overwrite" // 覆盖：上传时生成新版本（未开启版本管理时替换内容），移动、复制时原文件移入回收站；文件夹之间合并内容
+	ConflictSkip      = "skip"      // 保留已有条目，跳过本条目
+	ConflictFail      = "fail"      // 报错
+)
+
+// IsConflictPolicy 判断 s 是否为合法的冲突策略，空字符串表示使用操作的默认策略
+func IsConflictPolicy(s string) bool {
+	switch s {
+	case "", ConflictRename, ConflictOverwrite, ConflictSkip, ConflictFail:
+		return true
+	}
+	return false
+}
+
+// BatchItemResult 批量操作中单个条目的结果，Code 与 Message 在失败时给出原因
+type BatchItemResult struct {
+	FileID  string `json:"file_id"`
+	Status  string `json:"status"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	Err     error  `json:"-"`
+}
+
+// BatchResp 批量操作的结果；复制条目较多时转为后台任务，此时只返回 Job
+type BatchResp struct {
+	Mode      string            `json:"mode"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Skipped   int               `json:"skipped"`
+	Items     []BatchItemResult `json:"items,omitempty"`
+	Job       *JobResp          `json:"job,omitempty"`
+}
+
+type BatchDeleteRequest struct {
+	FileIDs []string `json:"file_ids" binding:"required"`
+	Mode    string   `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+}
+
+type RenameItem struct {
 	FileID  string `json:"file_id" binding:"required"`
 	NewName string `json:"new_name" binding:"required"`
 }
+
+type BatchRenameRequest struct {
+	Items      []RenameItem `json:"items" binding:"required,dive"`
+	Mode       string       `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	OnConflict string       `json:"on_conflict" binding:"omitempty,oneof=rename overwrite skip fail"` // 默认 fail
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+const (
+	JobTypeCopy    = "copy"    // 批量复制
+	JobTypeExtract = "extract" // 上传并解压
+	JobTypeDelete  = "delete"  // 批量移入回收站
+	JobTypeIngest  = "ingest"  // 为文件建立检索索引
+)
+
+const (
+	JobStatusPending   = "pending"   // 等待执行（含等待重试）
+	JobStatusRunning   = "running"   // 执行中
+	JobStatusSucceeded = "succeeded" // 执行成功
+	JobStatusFailed    = "failed"    // 执行失败
+	JobStatusCanceled  = "canceled"  // 已取消
+)
+
+// Job 后台任务，用于耗时较长的文件操作
+// 任务参数持久化在 Payload 中，服务重启后由工作协程继续执行
+type Job struct {
+	ID              string     `gorm:"primaryKey;type:char(36)"` // 任务ID
+	UserID          uint       `gorm:"index"`                    // 发起任务的用户
+	Type            string     `gorm:"size:32"`                  // 任务类型
+	Status          string     `gorm:"size:20;index"`            // 任务状态
+	Payload         string     `gorm:"type:text"`                // 任务参数（JSON）
+	Total           int64      // 需要处理的条目数
+	Done            int64      // 已处理的条目数
+	Attempts        int        // 已执行的次数
+	MaxAttempts     int        // 最多执行的次数（含首次）
+	RunAt           time.Time  `gorm:"index"`          // 最早可以执行的时间，重试时按退避时间推后
+	CancelRequested bool       `gorm:"default:false"`  // 用户已请求取消，执行中的任务在下次心跳时停止
+	WorkerID        string     `gorm:"size:64"`        // 正在执行的工作协程
+	HeartbeatAt     *time.Time `gorm:"index"`          // 执行中最近一次心跳，超时未更新视为中断
+	Error           string     `gorm:"type:text"`      // 失败原因（重试中为上次失败的原因）
+	CreatedAt       time.Time  `gorm:"autoCreateTime"` // 创建时间
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime"` // 更新时间
+	FinishedAt      *time.Time // 结束时间
+}
+
+// JobResp 任务进度，Progress 为百分比
+type JobResp struct {
+	JobID           string     `json:"job_id"`
+	Type            string     `json:"type"`
+	Status          string     `json:"status"`
+	Total           int64      `json:"total"`
+	Done            int64      `json:"done"`
+	Progress        float64    `json:"progress"`
+	Attempts        int        `json:"attempts"`
+	MaxAttempts     int        `json:"max_attempts"`
+	CancelRequested bool       `json:"cancel_requested,omitempty"`
+	Error           string     `json:"error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+type CancelJobReq struct {
+	JobID string `json:"job_id" binding:"required"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+const (
+	RoleViewer = "viewer" // 浏览与下载
+	RoleEditor = "editor" // 上传、新建、重命名、移动、删除
+	RoleOwner  = "owner"  // 在编辑权限之上可管理协作者
+)
+
+// FilePermission 将文件或文件夹授权给其他用户，文件夹上的授权对其下全部内容生效
+type FilePermission struct {
+	ID        string    `gorm:"primaryKey;type:char(36)"`                       // 授权ID
+	FileID    string    `gorm:"type:char(36);uniqueIndex:idx_file_grantee"`     // 被授权的文件或文件夹
+	OwnerID   uint      `gorm:"index"`                                          // 文件所有者
+	GranteeID uint      `gorm:"uniqueIndex:idx_file_grantee;index:idx_grantee"` // 被授权用户
+	Role      string    `gorm:"size:20"`                                        // 授权级别
+	GrantedBy uint      // 授权操作人
+	CreatedAt time.Time `gorm:"autoCreateTime"` // 创建时间
+	UpdatedAt time.Time `gorm:"autoUpdateTime"` // 更新时间
+}
+
+// GrantPermissionReq 授权或修改已有授权的级别
+type GrantPermissionReq struct {
+	FileID   string `json:"file_id" binding:"required"`
+	Username string `json:"username" binding:"required"`
+	Role     string `json:"role" binding:"required,oneof=viewer editor owner"`
+}
+
+// PermissionResp 文件的协作者信息
+type PermissionResp struct {
+	PermissionID string    `json:"permission_id"`
+	FileID       string    `json:"file_id"`
+	UserID       uint      `json:"user_id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SharedFileResp "与我共享"列表中的一项
+type SharedFileResp struct {
+	File      File      `json:"file"`
+	Role      string    `json:"role"`
+	OwnerID   uint      `json:"owner_id"`
+	OwnerName string    `json:"owner_name"`
+	SharedAt  time.Time `json:"shared_at"`
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+const (
+	CategoryImage    = "image"
+	CategoryVideo    = "video"
+	CategoryAudio    = "audio"
+	CategoryDocument = "document"
+	CategoryArchive  = "archive"
+	CategoryOther    = "other"
+)
+
+// UserQuota 用户配额与已用空间，已用空间在文件增删时增量维护
+type UserQuota struct {
+	UserID    uint      `gorm:"primaryKey;autoIncrement:false"` // 用户ID
+	Quota     *int64    // 管理员设置的配额（字节），为空时使用默认配额，0 表示不限
+	Used      int64     // 已用空间（字节），包含回收站与历史版本
+	UpdatedAt time.Time `gorm:"autoUpdateTime"` // 更新时间
+}
+
+// UsageCategory 按 MIME 类别统计的已用空间
+type UsageCategory struct {
+	UserID   uint   `gorm:"primaryKey;autoIncrement:false"` // 用户ID
+	Category string `gorm:"primaryKey;size:20"`             // 类别
+	Bytes    int64  // 占用字节数
+	Files    int64  // 文件数
+}
+
+type CategoryUsageResp struct {
+	Category string `json:"category"`
+	Bytes    int64  `json:"bytes"`
+	Files    int64  `json:"files"`
+}
+
+// UsageResp 用户空间使用情况
+type UsageResp struct {
+	Used       int64               `json:"used"`
+	Total      int64               `json:"total"` // 0 表示不限
+	Categories []CategoryUsageResp `json:"categories"`
+}
+
+// SetQuotaReq 管理员设置用户配额，Quota 为空时恢复默认配额
+type SetQuotaReq struct {
+	Username string `json:"username" binding:"required"`
+	Quota    *int64 `json:"quota" binding:"omitempty,gte=0"`
+}
@@ -0,0 +1,17 @@
+package model
+
+// ChunkMatch 语义检索命中的一个片段
+type ChunkMatch struct {
+	Index   int     `json:"index"`   // 片段序号
+	Snippet string  `json:"snippet"` // 片段文本
+	Score   float32 `json:"score"`   // 与查询的余弦相似度
+	Start   int     `json:"start"`   // 片段在提取出的文本中的字符偏移，左闭右开
+	End     int     `json:"end"`
+}
+
+// SemanticSearchResult 语义检索结果中的一个文件，按片段的最高相似度排序
+type SemanticSearchResult struct {
+	File   File         `json:"file"`
+	Score  float32      `json:"score"`
+	Chunks []ChunkMatch `json:"chunks"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+const (
+	ShareModeReadOnly = "read_only" // 只读：浏览与下载
+	ShareModeUpload   = "upload"    // 允许访客向共享文件夹上传
+)
+
+// ShareLink 公开分享链接，指向一个文件或整个文件夹
+type ShareLink struct {
+	ID            string     `gorm:"primaryKey;type:char(36)"` // 分享ID
+	Token         string     `gorm:"uniqueIndex;size:32"`      // 链接中的访问令牌
+	UserID        uint       `gorm:"index"`                    // 分享者，管理该链接的用户
+	FileID        string     `gorm:"type:char(36);index"`      // 被分享的文件或文件夹
+	OwnerID       uint       // 被分享文件的所有者，访客浏览与上传的文件均归属于该用户
+	PasswordHash  string     // 提取码（bcrypt），为空表示无需密码
+	ExpiresAt     *time.Time // 过期时间，为空表示永不过期
+	MaxDownloads  int64      // 最大下载次数，0 表示不限
+	DownloadCount int64      // 已下载次数
+	AccessCount   int64      // 访问次数
+	Mode          string     `gorm:"size:20;default:'read_only'"` // 分享模式
+	CreatedAt     time.Time  `gorm:"autoCreateTime"`              // 创建时间
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime"`              // 更新时间
+}
+
+// ShareDownload 一次分享下载的凭证（下载票据），用于续传时不重复计入下载次数
+// Served 为已发送（或正在发送）的字节数：续传只能取回文件剩余的部分，超出文件大小的请求按一次新的下载计数
+type ShareDownload struct {
+	ID        string    `gorm:"primaryKey;type:char(36)"` // 票据
+	ShareID   string    `gorm:"type:char(36);index"`
+	FileID    string    `gorm:"type:char(36)"`
+	Served    int64     // 已发送的字节数
+	ExpiresAt time.Time `gorm:"index"` // 过期后续传按新的下载计数
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// ShareLinkReq 创建或修改分享链接
+// 修改时 Password 为 nil 表示保持原密码，为空字符串表示取消密码
+type ShareLinkReq struct {
+	ShareID      string     `json:"share_id"`
+	FileID       string     `json:"file_id"`
+	Password     *string    `json:"password,omitempty" binding:"omitempty,max=32"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxDownloads int64      `json:"max_downloads" binding:"gte=0"`
+	Mode         string     `json:"mode" binding:"omitempty,oneof=read_only upload"`
+}
+
+type ShareAuthReq struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// ShareLinkResp 分享者查看的链接信息
+type ShareLinkResp struct {
+	ShareID       string     `json:"share_id"`
+	Token         string     `json:"token"`
+	FileID        string     `json:"file_id"`
+	FileName      string     `json:"file_name"`
+	IsDir         bool       `json:"is_dir"`
+	HasPassword   bool       `json:"has_password"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	MaxDownloads  int64      `json:"max_downloads"`
+	DownloadCount int64      `json:"download_count"`
+	AccessCount   int64      `json:"access_count"`
+	Mode          string     `json:"mode"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ShareMetaResp 访客查看的链接信息
+type ShareMetaResp struct {
+	Name               string     `json:"name"`
+	IsDir              bool       `json:"is_dir"`
+	Size               int64      `json:"size"`
+	RootID             string     `json:"root_id"`
+	HasPassword        bool       `json:"has_password"`
+	ExpiresAt          *time.Time `json:"expires_at"`
+	Mode               string     `json:"mode"`
+	RemainingDownloads int64      `json:"remaining_downloads"` // -1 表示不限
+}
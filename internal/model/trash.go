@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// TrashItem 回收站条目，对应一次删除操作中被删除的顶层文件或文件夹
+// 其下的所有子文件通过 File.TrashID 关联到该条目
+type TrashItem struct {
+	ID               string    `gorm:"primaryKey;type:char(36)"` // 条目ID
+	UserID           uint      `gorm:"index"`                    // 用户ID
+	FileID           string    `gorm:"type:char(36);index"`      // 被删除的顶层文件ID
+	Name             string    // 删除时的文件名
+	IsDir            bool      // 是否为目录
+	Size             int64     // 文件或整个子树的大小
+	OriginalParentID *string   `gorm:"type:char(36)"` // 删除前所在的父目录ID
+	DeletedAt        time.Time `gorm:"index"`         // 删除时间
+}
+
+type TrashIDsReq struct {
+	TrashIDs []string `json:"trash_ids" binding:"required,min=1"`
+}
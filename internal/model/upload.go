@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+const (
+	UploadStatusUploading  = "uploading"  // 分片上传中
+	UploadStatusCompleting = "completing" // 正在合并分片
+)
+
+// UploadSession 分片上传会话
+type UploadSession struct {
+	ID          string    `gorm:"primaryKey;type:char(36)"` // 会话ID
+	UserID      uint      `gorm:"index"`                    // 用户ID
+	FileName    string    `gorm:"not null"`                 // 文件名
+	Size        int64     // 文件总大小
+	Hash        string    `gorm:"size:64"`       // 客户端声明的 SHA-256（可选）
+	ParentID    *string   `gorm:"type:char(36)"` // 父目录ID
+	ChunkSize   int64     // 分片大小
+	ChunkCount  int       // 分片总数
+	StorageType string    // 存储类型
+	StorageKey  string    // 合并后的存储唯一标识
+	UploadID    string    // 存储驱动的分片上传ID
+	OnConflict  string    `gorm:"size:16"`        // 完成时的同名冲突策略
+	Status      string    `gorm:"size:20;index"`  // 会话状态
+	ExpiresAt   time.Time `gorm:"index"`          // 过期时间
+	CreatedAt   time.Time `gorm:"autoCreateTime"` // 创建时间
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"` // 更新时间
+}
+
+// UploadChunk 已接收的分片
+type UploadChunk struct {
+	SessionID  string    `gorm:"primaryKey;type:char(36)"`       // 会话ID
+	ChunkIndex int       `gorm:"primaryKey;autoIncrement:false"` // 分片序号，从 0 开始
+	Size       int64     // 分片大小
+	ETag       string    // 存储端返回的分片标识
+	CreatedAt  time.Time `gorm:"autoCreateTime"` // 创建时间
+}
+
+type InitUploadReq struct {
+	FileName string  `json:"file_name" binding:"required"`
+	Size     int64   `json:"size" binding:"required,gt=0"`
+	ParentID *string `json:"parent_id,omitempty"`
+	Hash     string  `json:"hash,omitempty" binding:"omitempty,len=64,hexadecimal"`
+	// OnConflict 同名冲突策略，默认开启版本管理时 overwrite，否则 rename
+	OnConflict string `json:"on_conflict" binding:"omitempty,oneof=rename overwrite skip fail"`
+}
+
+type CompleteUploadReq struct {
+	UploadID string `json:"upload_id" binding:"required"`
+}
+
+// UploadSessionResp 分片上传会话状态
+type UploadSessionResp struct {
+	UploadID       string    `json:"upload_id"`
+	FileName       string    `json:"file_name"`
+	Size           int64     `json:"size"`
+	ChunkSize      int64     `json:"chunk_size"`
+	ChunkCount     int       `json:"chunk_count"`
+	UploadedChunks []int     `json:"uploaded_chunks"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// FileVersion 文件的历史版本，当前版本的内容保存在 File 记录本身
+type FileVersion struct {
+	ID          string    `gorm:"primaryKey;type:char(36)"` // 版本ID
+	FileID      string    `gorm:"type:char(36);index"`      // 所属文件ID
+	Version     int       // 版本号
+	Size        int64     // 文件大小
+	Hash        string    `gorm:"index;size:64"` // 文件哈希（SHA-256）
+	MIMEType    string    // MIME类型
+	StorageType string    // 存储类型
+	StorageKey  string    // 存储唯一标识
+	UploaderID  uint      // 上传者
+	CreatedAt   time.Time // 该版本内容的上传时间
+}
+
+// VersionPolicy 用户的版本管理策略，未设置时使用配置中的默认值
+type VersionPolicy struct {
+	UserID      uint      `gorm:"primaryKey;autoIncrement:false"` // 用户ID
+	Enabled     bool      // 是否开启版本管理
+	MaxVersions int       // 最多保留的版本数（含当前版本）
+	UpdatedAt   time.Time `gorm:"autoUpdateTime"` // 更新时间
+}
+
+// FileVersionResp 版本列表项
+type FileVersionResp struct {
+	VersionID  string    `json:"version_id"` // 当前版本为空
+	Version    int       `json:"version"`
+	Size       int64     `json:"size"`
+	Hash       string    `json:"hash"`
+	UploaderID uint      `json:"uploader_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	IsCurrent  bool      `json:"is_current"`
+}
+
+type RestoreVersionReq struct {
+	FileID    string `json:"file_id" binding:"required"`
+	VersionID string `json:"version_id" binding:"required"`
+}
+
+type VersionPolicyReq struct {
+	Enabled     *bool `json:"enabled" binding:"required"`
+	MaxVersions int   `json:"max_versions" binding:"required,min=1,max=100"`
+}
@@ -0,0 +1,79 @@
+package rag
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultChunkSize    = 800
+	defaultChunkOverlap = 100
+)
+
+// TextChunker 按字符数切分文本，相邻片段重叠 overlap 个字符
+// 切分点优先选在段落、换行或句末，避免把句子从中间截断
+type TextChunker struct {
+	size    int
+	overlap int
+}
+
+// NewTextChunker 创建切分器，size 与 overlap 以字符（rune）计
+func NewTextChunker(size int, overlap int) *TextChunker {
+	if size <= 0 {
+		size = defaultChunkSize
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = min(defaultChunkOverlap, size/4)
+	}
+	return &TextChunker{size: size, overlap: overlap}
+}
+
+func (c *TextChunker) Split(text string) []Chunk {
+	runes := []rune(text)
+	var chunks []Chunk
+	start := 0
+	for start < len(runes) {
+		end := len(runes)
+		if end-start > c.size {
+			end = c.breakPoint(runes, start, start+c.size)
+		}
+		// 去掉片段首尾的空白，偏移随之调整
+		s, e := start, end
+		for s < e && unicode.IsSpace(runes[s]) {
+			s++
+		}
+		for e > s && unicode.IsSpace(runes[e-1]) {
+			e--
+		}
+		if s < e {
+			chunks = append(chunks, Chunk{Index: len(chunks), Text: string(runes[s:e]), Start: s, End: e})
+		}
+		if end == len(runes) {
+			break
+		}
+		next := end - c.overlap
+		if next <= start {
+			next = end
+		}
+		start = next
+	}
+	return chunks
+}
+
+// breakPoint 在 (start, limit] 的后半段中寻找最合适的切分位置，找不到时在 limit 处硬切
+func (c *TextChunker) breakPoint(runes []rune, start int, limit int) int {
+	floor := start + c.size/2
+	for _, isBreak := range []func(i int) bool{
+		func(i int) bool { return runes[i-1] == '\n' && i >= 2 && runes[i-2] == '\n' },
+		func(i int) bool { return runes[i-1] == '\n' },
+		func(i int) bool { return strings.ContainsRune("。！？；.!?;", runes[i-1]) },
+		func(i int) bool { return unicode.IsSpace(runes[i-1]) },
+	} {
+		for i := limit; i > floor; i-- {
+			if isBreak(i) {
+				return i
+			}
+		}
+	}
+	return limit
+}
@@ -0,0 +1,235 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultEmbedDimension    = 256
+	defaultEmbedBatchSize    = 32
+	defaultEmbedMaxTokens    = 8000
+	defaultEmbedConcurrency  = 4
+	defaultEmbedMaxRetries   = 3
+	defaultEmbedRetryBackoff = time.Second
+	defaultEmbedTimeout      = 30 * time.Second
+	maxEmbedRetryBackoff     = time.Minute
+)
+
+// NewEmbedder 根据配置初始化嵌入模型；远程模型包装为分批、截断、重试并限制并发的客户端
+func NewEmbedder(cfg config.EmbedderConfig) (Embedder, error) {
+	var inner Embedder
+	switch cfg.Provider {
+	case "", "hash":
+		return NewHashEmbedder(cfg.Dimension), nil
+	case "openai":
+		client, err := NewOpenAIEmbedder(cfg)
+		if err != nil {
+			return nil, err
+		}
+		inner = client
+	case "ollama":
+		client, err := NewOllamaEmbedder(cfg)
+		if err != nil {
+			return nil, err
+		}
+		inner = client
+	default:
+		return nil, fmt.Errorf("unsupported embedder provider: %s", cfg.Provider)
+	}
+	return NewBatchEmbedder(inner, cfg), nil
+}
+
+// BatchEmbedder 包装远程嵌入模型：
+// 超长文本按估算的 token 数截断，输入按批次拆分并发请求，限流与服务端错误按指数退避重试
+// 并发上限对同一实例的所有调用方共享
+type BatchEmbedder struct {
+	inner      Embedder
+	batchSize  int
+	maxTokens  int
+	maxRetries int
+	backoff    time.Duration
+	slots      chan struct{}
+}
+
+func NewBatchEmbedder(inner Embedder, cfg config.EmbedderConfig) *BatchEmbedder {
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultEmbedBatchSize
+	}
+	maxTokens := cfg.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultEmbedMaxTokens
+	}
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultEmbedConcurrency
+	}
+	maxRetries := cfg.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultEmbedMaxRetries
+	}
+	return &BatchEmbedder{
+		inner:      inner,
+		batchSize:  batchSize,
+		maxTokens:  maxTokens,
+		maxRetries: maxRetries,
+		backoff:    parseDuration(cfg.RetryBackoff, defaultEmbedRetryBackoff),
+		slots:      make(chan struct{}, concurrency),
+	}
+}
+
+func (e *BatchEmbedder) Dimension() int {
+	return e.inner.Dimension()
+}
+
+func (e *BatchEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	inputs := make([]string, len(texts))
+	for i, text := range texts {
+		inputs[i] = truncateTokens(text, e.maxTokens)
+	}
+	vectors := make([][]float32, len(texts))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for start := 0; start < len(inputs); start += e.batchSize {
+		end := min(start+e.batchSize, len(inputs))
+		wg.Add(1)
+		go func(start int, end int) {
+			defer wg.Done()
+			if err := e.embedBatch(ctx, inputs[start:end], vectors[start:end]); err != nil {
+				// 任一批次失败即取消其余批次
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return vectors, nil
+}
+
+// embedBatch 请求一个批次的向量并写入 out，失败时按配置重试
+func (e *BatchEmbedder) embedBatch(ctx context.Context, texts []string, out [][]float32) error {
+	for attempt := 0; ; attempt++ {
+		select {
+		case e.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		vectors, err := e.inner.Embed(ctx, texts)
+		<-e.slots
+		if err == nil {
+			err = checkVectors(vectors, len(texts), e.Dimension())
+			if err == nil {
+				copy(out, vectors)
+				return nil
+			}
+		}
+		if attempt >= e.maxRetries || !retryable(ctx, err) {
+			return err
+		}
+		select {
+		case <-time.After(e.retryDelay(attempt, err)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// retryDelay 第 attempt 次重试前的等待时间，服务端通过 Retry-After 要求更长等待时以其为准
+func (e *BatchEmbedder) retryDelay(attempt int, err error) time.Duration {
+	d := e.backoff
+	for i := 0; i < attempt && d < maxEmbedRetryBackoff; i++ {
+		d *= 2
+	}
+	var se *StatusError
+	if errors.As(err, &se) && se.RetryAfter > d {
+		d = se.RetryAfter
+	}
+	return min(d, maxEmbedRetryBackoff)
+}
+
+// retryable 判断请求失败是否值得重试：网络错误、限流（429）与服务端错误（5xx）
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode == 429 || se.StatusCode >= 500
+	}
+	var ue *url.Error
+	return errors.As(err, &ue)
+}
+
+// checkVectors 校验模型返回的向量数量与维度
+func checkVectors(vectors [][]float32, count int, dim int) error {
+	if len(vectors) != count {
+		return fmt.Errorf("生成向量失败: 期望 %d 个向量，实际 %d 个", count, len(vectors))
+	}
+	for _, v := range vectors {
+		if len(v) != dim {
+			return fmt.Errorf("向量维度不匹配: 期望 %d，实际 %d", dim, len(v))
+		}
+	}
+	return nil
+}
+
+// truncateTokens 将文本截断到估算不超过 maxTokens 个 token
+// 没有模型的分词器，按偏保守的方式估算：ASCII 字符约 4 个一个 token，其余字符各算一个 token
+func truncateTokens(text string, maxTokens int) string {
+	budget := maxTokens * 4
+	for i, r := range text {
+		cost := 4
+		if r < utf8.RuneSelf {
+			cost = 1
+		}
+		if budget < cost {
+			return text[:i]
+		}
+		budget -= cost
+	}
+	return text
+}
+
+// parseDuration 解析配置中的时间字符串，非法或为空时使用默认值
+func parseDuration(value string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+// normalize 将向量缩放为单位长度，零向量原样返回
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return v
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+	return v
+}
@@ -0,0 +1,298 @@
+package rag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// embeddingServer 模拟 OpenAI 兼容的嵌入接口：第 i 个输入的向量为 [序号, 本批输入数]，
+// 输入形如 "t<序号>"；data 按倒序返回，验证客户端按 index 还原顺序
+type embeddingServer struct {
+	mu      sync.Mutex
+	batches [][]string
+	// respond 非空时优先决定响应，返回 false 表示按正常结果响应
+	respond func(w http.ResponseWriter, call int) bool
+	calls   atomic.Int32
+}
+
+func (s *embeddingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := int(s.calls.Add(1))
+	if r.URL.Path != "/embeddings" {
+		http.NotFound(w, r)
+		return
+	}
+	var req struct {
+		Input []string `json:"input"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.batches = append(s.batches, req.Input)
+	s.mu.Unlock()
+	if s.respond != nil && s.respond(w, call) {
+		return
+	}
+	type item struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	}
+	data := make([]item, 0, len(req.Input))
+	for i := len(req.Input) - 1; i >= 0; i-- {
+		var n int
+		fmt.Sscanf(req.Input[i], "t%d", &n)
+		data = append(data, item{Index: i, Embedding: []float32{float32(n), float32(len(req.Input))}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+// newTestEmbedder 创建指向 srv 的 OpenAI 兼容嵌入客户端，并按 cfg 包装分批与重试
+func newTestEmbedder(t *testing.T, srv *httptest.Server, cfg config.EmbedderConfig) *BatchEmbedder {
+	t.Helper()
+	cfg.BaseURL = srv.URL
+	cfg.Model = "test-embedding"
+	cfg.Dimension = 2
+	if cfg.RetryBackoff == "" {
+		cfg.RetryBackoff = "1ms"
+	}
+	inner, err := NewOpenAIEmbedder(cfg)
+	if err != nil {
+		t.Fatalf("NewOpenAIEmbedder: %v", err)
+	}
+	return NewBatchEmbedder(inner, cfg)
+}
+
+func inputs(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("t%d", i)
+	}
+	return texts
+}
+
+func TestBatchEmbedderSplitsAndKeepsOrder(t *testing.T) {
+	es := &embeddingServer{}
+	srv := httptest.NewServer(es)
+	defer srv.Close()
+	e := newTestEmbedder(t, srv, config.EmbedderConfig{BatchSize: 4})
+
+	vectors, err := e.Embed(context.Background(), inputs(10))
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vectors) != 10 {
+		t.Fatalf("got %d vectors, want 10", len(vectors))
+	}
+	for i, v := range vectors {
+		if v[0] != float32(i) {
+			t.Errorf("vector %d belongs to input %v", i, v[0])
+		}
+	}
+	sizes := map[int]int{}
+	for _, b := range es.batches {
+		sizes[len(b)]++
+	}
+	if len(es.batches) != 3 || sizes[4] != 2 || sizes[2] != 1 {
+		t.Fatalf("batches = %v, want sizes 4, 4, 2", es.batches)
+	}
+}
+
+func TestBatchEmbedderTruncatesLongInputs(t *testing.T) {
+	es := &embeddingServer{}
+	srv := httptest.NewServer(es)
+	defer srv.Close()
+	// 2 个 token 约为 8 个 ASCII 字符或 2 个其他字符
+	e := newTestEmbedder(t, srv, config.EmbedderConfig{MaxTokens: 2, BatchSize: 8})
+
+	if _, err := e.Embed(context.Background(), []string{"t0-abcdefghij", "t1", "t2你好世界"}); err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	want := []string{"t0-abcde", "t1", "t2你"}
+	got := es.batches[0]
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("sent %q, want %q", got, want)
+	}
+}
+
+func TestBatchEmbedderRetriesRateLimitAndServerErrors(t *testing.T) {
+	es := &embeddingServer{respond: func(w http.ResponseWriter, call int) bool {
+		switch call {
+		case 1:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return true
+		case 2:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return true
+		}
+		return false
+	}}
+	srv := httptest.NewServer(es)
+	defer srv.Close()
+	e := newTestEmbedder(t, srv, config.EmbedderConfig{MaxRetries: 3})
+
+	start := time.Now()
+	vectors, err := e.Embed(context.Background(), inputs(3))
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if len(vectors) != 3 {
+		t.Fatalf("got %d vectors, want 3", len(vectors))
+	}
+	if calls := es.calls.Load(); calls != 3 {
+		t.Fatalf("server called %d times, want 3", calls)
+	}
+	// 退避只有 1ms，等待时间来自 Retry-After
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("retried after %v, want at least the Retry-After of 1s", elapsed)
+	}
+}
+
+func TestBatchEmbedderGivesUpAfterMaxRetries(t *testing.T) {
+	es := &embeddingServer{respond: func(w http.ResponseWriter, call int) bool {
+		http.Error(w, "boom", http.StatusInternalServerError)
+		return true
+	}}
+	srv := httptest.NewServer(es)
+	defer srv.Close()
+	e := newTestEmbedder(t, srv, config.EmbedderConfig{MaxRetries: 2})
+
+	_, err := e.Embed(context.Background(), inputs(1))
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Embed err = %v, want StatusError 500", err)
+	}
+	if calls := es.calls.Load(); calls != 3 {
+		t.Fatalf("server called %d times, want 1 + 2 retries", calls)
+	}
+}
+
+func TestBatchEmbedderDoesNotRetryClientErrors(t *testing.T) {
+	es := &embeddingServer{respond: func(w http.ResponseWriter, call int) bool {
+		http.Error(w, "bad api key", http.StatusUnauthorized)
+		return true
+	}}
+	srv := httptest.NewServer(es)
+	defer srv.Close()
+	e := newTestEmbedder(t, srv, config.EmbedderConfig{MaxRetries: 3})
+
+	_, err := e.Embed(context.Background(), inputs(1))
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("Embed err = %v, want StatusError 401", err)
+	}
+	if calls := es.calls.Load(); calls != 1 {
+		t.Fatalf("server called %d times, want 1", calls)
+	}
+}
+
+func TestBatchEmbedderLimitsConcurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	es := &embeddingServer{respond: func(w http.ResponseWriter, call int) bool {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return false
+	}}
+	srv := httptest.NewServer(es)
+	defer srv.Close()
+	e := newTestEmbedder(t, srv, config.EmbedderConfig{BatchSize: 1, Concurrency: 2})
+
+	// 两次调用共享同一并发上限
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := e.Embed(context.Background(), inputs(6)); err != nil {
+				t.Errorf("Embed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls := es.calls.Load(); calls != 12 {
+		t.Fatalf("server called %d times, want 12", calls)
+	}
+	if p := peak.Load(); p != 2 {
+		t.Fatalf("peak concurrent requests = %d, want 2", p)
+	}
+}
+
+func TestOpenAIEmbedderRejectsIncompleteResponse(t *testing.T) {
+	cases := map[string]string{
+		"missing index":   `{"data":[{"index":0,"embedding":[1,2]}]}`,
+		"duplicate index": `{"data":[{"index":0,"embedding":[1,2]},{"index":0,"embedding":[3,4]}]}`,
+		"out of range":    `{"data":[{"index":0,"embedding":[1,2]},{"index":2,"embedding":[3,4]}]}`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(body))
+			}))
+			defer srv.Close()
+			e, err := NewOpenAIEmbedder(config.EmbedderConfig{BaseURL: srv.URL, Model: "m", Dimension: 2})
+			if err != nil {
+				t.Fatalf("NewOpenAIEmbedder: %v", err)
+			}
+			vectors, err := e.Embed(context.Background(), []string{"a", "b"})
+			if err == nil {
+				t.Fatalf("Embed returned %v, want an error", vectors)
+			}
+		})
+	}
+}
+
+func TestOllamaEmbedder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if r.URL.Path != "/api/embed" || json.NewDecoder(r.Body).Decode(&req) != nil || req.Model != "m" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		embeddings := make([][]float32, len(req.Input))
+		for i := range req.Input {
+			embeddings[i] = []float32{float32(i), 1}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+	}))
+	defer srv.Close()
+	inner, err := NewOllamaEmbedder(config.EmbedderConfig{BaseURL: srv.URL, Model: "m", Dimension: 2})
+	if err != nil {
+		t.Fatalf("NewOllamaEmbedder: %v", err)
+	}
+	e := NewBatchEmbedder(inner, config.EmbedderConfig{BatchSize: 2})
+
+	vectors, err := e.Embed(context.Background(), []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	// 第二批只有一个输入，其向量序号从 0 开始
+	want := []float32{0, 1, 0}
+	for i, v := range vectors {
+		if v[0] != want[i] {
+			t.Errorf("vector %d = %v, want first element %v", i, v, want[i])
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const defaultMaxExtractSize = 20 << 20
+
+var (
+	ErrUnsupported = errors.New("不支持为该类型的文件建立索引")
+	ErrTooLarge    = errors.New("文件超过建立索引的大小上限")
+)
+
+// textExtensions 按纯文本处理的扩展名
+var textExtensions = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".rst": true, ".log": true,
+	".csv": true, ".tsv": true, ".json": true, ".xml": true, ".yaml": true, ".yml": true, ".toml": true, ".ini": true,
+	".html": true, ".htm": true,
+	".go": true, ".py": true, ".js": true, ".ts": true, ".java": true, ".c": true, ".h": true, ".cpp": true,
+	".rs": true, ".rb": true, ".php": true, ".sh": true, ".sql": true, ".css": true,
+}
+
+var (
+	htmlDropBlocks = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	htmlTags       = regexp.MustCompile(`(?s)<[^>]*>`)
+	blankLines     = regexp.MustCompile(`\n[ \t]*\n(\s*\n)+`)
+)
+
+// TextExtractor 处理纯文本、标记语言与源代码文件，HTML 去除标签后保留正文
+type TextExtractor struct {
+	maxSize int64
+}
+
+// NewTextExtractor 创建文本提取器，maxSize 为允许处理的最大文件大小（字节）
+func NewTextExtractor(maxSize int64) *TextExtractor {
+	if maxSize <= 0 {
+		maxSize = defaultMaxExtractSize
+	}
+	return &TextExtractor{maxSize: maxSize}
+}
+
+func (e *TextExtractor) Supports(name string, mimeType string) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	return textExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+func (e *TextExtractor) Extract(ctx context.Context, reader io.Reader, name string, mimeType string) (string, error) {
+	if !e.Supports(name, mimeType) {
+		return "", ErrUnsupported
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, e.maxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+	if int64(len(data)) > e.maxSize {
+		return "", ErrTooLarge
+	}
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%w: 文件不是 UTF-8 文本", ErrUnsupported)
+	}
+	text := strings.TrimPrefix(string(data), "\uFEFF")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	ext := strings.ToLower(filepath.Ext(name))
+	if mimeType == "text/html" || ext == ".html" || ext == ".htm" {
+		text = stripHTML(text)
+	}
+	return text, nil
+}
+
+// stripHTML 去除脚本、样式与标签，并合并多余的空行
+func stripHTML(s string) string {
+	s = htmlDropBlocks.ReplaceAllString(s, "")
+	s = htmlTags.ReplaceAllString(s, "")
+	r := strings.NewReplacer("&nbsp;", " ", "&lt;", "<", "&gt;", ">", "&quot;", "\"", "&#39;", "'", "&amp;", "&")
+	s = r.Replace(s)
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
@@ -0,0 +1,84 @@
+package rag
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// HashEmbedder 本地哈希嵌入：将词语（中日韩文字取单字与相邻二字）哈希到固定维度的向量（feature hashing）
+// 结果确定且无需模型，共享词语越多的文本相似度越高，但不理解同义词，供离线运行与测试使用
+type HashEmbedder struct {
+	dim int
+}
+
+func NewHashEmbedder(dim int) *HashEmbedder {
+	if dim <= 0 {
+		dim = defaultEmbedDimension
+	}
+	return &HashEmbedder{dim: dim}
+}
+
+func (e *HashEmbedder) Dimension() int {
+	return e.dim
+}
+
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		v := make([]float32, e.dim)
+		for _, token := range hashTokens(text) {
+			h := fnv.New64a()
+			h.Write([]byte(token))
+			sum := h.Sum64()
+			// 最高位决定符号，降低哈希冲突带来的偏差
+			if sum>>63 == 0 {
+				v[sum%uint64(e.dim)]++
+			} else {
+				v[sum%uint64(e.dim)]--
+			}
+		}
+		vectors[i] = normalize(v)
+	}
+	return vectors, nil
+}
+
+// hashTokens 将文本切分为小写的词语；中日韩文字没有空格分词，取单字与相邻二字
+func hashTokens(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	var prev rune
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flush()
+			tokens = append(tokens, string(r))
+			if prev != 0 {
+				tokens = append(tokens, string([]rune{prev, r}))
+			}
+			prev = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+		prev = 0
+	}
+	flush()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
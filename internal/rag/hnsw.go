@@ -0,0 +1,225 @@
+package rag
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// hnsw 分层可导航小世界图（Hierarchical Navigable Small World）近似最近邻索引
+// 节点保存已归一化的向量，距离为 1-余弦相似度；删除只做标记，标记过多时由调用方重建
+type hnsw struct {
+	m              int // 上层每个节点的最大邻居数，第 0 层为 2m
+	efConstruction int
+	levelMult      float64
+	rng            *rand.Rand
+
+	nodes    []*hnswNode
+	ids      map[string]int32 // 记录ID -> 有效节点
+	entry    int32
+	maxLevel int
+	deleted  int
+}
+
+type hnswNode struct {
+	id        string
+	vector    []float32
+	neighbors [][]int32 // 每层的邻居
+	deleted   bool
+}
+
+// candidate 候选节点及其与查询向量的距离
+type candidate struct {
+	node int32
+	dist float32
+}
+
+// minHeap 距离最近的候选在堆顶
+type minHeap []candidate
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// maxHeap 距离最远的候选在堆顶
+type maxHeap struct{ minHeap }
+
+func (h maxHeap) Less(i, j int) bool { return h.minHeap[i].dist > h.minHeap[j].dist }
+
+func newHNSW(m int, efConstruction int) *hnsw {
+	return &hnsw{
+		m:              m,
+		efConstruction: efConstruction,
+		levelMult:      1 / math.Log(float64(m)),
+		rng:            rand.New(rand.NewSource(1)),
+		ids:            make(map[string]int32),
+		entry:          -1,
+	}
+}
+
+// live 有效节点数
+func (h *hnsw) live() int {
+	return len(h.nodes) - h.deleted
+}
+
+func (h *hnsw) distance(q []float32, node int32) float32 {
+	return 1 - dot(q, h.nodes[node].vector)
+}
+
+func (h *hnsw) maxNeighbors(level int) int {
+	if level == 0 {
+		return 2 * h.m
+	}
+	return h.m
+}
+
+// add 插入节点，已存在同ID节点时先将其标记删除
+func (h *hnsw) add(id string, vector []float32) {
+	h.remove(id)
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+	node := &hnswNode{id: id, vector: vector, neighbors: make([][]int32, level+1)}
+	n := int32(len(h.nodes))
+	h.nodes = append(h.nodes, node)
+	h.ids[id] = n
+	if h.entry < 0 {
+		h.entry, h.maxLevel = n, level
+		return
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.searchLayer(vector, []int32{ep}, 1, l)[0].node
+	}
+	eps := []int32{ep}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		found := h.searchLayer(vector, eps, h.efConstruction, l)
+		neighbors := h.selectNeighbors(found, h.m)
+		node.neighbors[l] = neighbors
+		for _, nb := range neighbors {
+			h.link(nb, n, l)
+		}
+		eps = eps[:0]
+		for _, c := range found {
+			eps = append(eps, c.node)
+		}
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = n, level
+	}
+}
+
+// link 为 from 在第 level 层增加指向 to 的边，超出上限时只保留最近的邻居
+func (h *hnsw) link(from int32, to int32, level int) {
+	node := h.nodes[from]
+	node.neighbors[level] = append(node.neighbors[level], to)
+	limit := h.maxNeighbors(level)
+	if len(node.neighbors[level]) <= limit {
+		return
+	}
+	cands := make([]candidate, len(node.neighbors[level]))
+	for i, nb := range node.neighbors[level] {
+		cands[i] = candidate{node: nb, dist: h.distance(node.vector, nb)}
+	}
+	node.neighbors[level] = h.selectNeighbors(cands, limit)
+}
+
+// selectNeighbors 选取距离最近的至多 limit 个候选
+func (h *hnsw) selectNeighbors(cands []candidate, limit int) []int32 {
+	sorted := append([]candidate(nil), cands...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].dist < sorted[j].dist })
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+	out := make([]int32, len(sorted))
+	for i, c := range sorted {
+		out[i] = c.node
+	}
+	return out
+}
+
+// searchLayer 在第 level 层从 eps 出发贪心扩展，返回距离最近的至多 ef 个节点（按距离升序）
+// 被标记删除的节点仍参与导航，由调用方在结果中排除
+func (h *hnsw) searchLayer(q []float32, eps []int32, ef int, level int) []candidate {
+	visited := make(map[int32]bool, ef*4)
+	cands := &minHeap{}
+	results := &maxHeap{}
+	for _, ep := range eps {
+		if visited[ep] {
+			continue
+		}
+		visited[ep] = true
+		c := candidate{node: ep, dist: h.distance(q, ep)}
+		heap.Push(cands, c)
+		heap.Push(results, c)
+	}
+	for cands.Len() > 0 {
+		c := heap.Pop(cands).(candidate)
+		if results.Len() >= ef && c.dist > results.minHeap[0].dist {
+			break
+		}
+		node := h.nodes[c.node]
+		if level >= len(node.neighbors) {
+			continue
+		}
+		for _, nb := range node.neighbors[level] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+			d := h.distance(q, nb)
+			if results.Len() < ef || d < results.minHeap[0].dist {
+				heap.Push(cands, candidate{node: nb, dist: d})
+				heap.Push(results, candidate{node: nb, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+	out := append([]candidate(nil), results.minHeap...)
+	sort.Slice(out, func(i, j int) bool { return out[i].dist < out[j].dist })
+	return out
+}
+
+// search 返回与 q 最相近的至多 k 个满足 accept 的有效节点，ef 为第 0 层的候选集大小
+func (h *hnsw) search(q []float32, k int, ef int, accept func(id string) bool) []candidate {
+	if h.entry < 0 {
+		return nil
+	}
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.searchLayer(q, []int32{ep}, 1, l)[0].node
+	}
+	found := h.searchLayer(q, []int32{ep}, max(ef, k), 0)
+	out := make([]candidate, 0, k)
+	for _, c := range found {
+		node := h.nodes[c.node]
+		if node.deleted || !accept(node.id) {
+			continue
+		}
+		out = append(out, c)
+		if len(out) == k {
+			break
+		}
+	}
+	return out
+}
+
+// remove 将节点标记为删除
+func (h *hnsw) remove(id string) {
+	n, ok := h.ids[id]
+	if !ok {
+		return
+	}
+	h.nodes[n].deleted = true
+	delete(h.ids, id)
+	h.deleted++
+}
@@ -0,0 +1,76 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const maxErrorBodySize = 4096
+
+// StatusError 模型服务返回的非 2xx 响应
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // 服务端通过 Retry-After 要求的等待时间，未指定时为 0
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("model server returned %d: %s", e.StatusCode, e.Body)
+}
+
+// newJSONRequest 创建 JSON 请求，apiKey 非空时以 Bearer 方式认证
+func newJSONRequest(ctx context.Context, url string, apiKey string, body interface{}) (*http.Request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	return req, nil
+}
+
+// postJSON 发送 JSON 请求并将响应解析到 out，非 2xx 响应返回 *StatusError
+func postJSON(ctx context.Context, client *http.Client, url string, apiKey string, body interface{}, out interface{}) error {
+	req, err := newJSONRequest(ctx, url, apiKey, body)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid model server response: %w", err)
+	}
+	return nil
+}
+
+// checkStatus 将非 2xx 响应转换为 *StatusError
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	se := &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		se.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return se
+}
@@ -0,0 +1,43 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"llmcloud/config"
+	"time"
+)
+
+const defaultLLMTimeout = 5 * time.Minute
+
+// 对话消息的角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message 对话中的一条消息
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// LLM 语言模型，以流的方式生成回答
+type LLM interface {
+	// Stream 根据对话生成回答，每得到一段文本即调用 onDelta；onDelta 返回错误时停止生成并返回该错误
+	Stream(ctx context.Context, messages []Message, onDelta func(delta string) error) error
+}
+
+// NewLLM 根据配置初始化语言模型
+func NewLLM(cfg config.LLMConfig) (LLM, error) {
+	switch cfg.Provider {
+	case "", "scripted":
+		return NewScriptedLLM(cfg.Script...), nil
+	case "openai":
+		return NewOpenAILLM(cfg)
+	case "ollama":
+		return NewOllamaLLM(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported llm provider: %s", cfg.Provider)
+	}
+}
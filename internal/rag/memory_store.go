@@ -0,0 +1,320 @@
+package rag
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHNSWM            = 16
+	defaultEfConstruction   = 200
+	defaultEfSearch         = 64
+	defaultSnapshotInterval = time.Minute
+)
+
+// MemoryStore 进程内的向量库：记录保存在内存中，可选 HNSW 近似索引，按间隔将记录快照写入磁盘
+type MemoryStore struct {
+	mu       sync.RWMutex
+	dim      int
+	records  map[string]Record          // 记录ID -> 记录
+	byFile   map[string]map[string]bool // 文件ID -> 记录ID
+	index    *hnsw                      // 为空时使用暴力检索
+	dirty    bool                       // 上次快照后是否有变化
+	efSearch int
+
+	useHNSW          bool
+	m                int
+	efConstruction   int
+	snapshotPath     string
+	snapshotInterval time.Duration
+}
+
+// NewMemoryStore 创建进程内向量库，配置了快照路径时从快照恢复记录
+func NewMemoryStore(cfg config.MemoryStoreConfig, dim int) (*MemoryStore, error) {
+	s := &MemoryStore{
+		dim:              dim,
+		records:          make(map[string]Record),
+		byFile:           make(map[string]map[string]bool),
+		efSearch:         cfg.EfSearch,
+		m:                cfg.HNSWM,
+		efConstruction:   cfg.EfConstruction,
+		snapshotPath:     cfg.SnapshotPath,
+		snapshotInterval: parseDuration(cfg.SnapshotInterval, defaultSnapshotInterval),
+	}
+	switch cfg.Index {
+	case "", "hnsw":
+		s.useHNSW = true
+	case "flat":
+	default:
+		return nil, fmt.Errorf("unsupported vector index: %s", cfg.Index)
+	}
+	if s.m <= 1 {
+		s.m = defaultHNSWM
+	}
+	if s.efConstruction <= 0 {
+		s.efConstruction = defaultEfConstruction
+	}
+	if s.efSearch <= 0 {
+		s.efSearch = defaultEfSearch
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	s.rebuild()
+	return s, nil
+}
+
+func (s *MemoryStore) Upsert(ctx context.Context, records []Record) error {
+	for _, r := range records {
+		if len(r.Vector) != s.dim {
+			return fmt.Errorf("向量维度不匹配: 期望 %d，实际 %d", s.dim, len(r.Vector))
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range records {
+		r.Vector = normalize(append([]float32(nil), r.Vector...))
+		if old, ok := s.records[r.ID]; ok && old.FileID != r.FileID {
+			delete(s.byFile[old.FileID], r.ID)
+		}
+		s.records[r.ID] = r
+		if s.byFile[r.FileID] == nil {
+			s.byFile[r.FileID] = make(map[string]bool)
+		}
+		s.byFile[r.FileID][r.ID] = true
+		if s.index != nil {
+			s.index.add(r.ID, r.Vector)
+		}
+	}
+	s.dirty = true
+	return nil
+}
+
+func (s *MemoryStore) DeleteByFile(ctx context.Context, fileID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids, ok := s.byFile[fileID]
+	if !ok {
+		return nil
+	}
+	for id := range ids {
+		delete(s.records, id)
+		if s.index != nil {
+			s.index.remove(id)
+		}
+	}
+	delete(s.byFile, fileID)
+	s.dirty = true
+	// 已删除的节点过多时重建索引，释放内存并保证检索质量
+	if s.index != nil && s.index.deleted > s.index.live() {
+		s.rebuild()
+	}
+	return nil
+}
+
+func (s *MemoryStore) Search(ctx context.Context, vector []float32, k int, filter Filter) ([]Hit, error) {
+	if len(vector) != s.dim {
+		return nil, fmt.Errorf("向量维度不匹配: 期望 %d，实际 %d", s.dim, len(vector))
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+	q := normalize(append([]float32(nil), vector...))
+	match := filter.matcher()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	// 只限定了少量文件时直接计算这些文件的记录
+	if len(filter.UserIDs) == 0 && len(filter.FileIDs) > 0 {
+		var ids []string
+		for _, fileID := range filter.FileIDs {
+			for id := range s.byFile[fileID] {
+				ids = append(ids, id)
+			}
+		}
+		return s.exact(q, k, ids, match), nil
+	}
+	if s.index != nil {
+		found := s.index.search(q, k, max(s.efSearch, 4*k), func(id string) bool {
+			r := s.records[id]
+			return match(&r)
+		})
+		// 近似检索在过滤条件很严格时可能不足 k 条，退回暴力检索
+		if len(found) == k || len(found) == len(s.records) {
+			hits := make([]Hit, 0, len(found))
+			for _, c := range found {
+				hits = append(hits, s.hit(s.records[s.index.nodes[c.node].id], 1-c.dist))
+			}
+			return hits, nil
+		}
+	}
+	ids := make([]string, 0, len(s.records))
+	for id := range s.records {
+		ids = append(ids, id)
+	}
+	return s.exact(q, k, ids, match), nil
+}
+
+// exact 在给定记录中精确计算相似度最高的 k 条
+func (s *MemoryStore) exact(q []float32, k int, ids []string, match func(r *Record) bool) []Hit {
+	hits := make([]Hit, 0, len(ids))
+	for _, id := range ids {
+		r := s.records[id]
+		if !match(&r) {
+			continue
+		}
+		hits = append(hits, s.hit(r, dot(q, r.Vector)))
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+func (s *MemoryStore) hit(r Record, score float32) Hit {
+	r.Vector = nil
+	return Hit{Record: r, Score: score}
+}
+
+// rebuild 根据当前记录重建 HNSW 索引；按记录ID顺序插入，使重建结果可复现
+func (s *MemoryStore) rebuild() {
+	if !s.useHNSW {
+		return
+	}
+	ids := make([]string, 0, len(s.records))
+	for id := range s.records {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	s.index = newHNSW(s.m, s.efConstruction)
+	for _, id := range ids {
+		s.index.add(id, s.records[id].Vector)
+	}
+}
+
+// memorySnapshot 快照文件的内容
+type memorySnapshot struct {
+	Dimension int
+	Records   []Record
+}
+
+// load 从快照恢复记录，快照不存在时从空库开始
+func (s *MemoryStore) load() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	f, err := os.Open(s.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("打开向量快照失败: %w", err)
+	}
+	defer f.Close()
+	var snap memorySnapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		return fmt.Errorf("读取向量快照失败: %w", err)
+	}
+	// 维度变化后旧向量不可用，丢弃快照，由后续的索引任务重新生成
+	if snap.Dimension != s.dim {
+		log.Printf("向量快照维度(%d)与配置(%d)不一致，已忽略", snap.Dimension, s.dim)
+		return nil
+	}
+	for _, r := range snap.Records {
+		s.records[r.ID] = r
+		if s.byFile[r.FileID] == nil {
+			s.byFile[r.FileID] = make(map[string]bool)
+		}
+		s.byFile[r.FileID][r.ID] = true
+	}
+	return nil
+}
+
+// Snapshot 在有变化时将全部记录写入快照文件；先写临时文件再重命名，避免中断时留下不完整的快照
+func (s *MemoryStore) Snapshot() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	snap := memorySnapshot{Dimension: s.dim, Records: make([]Record, 0, len(s.records))}
+	for _, r := range s.records {
+		snap.Records = append(snap.Records, r)
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err := s.writeSnapshot(&snap); err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (s *MemoryStore) writeSnapshot(snap *memorySnapshot) error {
+	if err := os.MkdirAll(filepath.Dir(s.snapshotPath), 0755); err != nil {
+		return fmt.Errorf("创建快照目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.snapshotPath), filepath.Base(s.snapshotPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("创建快照文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入向量快照失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入向量快照失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入向量快照失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.snapshotPath); err != nil {
+		return fmt.Errorf("保存向量快照失败: %w", err)
+	}
+	return nil
+}
+
+// RunSnapshots 按配置的间隔写入快照，直到 ctx 结束；结束前再写入一次
+func (s *MemoryStore) RunSnapshots(ctx context.Context) {
+	if s.snapshotPath == "" {
+		return
+	}
+	ticker := time.NewTicker(s.snapshotInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.Snapshot(); err != nil {
+				log.Printf("写入向量快照失败: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil {
+				log.Printf("写入向量快照失败: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"llmcloud/config"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultMilvusTimeout    = 10 * time.Second
+	defaultMilvusCollection = "llmcloud_chunks"
+	milvusUpsertBatchSize   = 100
+	milvusMaxTextLength     = 65535
+)
+
+// milvusOutputFields 检索时返回的字段
+var milvusOutputFields = []string{"id", "file_id", "user_id", "chunk_index", "text", "start_offset", "end_offset"}
+
+// MilvusStore 基于 Milvus RESTful API（v2）的向量库，集合不存在时自动创建
+type MilvusStore struct {
+	client     *http.Client
+	endpoint   string
+	token      string
+	database   string
+	collection string
+	dim        int
+}
+
+// NewMilvusStore 创建 Milvus 向量库实例，确保集合存在并已加载
+func NewMilvusStore(cfg config.MilvusConfig, dim int) (*MilvusStore, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("milvus endpoint is required")
+	}
+	s := &MilvusStore{
+		client:     &http.Client{Timeout: parseDuration(cfg.Timeout, defaultMilvusTimeout)},
+		endpoint:   strings.TrimRight(cfg.Endpoint, "/"),
+		token:      cfg.Token,
+		database:   cfg.Database,
+		collection: cfg.Collection,
+		dim:        dim,
+	}
+	if s.collection == "" {
+		s.collection = defaultMilvusCollection
+	}
+	if err := s.ensureCollection(context.Background()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *MilvusStore) Upsert(ctx context.Context, records []Record) error {
+	for start := 0; start < len(records); start += milvusUpsertBatchSize {
+		end := min(start+milvusUpsertBatchSize, len(records))
+		rows := make([]map[string]interface{}, 0, end-start)
+		for _, r := range records[start:end] {
+			if len(r.Vector) != s.dim {
+				return fmt.Errorf("向量维度不匹配: 期望 %d，实际 %d", s.dim, len(r.Vector))
+			}
+			rows = append(rows, map[string]interface{}{
+				"id":           r.ID,
+				"file_id":      r.FileID,
+				"user_id":      r.UserID,
+				"chunk_index":  r.Index,
+				"text":         truncateBytes(r.Text, milvusMaxTextLength),
+				"start_offset": r.Start,
+				"end_offset":   r.End,
+				"vector":       r.Vector,
+			})
+		}
+		if err := s.call(ctx, "/v2/vectordb/entities/upsert", map[string]interface{}{"data": rows}, nil); err != nil {
+			return fmt.Errorf("failed to upsert vectors: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *MilvusStore) DeleteByFile(ctx context.Context, fileID string) error {
+	body := map[string]interface{}{"filter": "file_id == " + milvusString(fileID)}
+	if err := s.call(ctx, "/v2/vectordb/entities/delete", body, nil); err != nil {
+		return fmt.Errorf("failed to delete vectors: %w", err)
+	}
+	return nil
+}
+
+func (s *MilvusStore) Search(ctx context.Context, vector []float32, k int, filter Filter) ([]Hit, error) {
+	if len(vector) != s.dim {
+		return nil, fmt.Errorf("向量维度不匹配: 期望 %d，实际 %d", s.dim, len(vector))
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+	body := map[string]interface{}{
+		"data":         [][]float32{vector},
+		"annsField":    "vector",
+		"limit":        k,
+		"outputFields": milvusOutputFields,
+	}
+	if expr := milvusFilter(filter); expr != "" {
+		body["filter"] = expr
+	}
+	var rows []struct {
+		ID          string  `json:"id"`
+		FileID      string  `json:"file_id"`
+		UserID      uint    `json:"user_id"`
+		ChunkIndex  int     `json:"chunk_index"`
+		Text        string  `json:"text"`
+		StartOffset int     `json:"start_offset"`
+		EndOffset   int     `json:"end_offset"`
+		Distance    float32 `json:"distance"` // COSINE 度量下为相似度，越大越相近
+	}
+	if err := s.call(ctx, "/v2/vectordb/entities/search", body, &rows); err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+	hits := make([]Hit, 0, len(rows))
+	for _, row := range rows {
+		hits = append(hits, Hit{
+			Record: Record{
+				ID:     row.ID,
+				FileID: row.FileID,
+				UserID: row.UserID,
+				Chunk:  Chunk{Index: row.ChunkIndex, Text: row.Text, Start: row.StartOffset, End: row.EndOffset},
+			},
+			Score: row.Distance,
+		})
+	}
+	return hits, nil
+}
+
+// ensureCollection 集合不存在时按记录结构创建，并加载到内存以供检索
+func (s *MilvusStore) ensureCollection(ctx context.Context) error {
+	var has struct {
+		Has bool `json:"has"`
+	}
+	if err := s.call(ctx, "/v2/vectordb/collections/has", nil, &has); err != nil {
+		return fmt.Errorf("failed to check collection existence: %w", err)
+	}
+	if !has.Has {
+		schema := map[string]interface{}{
+			"autoId":             false,
+			"enableDynamicField": false,
+			"fields": []map[string]interface{}{
+				{"fieldName": "id", "dataType": "VarChar", "isPrimary": true, "elementTypeParams": map[string]interface{}{"max_length": 128}},
+				{"fieldName": "file_id", "dataType": "VarChar", "elementTypeParams": map[string]interface{}{"max_length": 64}},
+				{"fieldName": "user_id", "dataType": "Int64"},
+				{"fieldName": "chunk_index", "dataType": "Int64"},
+				{"fieldName": "text", "dataType": "VarChar", "elementTypeParams": map[string]interface{}{"max_length": milvusMaxTextLength}},
+				{"fieldName": "start_offset", "dataType": "Int64"},
+				{"fieldName": "end_offset", "dataType": "Int64"},
+				{"fieldName": "vector", "dataType": "FloatVector", "elementTypeParams": map[string]interface{}{"dim": s.dim}},
+			},
+		}
+		indexParams := []map[string]interface{}{
+			{"fieldName": "vector", "indexName": "vector", "metricType": "COSINE", "indexType": "AUTOINDEX"},
+			{"fieldName": "file_id", "indexName": "file_id", "indexType": "INVERTED"},
+			{"fieldName": "user_id", "indexName": "user_id", "indexType": "INVERTED"},
+		}
+		body := map[string]interface{}{"schema": schema, "indexParams": indexParams}
+		if err := s.call(ctx, "/v2/vectordb/collections/create", body, nil); err != nil {
+			return fmt.Errorf("failed to create collection: %w", err)
+		}
+	}
+	if err := s.call(ctx, "/v2/vectordb/collections/load", nil, nil); err != nil {
+		return fmt.Errorf("failed to load collection: %w", err)
+	}
+	return nil
+}
+
+// call 调用 Milvus RESTful 接口；请求体自动带上数据库与集合名，out 非空时解析响应中的 data 字段
+func (s *MilvusStore) call(ctx context.Context, path string, body map[string]interface{}, out interface{}) error {
+	if body == nil {
+		body = make(map[string]interface{})
+	}
+	body["collectionName"] = s.collection
+	if s.database != "" {
+		body["dbName"] = s.database
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("milvus returned %s: %s", resp.Status, strings.TrimSpace(string(raw)))
+	}
+	var envelope struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("invalid milvus response: %w", err)
+	}
+	if envelope.Code != 0 {
+		return fmt.Errorf("milvus error %d: %s", envelope.Code, envelope.Message)
+	}
+	if out != nil && len(envelope.Data) > 0 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return fmt.Errorf("invalid milvus response: %w", err)
+		}
+	}
+	return nil
+}
+
+// milvusFilter 将检索范围转换为 Milvus 过滤表达式，不限制时返回空串
+func milvusFilter(filter Filter) string {
+	var parts []string
+	if len(filter.UserIDs) > 0 {
+		ids := make([]string, len(filter.UserIDs))
+		for i, id := range filter.UserIDs {
+			ids[i] = strconv.FormatUint(uint64(id), 10)
+		}
+		parts = append(parts, "user_id in ["+strings.Join(ids, ", ")+"]")
+	}
+	if len(filter.FileIDs) > 0 {
+		ids := make([]string, len(filter.FileIDs))
+		for i, id := range filter.FileIDs {
+			ids[i] = milvusString(id)
+		}
+		parts = append(parts, "file_id in ["+strings.Join(ids, ", ")+"]")
+	}
+	return strings.Join(parts, " or ")
+}
+
+// milvusString 将字符串转义为过滤表达式中的字符串字面量
+func milvusString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// truncateBytes 将字符串截断到不超过 n 字节，不切断多字节字符
+func truncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
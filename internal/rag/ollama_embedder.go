@@ -0,0 +1,59 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"llmcloud/config"
+	"net/http"
+	"strings"
+)
+
+const defaultOllamaBaseURL = "http://localhost:11434"
+
+// OllamaEmbedder Ollama 的嵌入接口（POST {base_url}/api/embed），一次请求嵌入全部输入
+type OllamaEmbedder struct {
+	client  *http.Client
+	baseURL string
+	model   string
+	dim     int
+}
+
+func NewOllamaEmbedder(cfg config.EmbedderConfig) (*OllamaEmbedder, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("embedder model is required")
+	}
+	if cfg.Dimension <= 0 {
+		return nil, fmt.Errorf("embedder dimension is required")
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	return &OllamaEmbedder{
+		client:  &http.Client{Timeout: parseDuration(cfg.Timeout, defaultEmbedTimeout)},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		model:   cfg.Model,
+		dim:     cfg.Dimension,
+	}, nil
+}
+
+func (e *OllamaEmbedder) Dimension() int {
+	return e.dim
+}
+
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body := map[string]interface{}{
+		"model": e.model,
+		"input": texts,
+	}
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	if err := postJSON(ctx, e.client, e.baseURL+"/api/embed", "", body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Embeddings, nil
+}
@@ -0,0 +1,96 @@
+package rag
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"llmcloud/config"
+	"net/http"
+	"strings"
+)
+
+// OllamaLLM Ollama 的对话接口（POST {base_url}/api/chat），逐行读取流式返回的 JSON
+type OllamaLLM struct {
+	client      *http.Client
+	baseURL     string
+	model       string
+	temperature float64
+	maxTokens   int
+}
+
+func NewOllamaLLM(cfg config.LLMConfig) (*OllamaLLM, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("llm model is required")
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOllamaBaseURL
+	}
+	return &OllamaLLM{
+		client:      &http.Client{Timeout: parseDuration(cfg.Timeout, defaultLLMTimeout)},
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		model:       cfg.Model,
+		temperature: cfg.Temperature,
+		maxTokens:   cfg.MaxTokens,
+	}, nil
+}
+
+func (l *OllamaLLM) Stream(ctx context.Context, messages []Message, onDelta func(delta string) error) error {
+	options := map[string]interface{}{"temperature": l.temperature}
+	if l.maxTokens > 0 {
+		options["num_predict"] = l.maxTokens
+	}
+	body := map[string]interface{}{
+		"model":    l.model,
+		"messages": messages,
+		"stream":   true,
+		"options":  options,
+	}
+	req, err := newJSONRequest(ctx, l.baseURL+"/api/chat", "", body)
+	if err != nil {
+		return err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var chunk struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+			Done  bool   `json:"done"`
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return fmt.Errorf("invalid model server response: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("model server error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			if err := onDelta(chunk.Message.Content); err != nil {
+				return err
+			}
+		}
+		if chunk.Done {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("model server closed the stream unexpectedly")
+}
@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"llmcloud/config"
+	"net/http"
+	"strings"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIEmbedder 兼容 OpenAI 的嵌入接口（POST {base_url}/embeddings），一次请求嵌入全部输入
+type OpenAIEmbedder struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+	model   string
+	dim     int
+}
+
+func NewOpenAIEmbedder(cfg config.EmbedderConfig) (*OpenAIEmbedder, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("embedder model is required")
+	}
+	if cfg.Dimension <= 0 {
+		return nil, fmt.Errorf("embedder dimension is required")
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAIEmbedder{
+		client:  &http.Client{Timeout: parseDuration(cfg.Timeout, defaultEmbedTimeout)},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  cfg.APIKey,
+		model:   cfg.Model,
+		dim:     cfg.Dimension,
+	}, nil
+}
+
+func (e *OpenAIEmbedder) Dimension() int {
+	return e.dim
+}
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body := map[string]interface{}{
+		"model":           e.model,
+		"input":           texts,
+		"encoding_format": "float",
+	}
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := postJSON(ctx, e.client, e.baseURL+"/embeddings", e.apiKey, body, &resp); err != nil {
+		return nil, err
+	}
+	// 按 index 还原输入顺序，每个输入必须恰好有一个向量
+	vectors := make([][]float32, len(texts))
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("invalid model server response: index %d out of range", d.Index)
+		}
+		if vectors[d.Index] != nil {
+			return nil, fmt.Errorf("invalid model server response: duplicate index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("invalid model server response: missing embedding for index %d", i)
+		}
+	}
+	return vectors, nil
+}
@@ -0,0 +1,109 @@
+package rag
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"llmcloud/config"
+	"net/http"
+	"strings"
+)
+
+// maxStreamLineSize 流式响应中单行的长度上限
+const maxStreamLineSize = 1 << 20
+
+// OpenAILLM 兼容 OpenAI 的对话接口（POST {base_url}/chat/completions），以 SSE 流式读取回答
+type OpenAILLM struct {
+	client      *http.Client
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature float64
+	maxTokens   int
+}
+
+func NewOpenAILLM(cfg config.LLMConfig) (*OpenAILLM, error) {
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("llm model is required")
+	}
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAILLM{
+		client:      &http.Client{Timeout: parseDuration(cfg.Timeout, defaultLLMTimeout)},
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		apiKey:      cfg.APIKey,
+		model:       cfg.Model,
+		temperature: cfg.Temperature,
+		maxTokens:   cfg.MaxTokens,
+	}, nil
+}
+
+func (l *OpenAILLM) Stream(ctx context.Context, messages []Message, onDelta func(delta string) error) error {
+	body := map[string]interface{}{
+		"model":       l.model,
+		"messages":    messages,
+		"stream":      true,
+		"temperature": l.temperature,
+	}
+	if l.maxTokens > 0 {
+		body["max_tokens"] = l.maxTokens
+	}
+	req, err := newJSONRequest(ctx, l.baseURL+"/chat/completions", l.apiKey, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// 只关心 data 行，忽略空行、注释与其他字段
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("invalid model server response: %w", err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("model server error: %s", chunk.Error.Message)
+		}
+		for _, c := range chunk.Choices {
+			if c.Delta.Content == "" {
+				continue
+			}
+			if err := onDelta(c.Delta.Content); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return fmt.Errorf("model server closed the stream unexpectedly")
+}
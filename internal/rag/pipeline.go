@@ -0,0 +1,77 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Pipeline 索引流水线：提取文本、切分、嵌入，最后写入向量库
+// 各阶段均为接口，可替换为离线实现
+type Pipeline struct {
+	Extractor Extractor
+	Chunker   Chunker
+	Embedder  Embedder
+	Store     VectorStore
+}
+
+// Supports 判断文件是否可以建立索引
+func (p *Pipeline) Supports(name string, mimeType string) bool {
+	return p.Extractor.Supports(name, mimeType)
+}
+
+// Ingest 为文件建立索引，替换该文件已有的全部记录，返回片段数
+func (p *Pipeline) Ingest(ctx context.Context, doc Document, reader io.Reader) (int, error) {
+	text, err := p.Extractor.Extract(ctx, reader, doc.Name, doc.MIMEType)
+	if err != nil {
+		return 0, err
+	}
+	chunks := p.Chunker.Split(text)
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	// 分批与重试由嵌入模型负责
+	vectors, err := p.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return 0, fmt.Errorf("生成向量失败: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return 0, fmt.Errorf("生成向量失败: 期望 %d 个向量，实际 %d 个", len(texts), len(vectors))
+	}
+	records := make([]Record, len(chunks))
+	for i, c := range chunks {
+		records[i] = Record{
+			ID:     RecordID(doc.FileID, c.Index),
+			FileID: doc.FileID,
+			UserID: doc.UserID,
+			Chunk:  c,
+			Vector: vectors[i],
+		}
+	}
+	if doc.Current != nil {
+		current, err := doc.Current()
+		if err != nil {
+			return 0, err
+		}
+		if !current {
+			return 0, ErrSuperseded
+		}
+	}
+	// 先删除旧记录：新内容的片段可能比旧内容少
+	if err := p.Store.DeleteByFile(ctx, doc.FileID); err != nil {
+		return 0, fmt.Errorf("删除旧索引失败: %w", err)
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+	if err := p.Store.Upsert(ctx, records); err != nil {
+		return 0, fmt.Errorf("写入向量库失败: %w", err)
+	}
+	return len(records), nil
+}
+
+// Remove 删除文件的全部索引记录
+func (p *Pipeline) Remove(ctx context.Context, fileID string) error {
+	return p.Store.DeleteByFile(ctx, fileID)
+}
@@ -0,0 +1,187 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"llmcloud/config"
+	"strings"
+	"testing"
+)
+
+const testDocument = `分布式存储将文件切分为多个分片，分散保存在不同节点上。
+
+Each chunk is replicated to three nodes so that a single disk failure never loses data.
+
+向量检索先把文本转换为向量，再按余弦相似度查找最接近的片段。
+
+The ingest pipeline extracts text, splits it into chunks, embeds every chunk and upserts the records.`
+
+// newTestPipeline 组装离线流水线：哈希嵌入与暴力检索的内存向量库
+func newTestPipeline(t *testing.T) *Pipeline {
+	t.Helper()
+	embedder := NewHashEmbedder(128)
+	store, err := NewMemoryStore(config.MemoryStoreConfig{Index: "flat"}, embedder.Dimension())
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+	return &Pipeline{
+		Extractor: NewTextExtractor(0),
+		Chunker:   NewTextChunker(80, 10),
+		Embedder:  embedder,
+		Store:     store,
+	}
+}
+
+// fileRecords 检索文件的全部记录
+func fileRecords(t *testing.T, p *Pipeline, fileID string) []Hit {
+	t.Helper()
+	vectors, err := p.Embedder.Embed(context.Background(), []string{"query"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	hits, err := p.Store.Search(context.Background(), vectors[0], 1000, Filter{FileIDs: []string{fileID}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	return hits
+}
+
+func TestPipelineIngest(t *testing.T) {
+	p := newTestPipeline(t)
+	ctx := context.Background()
+	doc := Document{FileID: "f1", UserID: 7, Name: "notes.md", MIMEType: "text/markdown"}
+
+	n, err := p.Ingest(ctx, doc, strings.NewReader(testDocument))
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	chunks := p.Chunker.Split(testDocument)
+	if n != len(chunks) || n < 2 {
+		t.Fatalf("Ingest returned %d chunks, want %d (>= 2)", n, len(chunks))
+	}
+
+	hits := fileRecords(t, p, "f1")
+	if len(hits) != n {
+		t.Fatalf("store holds %d records, want %d", len(hits), n)
+	}
+	for _, h := range hits {
+		if h.UserID != 7 || h.FileID != "f1" || h.ID != RecordID("f1", h.Index) {
+			t.Errorf("record %s has owner %d file %s", h.ID, h.UserID, h.FileID)
+		}
+		if want := chunks[h.Index].Text; h.Text != want {
+			t.Errorf("record %d text = %q, want %q", h.Index, h.Text, want)
+		}
+	}
+
+	// 以某个片段本身作为查询，该片段应排在第一位
+	target := chunks[len(chunks)-1]
+	vectors, err := p.Embedder.Embed(ctx, []string{target.Text})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	top, err := p.Store.Search(ctx, vectors[0], 1, Filter{UserIDs: []uint{7}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(top) != 1 || top[0].Index != target.Index {
+		t.Fatalf("top hit = %+v, want chunk %d", top, target.Index)
+	}
+	if top[0].Score < 0.99 {
+		t.Errorf("self similarity = %f, want ~1", top[0].Score)
+	}
+
+	// 其他用户的过滤条件不应命中
+	other, err := p.Store.Search(ctx, vectors[0], 10, Filter{UserIDs: []uint{8}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(other) != 0 {
+		t.Fatalf("filter by another owner returned %d hits", len(other))
+	}
+}
+
+func TestPipelineReingestReplacesRecords(t *testing.T) {
+	p := newTestPipeline(t)
+	ctx := context.Background()
+	doc := Document{FileID: "f1", UserID: 1, Name: "notes.txt"}
+
+	if _, err := p.Ingest(ctx, doc, strings.NewReader(testDocument)); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	n, err := p.Ingest(ctx, doc, strings.NewReader("short replacement"))
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("Ingest returned %d chunks, want 1", n)
+	}
+	hits := fileRecords(t, p, "f1")
+	if len(hits) != 1 || hits[0].Text != "short replacement" {
+		t.Fatalf("records after re-ingest = %+v, want only the new chunk", hits)
+	}
+
+	// 内容为空时清除旧记录
+	if n, err = p.Ingest(ctx, doc, strings.NewReader("")); err != nil || n != 0 {
+		t.Fatalf("Ingest empty = %d, %v", n, err)
+	}
+	if hits := fileRecords(t, p, "f1"); len(hits) != 0 {
+		t.Fatalf("store still holds %d records", len(hits))
+	}
+}
+
+func TestPipelineUnsupported(t *testing.T) {
+	p := newTestPipeline(t)
+	ctx := context.Background()
+
+	if p.Supports("photo.png", "image/png") {
+		t.Fatal("image should not be supported")
+	}
+	_, err := p.Ingest(ctx, Document{FileID: "f1", Name: "photo.png", MIMEType: "image/png"}, strings.NewReader("x"))
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Ingest image err = %v, want ErrUnsupported", err)
+	}
+	_, err = p.Ingest(ctx, Document{FileID: "f2", Name: "data.txt"}, strings.NewReader("\xff\xfe\x00bad"))
+	if !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("Ingest invalid UTF-8 err = %v, want ErrUnsupported", err)
+	}
+	if hits := fileRecords(t, p, "f2"); len(hits) != 0 {
+		t.Fatalf("failed ingest left %d records", len(hits))
+	}
+}
+
+func TestPipelineRemove(t *testing.T) {
+	p := newTestPipeline(t)
+	ctx := context.Background()
+	for _, id := range []string{"f1", "f2"} {
+		if _, err := p.Ingest(ctx, Document{FileID: id, Name: id + ".txt"}, strings.NewReader(testDocument)); err != nil {
+			t.Fatalf("Ingest %s: %v", id, err)
+		}
+	}
+	if err := p.Remove(ctx, "f1"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if hits := fileRecords(t, p, "f1"); len(hits) != 0 {
+		t.Fatalf("removed file still has %d records", len(hits))
+	}
+	if hits := fileRecords(t, p, "f2"); len(hits) == 0 {
+		t.Fatal("removing f1 also removed f2")
+	}
+}
+
+func TestPipelineSkipsSupersededWrite(t *testing.T) {
+	p := newTestPipeline(t)
+	ctx := context.Background()
+	if _, err := p.Ingest(ctx, Document{FileID: "f1", Name: "notes.txt"}, strings.NewReader("new content")); err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+
+	// 旧版本的任务在新版本写入后才完成，不能覆盖新版本的记录
+	stale := Document{FileID: "f1", Name: "notes.txt", Current: func() (bool, error) { return false, nil }}
+	if _, err := p.Ingest(ctx, stale, strings.NewReader(testDocument)); !errors.Is(err, ErrSuperseded) {
+		t.Fatalf("Ingest err = %v, want ErrSuperseded", err)
+	}
+	hits := fileRecords(t, p, "f1")
+	if len(hits) != 1 || hits[0].Text != "new content" {
+		t.Fatalf("records = %+v, want only the newer content", hits)
+	}
+}
@@ -0,0 +1,76 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"llmcloud/config"
+)
+
+// ErrSuperseded 写入前发现文件内容已更新，本次结果不再写入向量库
+var ErrSuperseded = errors.New("文件内容已更新，放弃写入旧内容的索引")
+
+// Document 待建立索引的文件
+type Document struct {
+	FileID   string
+	UserID   uint // 文件所有者
+	Name     string
+	MIMEType string
+	// Current 可选，写入向量库前调用，返回 false 表示内容已被更新：
+	// 提取与嵌入耗时较长，期间新版本的索引可能已经写入，不能再被旧内容覆盖
+	Current func() (bool, error)
+}
+
+// Chunk 文本中的一个片段，Start/End 为片段在提取出的文本中的字符（rune）偏移，左闭右开
+type Chunk struct {
+	Index int
+	Text  string
+	Start int
+	End   int
+}
+
+// Record 写入向量库的一条记录
+type Record struct {
+	ID     string // 记录ID，由文件ID与片段序号组成
+	FileID string
+	UserID uint
+	Chunk
+	Vector []float32
+}
+
+// Extractor 从文件内容中提取纯文本
+type Extractor interface {
+	Supports(name string, mimeType string) bool                                                  // 是否能处理该类型的文件
+	Extract(ctx context.Context, reader io.Reader, name string, mimeType string) (string, error) // 提取文本，不支持或无法解码时返回 ErrUnsupported
+}
+
+// Chunker 将文本切分为适合嵌入的片段
+type Chunker interface {
+	Split(text string) []Chunk
+}
+
+// Embedder 将文本转换为向量
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error) // 按输入顺序返回向量
+	Dimension() int                                                 // 向量维度
+}
+
+// RecordID 返回文件第 index 个片段的记录ID
+func RecordID(fileID string, index int) string {
+	return fmt.Sprintf("%s-%d", fileID, index)
+}
+
+// NewPipeline 根据配置组装索引流水线，向量维度由嵌入模型决定
+func NewPipeline(cfg config.RAGConfig, embedder Embedder) (*Pipeline, error) {
+	store, err := NewVectorStore(cfg.VectorStore, embedder.Dimension())
+	if err != nil {
+		return nil, err
+	}
+	return &Pipeline{
+		Extractor: NewTextExtractor(cfg.MaxFileSize),
+		Chunker:   NewTextChunker(cfg.ChunkSize, cfg.ChunkOverlap),
+		Embedder:  embedder,
+		Store:     store,
+	}, nil
+}
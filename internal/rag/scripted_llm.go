@@ -0,0 +1,46 @@
+package rag
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultScript 未配置脚本时的回答
+var defaultScript = []string{"当前未配置语言模型，", "请参考下方引用的资料 [1]。"}
+
+// ScriptedLLM 按脚本依次输出固定的回答片段，不理解问题，供离线运行与测试使用
+// 收到的对话会被记录下来，便于检查构造的提示词
+type ScriptedLLM struct {
+	script []string
+	mu     sync.Mutex
+	calls  [][]Message
+}
+
+func NewScriptedLLM(script ...string) *ScriptedLLM {
+	if len(script) == 0 {
+		script = defaultScript
+	}
+	return &ScriptedLLM{script: script}
+}
+
+func (l *ScriptedLLM) Stream(ctx context.Context, messages []Message, onDelta func(delta string) error) error {
+	l.mu.Lock()
+	l.calls = append(l.calls, append([]Message(nil), messages...))
+	l.mu.Unlock()
+	for _, delta := range l.script {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := onDelta(delta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Calls 返回每次调用收到的对话
+func (l *ScriptedLLM) Calls() [][]Message {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([][]Message(nil), l.calls...)
+}
@@ -0,0 +1,64 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"llmcloud/config"
+)
+
+// VectorStore 保存片段向量并按相似度检索
+type VectorStore interface {
+	Upsert(ctx context.Context, records []Record) error                                // 写入记录，ID 相同的记录被替换
+	DeleteByFile(ctx context.Context, fileID string) error                             // 删除文件的全部记录
+	Search(ctx context.Context, vector []float32, k int, filter Filter) ([]Hit, error) // 按余弦相似度返回最相近的 k 条记录
+}
+
+// Filter 检索范围：所有者属于 UserIDs，或文件属于 FileIDs 的记录；两者都为空时不限制
+// 按文件夹检索时由调用方将子树展开为 FileIDs
+type Filter struct {
+	UserIDs []uint
+	FileIDs []string
+}
+
+// Hit 一条检索结果，Record 中不含向量，Score 为余弦相似度
+type Hit struct {
+	Record
+	Score float32
+}
+
+// matcher 返回判断记录是否在检索范围内的函数
+func (f Filter) matcher() func(r *Record) bool {
+	if len(f.UserIDs) == 0 && len(f.FileIDs) == 0 {
+		return func(r *Record) bool { return true }
+	}
+	users := make(map[uint]bool, len(f.UserIDs))
+	for _, id := range f.UserIDs {
+		users[id] = true
+	}
+	files := make(map[string]bool, len(f.FileIDs))
+	for _, id := range f.FileIDs {
+		files[id] = true
+	}
+	return func(r *Record) bool { return users[r.UserID] || files[r.FileID] }
+}
+
+// NewVectorStore 根据配置初始化向量库，dim 为向量维度
+func NewVectorStore(cfg config.VectorStoreConfig, dim int) (VectorStore, error) {
+	switch cfg.Type {
+	case "", "memory":
+		return NewMemoryStore(cfg.Memory, dim)
+	case "milvus":
+		return NewMilvusStore(cfg.Milvus, dim)
+	default:
+		return nil, fmt.Errorf("unsupported vector store type: %s", cfg.Type)
+	}
+}
+
+// dot 计算两个向量的内积，向量均已归一化时即为余弦相似度
+func dot(a []float32, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
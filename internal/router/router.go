@@ -7,7 +7,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func SetUpRouters(r *gin.Engine, uc *controller.UserController, fc *controller.FileController) {
+func SetUpRouters(r *gin.Engine, uc *controller.UserController, fc *controller.FileController, upc *controller.UploadController,
+	tc *controller.TrashController, vc *controller.VersionController, sc *controller.ShareController,
+	pc *controller.PermissionController, jc *controller.JobController, qc *controller.QuotaController,
+	cc *controller.ChangeController, src *controller.SearchController, chc *controller.ChatController) {
 	// 用户相关路由
 	api := r.Group("/api/v1")
 	{
@@ -22,13 +25,88 @@ func SetUpRouters(r *gin.Engine, uc *controller.UserController, fc *controller.F
 		{
 			auth.POST("/upload", fc.Upload)
 			auth.GET("/download", fc.Download)
+			auth.GET("/download/archive", fc.DownloadArchive)
 			auth.GET("/page", fc.PageList)
 			auth.DELETE("/delete", fc.Delete)
 			auth.POST("folder", fc.CreateFolder)
 			auth.POST("/move", fc.BatchMove)
+			auth.POST("/copy", fc.BatchCopy)
+			auth.POST("/delete/batch", fc.BatchDelete)
 			auth.PUT("rename", fc.Rename)
+			auth.PUT("/rename/batch", fc.BatchRename)
 			auth.GET("/path", fc.GetPath)
 			auth.GET("/id-path", fc.GetIDPath)
+			auth.GET("/stats", fc.Stats)
+			auth.GET("/semantic-search", src.SemanticSearch)
+			auth.POST("/chat", chc.Chat)
+
+			// 基于路径的访问
+			auth.GET("/by-path/stat", fc.StatByPath)
+			auth.GET("/by-path/list", fc.ListByPath)
+			auth.GET("/by-path/download", fc.DownloadByPath)
+			auth.POST("/by-path/upload", fc.UploadByPath)
+			auth.POST("/by-path/mkdir", fc.MkdirByPath)
+			auth.POST("/by-path/move", fc.MoveByPath)
+			auth.DELETE("/by-path", fc.DeleteByPath)
+
+			// 分片（断点续传）上传
+			auth.POST("/upload/precheck", fc.PreCheck)
+			auth.POST("/upload/extract", fc.UploadExtract)
+			auth.POST("/upload/init", upc.InitUpload)
+			auth.PUT("/upload/chunk", upc.UploadChunk)
+			auth.GET("/upload/status", upc.GetUploadStatus)
+			auth.POST("/upload/complete", upc.CompleteUpload)
+			auth.DELETE("/upload/abort", upc.AbortUpload)
+
+			// 回收站
+			auth.GET("/trash", tc.List)
+			auth.POST("/trash/restore", tc.Restore)
+			auth.DELETE("/trash", tc.Delete)
+			auth.DELETE("/trash/empty", tc.Empty)
+
+			// 文件版本
+			auth.GET("/versions", vc.List)
+			auth.GET("/versions/download", vc.Download)
+			auth.POST("/versions/restore", vc.Restore)
+			auth.DELETE("/versions", vc.Delete)
+			auth.GET("/versions/policy", vc.GetPolicy)
+			auth.PUT("/versions/policy", vc.UpdatePolicy)
+
+			// 分享链接管理
+			auth.POST("/shares", sc.Create)
+			auth.GET("/shares", sc.List)
+			auth.PUT("/shares", sc.Update)
+			auth.DELETE("/shares", sc.Revoke)
+
+			// 协作者授权
+			auth.POST("/permissions", pc.Grant)
+			auth.GET("/permissions", pc.List)
+			auth.DELETE("/permissions", pc.Revoke)
+			auth.GET("/shared-with-me", pc.SharedWithMe)
+
+			// 后台任务
+			auth.GET("/jobs", jc.Get)
+			auth.GET("/jobs/list", jc.List)
+			auth.POST("/jobs/cancel", jc.Cancel)
+
+			// 变更日志（增量同步）
+			auth.GET("/changes/cursor", cc.Cursor)
+			auth.GET("/changes", cc.Delta)
+			auth.GET("/changes/poll", cc.Poll)
+
+			// 空间配额
+			auth.GET("/usage", qc.GetUsage)
+			auth.PUT("/quota", qc.SetQuota)
+		}
+
+		// 公开分享访问，无需登录
+		publicShare := api.Group("/share/:token")
+		{
+			publicShare.GET("", sc.Meta)
+			publicShare.POST("/auth", sc.Auth)
+			publicShare.GET("/list", sc.ListFiles)
+			publicShare.GET("/download", sc.Download)
+			publicShare.POST("/upload", sc.Upload)
 		}
 	}
 }
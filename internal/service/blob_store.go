@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"llmcloud/internal/storage"
+	"log"
+)
+
+// blobStore 负责内容寻址对象的写入、去重与引用计数
+// 文件记录通过 Hash 引用 Blob，相同内容只在存储中保存一份
+type blobStore struct {
+	blobDao       dao.BlobDao
+	storageDriver storage.Driver
+}
+
+func newBlobStore(blobDao dao.BlobDao, driver storage.Driver) *blobStore {
+	return &blobStore{blobDao: blobDao, storageDriver: driver}
+}
+
+// put 流式写入对象并同时计算 SHA-256，内容已存在时丢弃新副本并复用已有对象
+func (bs *blobStore) put(ctx context.Context, userID uint, reader io.Reader, size int64) (*model.Blob, error) {
+	key := GenerateStorageKey(userID, "")
+	hasher := sha256.New()
+	if err := bs.storageDriver.Upload(ctx, key, io.TeeReader(reader, hasher), size); err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+	return bs.commit(ctx, hex.EncodeToString(hasher.Sum(nil)), size, key)
+}
+
+// commit 登记一个已写入存储的对象，返回实际生效的 Blob
+func (bs *blobStore) commit(ctx context.Context, hash string, size int64, key string) (*model.Blob, error) {
+	blob, err := bs.blobDao.AcquireOrCreate(&model.Blob{
+		Hash:        hash,
+		Size:        size,
+		StorageType: config.AppConfigInstance.Storage.Type,
+		StorageKey:  key,
+	})
+	if err != nil {
+		_ = bs.storageDriver.Delete(ctx, key)
+		return nil, fmt.Errorf("登记存储对象失败: %w", err)
+	}
+	if blob.StorageKey != key {
+		// 内容重复，删除刚写入的副本
+		if err := bs.storageDriver.Delete(ctx, key); err != nil {
+			log.Printf("删除重复对象失败(%s): %v", key, err)
+		}
+	}
+	return blob, nil
+}
+
+// acquire 为已存在的对象增加一个引用，对象不存在时返回 nil
+func (bs *blobStore) acquire(hash string, size int64) (*model.Blob, error) {
+	return bs.blobDao.AcquireExisting(hash, size)
+}
+
+// duplicate 为 src 的副本获取内容对象：已登记的内容只增加引用计数，
+// 未登记的历史文件先在存储端复制一份再登记，均不经过应用服务器传输数据
+func (bs *blobStore) duplicate(ctx context.Context, userID uint, src *model.File) (*model.Blob, error) {
+	if src.Hash != "" {
+		blob, err := bs.acquire(src.Hash, src.Size)
+		if err != nil {
+			return nil, fmt.Errorf("查询存储对象失败: %w", err)
+		}
+		if blob != nil {
+			return blob, nil
+		}
+	}
+	key := GenerateStorageKey(userID, "")
+	if err := bs.storageDriver.Copy(ctx, src.StorageKey, key); err != nil {
+		return nil, fmt.Errorf("复制存储对象失败: %w", err)
+	}
+	hash := src.Hash
+	if hash == "" {
+		var err error
+		if hash, err = bs.hashObject(ctx, key); err != nil {
+			_ = bs.storageDriver.Delete(ctx, key)
+			return nil, err
+		}
+	}
+	return bs.commit(ctx, hash, src.Size, key)
+}
+
+// release 释放对内容对象的一个引用，最后一个引用释放时删除存储对象
+// hash 为空表示未登记哈希的历史文件，其独占 storageKey 对应的对象
+func (bs *blobStore) release(ctx context.Context, hash string, storageKey string) error {
+	if hash == "" {
+		return bs.storageDriver.Delete(ctx, storageKey)
+	}
+	blob, err := bs.blobDao.Release(hash)
+	if errors.Is(err, dao.ErrBlobNotFound) {
+		return bs.storageDriver.Delete(ctx, storageKey)
+	}
+	if err != nil {
+		return fmt.Errorf("释放存储对象失败: %w", err)
+	}
+	if blob != nil {
+		return bs.storageDriver.Delete(ctx, blob.StorageKey)
+	}
+	return nil
+}
+
+// hashObject 读取存储中的对象并计算 SHA-256
+func (bs *blobStore) hashObject(ctx context.Context, key string) (string, error) {
+	reader, err := bs.storageDriver.Download(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("读取对象失败: %w", err)
+	}
+	defer reader.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, reader); err != nil {
+		return "", fmt.Errorf("读取对象失败: %w", err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"time"
+)
+
+const (
+	defaultChangeLimit        = 500
+	maxChangeLimit            = 1000
+	defaultChangePollInterval = time.Second
+	defaultChangeMaxWait      = time.Minute
+)
+
+var ErrInvalidCursor = errors.New("游标参数错误")
+
+// ChangeService 变更日志查询，供同步客户端增量获取文件树的变化
+type ChangeService interface {
+	LatestCursor(userID uint) (*model.CursorResp, error)
+	Delta(userID uint, cursor int64, limit int) (*model.DeltaResp, error)
+	Poll(ctx context.Context, userID uint, cursor int64, limit int, wait time.Duration) (*model.DeltaResp, error)
+}
+
+type changeService struct {
+	changeDao    dao.ChangeDao
+	pollInterval time.Duration
+	maxWait      time.Duration
+}
+
+// LatestCursor 返回用户当前最新的游标
+func (cs *changeService) LatestCursor(userID uint) (*model.CursorResp, error) {
+	seq, err := cs.changeDao.LatestSeq(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取游标失败: %w", err)
+	}
+	return &model.CursorResp{Cursor: seq}, nil
+}
+
+// Delta 返回游标 cursor 之后的变更，最多 limit 条
+func (cs *changeService) Delta(userID uint, cursor int64, limit int) (*model.DeltaResp, error) {
+	if cursor < 0 {
+		return nil, ErrInvalidCursor
+	}
+	if limit <= 0 {
+		limit = defaultChangeLimit
+	}
+	if limit > maxChangeLimit {
+		limit = maxChangeLimit
+	}
+	// 多取一条用于判断是否还有后续变更
+	changes, err := cs.changeDao.ListChanges(userID, cursor, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("获取变更失败: %w", err)
+	}
+	resp := &model.DeltaResp{Changes: changes, Cursor: cursor}
+	if len(changes) > limit {
+		resp.Changes, resp.HasMore = changes[:limit], true
+	}
+	if len(resp.Changes) > 0 {
+		resp.Cursor = resp.Changes[len(resp.Changes)-1].Seq
+	}
+	return resp, nil
+}
+
+// Poll 与 Delta 相同，但在没有新变更时最多等待 wait（不超过配置的上限），期间有变更立即返回
+// 通过定期查询数据库发现变更，多实例部署时同样有效
+func (cs *changeService) Poll(ctx context.Context, userID uint, cursor int64, limit int, wait time.Duration) (*model.DeltaResp, error) {
+	if wait <= 0 || wait > cs.maxWait {
+		wait = cs.maxWait
+	}
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	ticker := time.NewTicker(cs.pollInterval)
+	defer ticker.Stop()
+	for {
+		resp, err := cs.Delta(userID, cursor, limit)
+		if err != nil || len(resp.Changes) > 0 {
+			return resp, err
+		}
+		select {
+		case <-ctx.Done():
+			return resp, nil
+		case <-deadline.C:
+			return resp, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// changeOf 以条目当前的状态生成一条变更记录，记入文件所有者的日志
+func changeOf(changeType string, file *model.File) *model.Change {
+	return &model.Change{
+		UserID:   file.UserID,
+		Type:     changeType,
+		FileID:   file.ID,
+		ParentID: file.ParentID,
+		Name:     file.Name,
+		IsDir:    file.IsDir,
+		Size:     file.Size,
+		Hash:     file.Hash,
+		Version:  file.Version,
+	}
+}
+
+// writeChange 在同一事务中执行 write 并记录 file 的变更，任一失败时整体回滚，
+// 保证同步客户端看到的变更日志与文件树一致
+func writeChange(t dao.Transactor, changeType string, file *model.File, write func(tx *dao.Tx) error) error {
+	return t.Transaction(func(tx *dao.Tx) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		return tx.Changes.Record(changeOf(changeType, file))
+	})
+}
+
+func NewChangeService(changeDao dao.ChangeDao) ChangeService {
+	cfg := config.AppConfigInstance.Change
+	return &changeService{
+		changeDao:    changeDao,
+		pollInterval: parseDuration(cfg.PollInterval, defaultChangePollInterval),
+		maxWait:      parseDuration(cfg.MaxWait, defaultChangeMaxWait),
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"llmcloud/config"
+	"llmcloud/internal/model"
+	"llmcloud/internal/rag"
+	"log"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	defaultChatTopK    = 8
+	defaultChatContext = 6000
+	maxChatHistory     = 20 // 提供给模型的历史消息条数上限
+)
+
+const chatSystemPrompt = `你是文档问答助手，只能依据用户提供的资料回答问题。
+资料按 [编号] 列出，回答中引用资料时在相应句子后标注编号，如 [1]、[2]。
+资料中没有答案时，直接说明无法从现有文件中找到答案，不要编造。
+使用与问题相同的语言回答。`
+
+// chatNoSourceAnswer 没有检索到资料时的回答，不再调用模型
+const chatNoSourceAnswer = "没有在可访问的文件中找到与问题相关的内容。"
+
+// ChatService 基于文档的问答：检索调用方文件中的相关片段，构造提示词后由语言模型生成回答
+type ChatService interface {
+	// Chat 回答问题：回答的文本依次交给 onDelta，结束后返回回答所依据的资料；
+	// 检索阶段的错误在第一次调用 onDelta 之前返回
+	Chat(ctx context.Context, userID uint, req *model.ChatReq, onDelta func(delta string) error) ([]model.Citation, error)
+}
+
+// chatSource 提供给模型的一条资料
+type chatSource struct {
+	ref   int
+	file  *model.File
+	path  string
+	chunk model.ChunkMatch
+}
+
+type chatService struct {
+	search     SearchService
+	files      FileService
+	llm        rag.LLM
+	topK       int
+	contextLen int
+}
+
+func (cs *chatService) Chat(ctx context.Context, userID uint, req *model.ChatReq, onDelta func(delta string) error) ([]model.Citation, error) {
+	topK := req.TopK
+	if topK <= 0 {
+		topK = cs.topK
+	}
+	results, err := cs.search.SemanticSearch(ctx, userID, req.Question, topK, req.FolderID)
+	if err != nil {
+		return nil, err
+	}
+	sources := cs.sources(userID, results)
+	if len(sources) == 0 {
+		return []model.Citation{}, onDelta(chatNoSourceAnswer)
+	}
+	if err := cs.llm.Stream(ctx, cs.prompt(req, sources), onDelta); err != nil {
+		return nil, fmt.Errorf("生成回答失败: %w", err)
+	}
+	return citationsOf(sources), nil
+}
+
+// sources 按相似度从高到低选取资料，总长度不超过配置的上限，并为其编号
+func (cs *chatService) sources(userID uint, results []model.SemanticSearchResult) []chatSource {
+	var all []chatSource
+	for i := range results {
+		file := &results[i].File
+		path, err := cs.files.GetFilePath(userID, file.ID)
+		if err != nil {
+			// 检索后文件被删除或取消授权
+			log.Printf("获取文件路径失败(%s): %v", file.ID, err)
+			continue
+		}
+		for _, c := range results[i].Chunks {
+			all = append(all, chatSource{file: file, path: path, chunk: c})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].chunk.Score > all[j].chunk.Score })
+
+	budget := cs.contextLen
+	sources := make([]chatSource, 0, len(all))
+	for _, s := range all {
+		n := utf8.RuneCountInString(s.chunk.Snippet)
+		// 至少保留一条资料
+		if n > budget && len(sources) > 0 {
+			break
+		}
+		budget -= n
+		s.ref = len(sources) + 1
+		sources = append(sources, s)
+	}
+	return sources
+}
+
+// prompt 构造对话：系统提示词、历史消息，以及附带资料的问题
+func (cs *chatService) prompt(req *model.ChatReq, sources []chatSource) []rag.Message {
+	messages := []rag.Message{{Role: rag.RoleSystem, Content: chatSystemPrompt}}
+	history := req.History
+	if len(history) > maxChatHistory {
+		history = history[len(history)-maxChatHistory:]
+	}
+	for _, m := range history {
+		messages = append(messages, rag.Message{Role: m.Role, Content: m.Content})
+	}
+
+	var b strings.Builder
+	b.WriteString("资料：\n")
+	for _, s := range sources {
+		fmt.Fprintf(&b, "[%d] 文件 %s（第 %d 段，字符 %d-%d）\n%s\n\n", s.ref, s.path, s.chunk.Index, s.chunk.Start, s.chunk.End, s.chunk.Snippet)
+	}
+	b.WriteString("问题：")
+	b.WriteString(req.Question)
+	return append(messages, rag.Message{Role: rag.RoleUser, Content: b.String()})
+}
+
+// citationsOf 按文件汇总资料，文件按其第一条资料的编号排列
+func citationsOf(sources []chatSource) []model.Citation {
+	citations := make([]model.Citation, 0)
+	byFile := make(map[string]int)
+	for _, s := range sources {
+		i, ok := byFile[s.file.ID]
+		if !ok {
+			i = len(citations)
+			byFile[s.file.ID] = i
+			citations = append(citations, model.Citation{FileID: s.file.ID, Path: s.path})
+		}
+		citations[i].Chunks = append(citations[i].Chunks, model.ChunkRange{
+			Ref:   s.ref,
+			Index: s.chunk.Index,
+			Start: s.chunk.Start,
+			End:   s.chunk.End,
+			Score: s.chunk.Score,
+		})
+	}
+	return citations
+}
+
+func NewChatService(search SearchService, files FileService, llm rag.LLM) ChatService {
+	cfg := config.AppConfigInstance.RAG
+	topK := cfg.ChatTopK
+	if topK <= 0 {
+		topK = defaultChatTopK
+	}
+	contextLen := cfg.ChatContext
+	if contextLen <= 0 {
+		contextLen = defaultChatContext
+	}
+	return &chatService{
+		search:     search,
+		files:      files,
+		llm:        llm,
+		topK:       topK,
+		contextLen: contextLen,
+	}
+}
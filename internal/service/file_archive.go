@@ -0,0 +1,175 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"llmcloud/internal/model"
+	"path"
+	"strings"
+	"time"
+)
+
+const defaultArchiveMaxSize = 10 << 30
+
+var (
+	ErrArchiveTooLarge = errors.New("打包内容超过大小上限")
+	ErrArchiveName     = errors.New("文件名无法安全地写入归档")
+)
+
+// PrepareArchive 校验权限并展开所选文件与文件夹，生成打包计划
+// 所选条目位于归档根目录，文件夹内容保留其相对路径；超过大小上限时返回 ErrArchiveTooLarge
+func (fs *fileService) PrepareArchive(userID uint, fileIDs []string, format string) (*model.Archive, error) {
+	if len(fileIDs) == 0 {
+		return nil, errors.New("请选择要下载的文件")
+	}
+	if format == "" {
+		format = model.ArchiveFormatZip
+	}
+	archive := &model.Archive{Format: format}
+	rootNames := make(map[string]bool, len(fileIDs))
+	for _, fileID := range fileIDs {
+		file, err := fs.permissions.Authorize(userID, fileID, model.RoleViewer)
+		if err != nil {
+			return nil, err
+		}
+		subtree, err := fs.collectSubtree(file)
+		if err != nil {
+			return nil, err
+		}
+		// 不同目录下的同名条目放在归档根目录时自动重命名
+		rootName := uniqueName(file.Name, rootNames)
+		rootNames[rootName] = true
+		rootPath, err := archiveEntryPath("", rootName)
+		if err != nil {
+			return nil, err
+		}
+		paths := map[string]string{file.ID: rootPath}
+		for i := range subtree {
+			f := &subtree[i]
+			if i > 0 {
+				if paths[f.ID], err = archiveEntryPath(paths[*f.ParentID], f.Name); err != nil {
+					return nil, err
+				}
+			}
+			entryPath := paths[f.ID]
+			if f.IsDir {
+				entryPath += "/"
+			}
+			archive.Size += f.Size
+			if archive.Size > fs.archiveMaxSize {
+				return nil, ErrArchiveTooLarge
+			}
+			archive.Entries = append(archive.Entries, model.ArchiveEntry{Path: entryPath, File: *f})
+		}
+		if len(fileIDs) == 1 {
+			archive.Name = rootName
+		}
+	}
+	if archive.Name == "" {
+		archive.Name = "download-" + time.Now().Format("20060102150405")
+	}
+	archive.Name += "." + format
+	return archive, nil
+}
+
+// archiveEntryPath 返回名为 name 的条目在 parent 目录下的归档路径
+// 名称必须恰好构成一级路径：".."、含路径分隔符等名称在解压时会逃逸出归档根目录，直接拒绝
+func archiveEntryPath(parent string, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return "", fmt.Errorf("%w: %s", ErrArchiveName, name)
+	}
+	entry := path.Join(parent, name)
+	cleaned, err := sanitizeEntryPath(entry)
+	if err != nil || cleaned != entry {
+		return "", fmt.Errorf("%w: %s", ErrArchiveName, name)
+	}
+	return cleaned, nil
+}
+
+// WriteArchive 按计划逐个读取存储对象并以流的方式写入归档，不在内存或磁盘中缓存归档
+func (fs *fileService) WriteArchive(ctx context.Context, archive *model.Archive, w io.Writer) error {
+	switch archive.Format {
+	case model.ArchiveFormatZip:
+		return fs.writeZip(ctx, archive, w)
+	case model.ArchiveFormatTarGz:
+		return fs.writeTarGz(ctx, archive, w)
+	default:
+		return fmt.Errorf("不支持的归档格式: %s", archive.Format)
+	}
+}
+
+func (fs *fileService) writeZip(ctx context.Context, archive *model.Archive, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	for i := range archive.Entries {
+		entry := &archive.Entries[i]
+		header := &zip.FileHeader{
+			Name:     entry.Path,
+			Method:   zip.Deflate,
+			Modified: entry.File.UpdatedAt,
+		}
+		if entry.File.IsDir {
+			header.Method = zip.Store
+		}
+		writer, err := zw.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("写入归档失败: %w", err)
+		}
+		if entry.File.IsDir {
+			continue
+		}
+		if err := fs.copyObject(ctx, writer, &entry.File); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (fs *fileService) writeTarGz(ctx context.Context, archive *model.Archive, w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	for i := range archive.Entries {
+		entry := &archive.Entries[i]
+		header := &tar.Header{
+			Name:    entry.Path,
+			ModTime: entry.File.UpdatedAt,
+			Mode:    0644,
+			Size:    entry.File.Size,
+		}
+		if entry.File.IsDir {
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0755
+			header.Size = 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("写入归档失败: %w", err)
+		}
+		if entry.File.IsDir {
+			continue
+		}
+		if err := fs.copyObject(ctx, tw, &entry.File); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("写入归档失败: %w", err)
+	}
+	return gw.Close()
+}
+
+// copyObject 将文件内容从存储驱动复制到 w
+func (fs *fileService) copyObject(ctx context.Context, w io.Writer, file *model.File) error {
+	reader, err := fs.storageDriver.Download(ctx, file.StorageKey)
+	if err != nil {
+		return fmt.Errorf("读取文件失败(%s): %w", file.ID, err)
+	}
+	defer reader.Close()
+	if _, err := io.Copy(w, reader); err != nil {
+		return fmt.Errorf("写入归档失败(%s): %w", file.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"strings"
+)
+
+const defaultDeleteAsyncThreshold = 1000
+
+var errDuplicateItem = errors.New("条目重复")
+
+// batchStep 批量操作中一个条目的写入计划
+// 校验在生成计划时完成，apply 只通过事务内的 DAO 写库；
+// commit/rollback 在事务结束后处理数据库之外的副作用（记账、释放存储对象等），可为 nil；
+// skip 为 true 时条目按冲突策略跳过，不执行 apply
+type batchStep struct {
+	prepare  func() error // 可选，在事务开始前执行耗时的准备工作（如存储端复制），失败时同样调用 rollback
+	apply    func(tx *dao.Tx) error
+	commit   func()
+	rollback func()
+	skip     bool
+}
+
+// batch 一次批量操作：steps 与 results 一一对应，校验失败的条目 step 为 nil
+type batch struct {
+	mode    string
+	steps   []*batchStep
+	results []model.BatchItemResult
+}
+
+// prepareBatch 逐个校验条目并生成写入计划，此阶段不修改任何数据
+func prepareBatch(mode string, fileIDs []string, prepare func(fileID string) (*batchStep, error)) *batch {
+	if mode == "" {
+		mode = model.BatchModeAtomic
+	}
+	b := &batch{mode: mode, steps: make([]*batchStep, len(fileIDs)), results: make([]model.BatchItemResult, len(fileIDs))}
+	seen := make(map[string]bool, len(fileIDs))
+	for i, fileID := range fileIDs {
+		b.results[i].FileID = fileID
+		var err error
+		if seen[fileID] {
+			err = errDuplicateItem
+		} else {
+			seen[fileID] = true
+			b.steps[i], err = prepare(fileID)
+		}
+		if err != nil {
+			b.fail(i, err)
+			if mode == model.BatchModeAtomic {
+				b.skipRest()
+				return b
+			}
+		}
+	}
+	return b
+}
+
+// execute 执行写入计划：全部成功模式下所有条目在同一个事务中执行，任一失败则全部回滚并返回该错误；
+// 逐项模式下每个条目各自使用一个事务，失败只记录在结果中
+func (b *batch) execute(t dao.Transactor) error {
+	if b.mode == model.BatchModeAtomic {
+		if err := b.firstError(); err != nil {
+			return err
+		}
+		failed := -1
+		var err error
+		for i, step := range b.steps {
+			if step.skip || step.prepare == nil {
+				continue
+			}
+			if err = step.prepare(); err != nil {
+				failed = i
+				break
+			}
+		}
+		if err == nil {
+			err = t.Transaction(func(tx *dao.Tx) error {
+				for i, step := range b.steps {
+					if step.skip {
+						continue
+					}
+					if err := step.apply(tx); err != nil {
+						failed = i
+						return err
+					}
+				}
+				return nil
+			})
+		}
+		if err != nil {
+			for i, step := range b.steps {
+				if step.rollback != nil {
+					step.rollback()
+				}
+				if i == failed {
+					b.fail(i, err)
+				} else {
+					b.results[i].Status = model.BatchItemSkipped
+				}
+			}
+			return err
+		}
+		for i, step := range b.steps {
+			b.succeed(i, step)
+		}
+		return nil
+	}
+
+	for i, step := range b.steps {
+		if step == nil {
+			continue
+		}
+		if step.skip {
+			b.succeed(i, step)
+			continue
+		}
+		var err error
+		if step.prepare != nil {
+			err = step.prepare()
+		}
+		if err == nil {
+			err = t.Transaction(step.apply)
+		}
+		if err != nil {
+			if step.rollback != nil {
+				step.rollback()
+			}
+			b.fail(i, err)
+			continue
+		}
+		b.succeed(i, step)
+	}
+	return nil
+}
+
+func (b *batch) succeed(i int, step *batchStep) {
+	if step.skip {
+		b.results[i].Status = model.BatchItemSkipped
+		return
+	}
+	if step.commit != nil {
+		step.commit()
+	}
+	b.results[i].Status = model.BatchItemSucceeded
+}
+
+func (b *batch) fail(i int, err error) {
+	b.results[i].Status = model.BatchItemFailed
+	b.results[i].Err = err
+}
+
+// skipRest 全部成功模式下校验失败时，其余条目均不执行
+func (b *batch) skipRest() {
+	for i := range b.results {
+		if b.results[i].Status == "" {
+			b.results[i].Status = model.BatchItemSkipped
+		}
+	}
+}
+
+func (b *batch) firstError() error {
+	for _, r := range b.results {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
+
+// failures 汇总失败条目的原因，没有失败时返回 nil
+func (b *batch) failures() error {
+	var msgs []string
+	for _, r := range b.results {
+		if r.Err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", r.FileID, r.Err))
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+func (b *batch) resp() *model.BatchResp {
+	resp := &model.BatchResp{Mode: b.mode, Items: b.results}
+	for _, r := range b.results {
+		switch r.Status {
+		case model.BatchItemSucceeded:
+			resp.Succeeded++
+		case model.BatchItemFailed:
+			resp.Failed++
+		case model.BatchItemSkipped:
+			resp.Skipped++
+		}
+	}
+	return resp
+}
+
+// BatchMoveFiles 批量移动，文件只能在同一所有者的文件树内移动；同名时默认自动重命名
+func (fs *fileService) BatchMoveFiles(userID uint, fileIDs []string, targetParentID string, mode string, onConflict string) (*model.BatchResp, error) {
+	items := make([]moveItem, len(fileIDs))
+	for i, fileID := range fileIDs {
+		items[i].fileID = fileID
+	}
+	return fs.moveFiles(userID, items, targetParentID, mode, conflictPolicy(onConflict, model.ConflictRename))
+}
+
+// moveItem 待移动的条目，name 为空时保留原名称
+type moveItem struct {
+	fileID string
+	name   string
+}
+
+// moveFiles 将 items 移动到目标文件夹，同名条目按 policy 处理
+func (fs *fileService) moveFiles(userID uint, items []moveItem, targetParentID string, mode string, policy string) (*model.BatchResp, error) {
+	var targetParentIDPtr *string
+	if targetParentID != "" {
+		targetParentIDPtr = &targetParentID
+	}
+	// 验证目标文件夹是否存在且可编辑
+	targetFolder, err := fs.permissions.AuthorizeFolder(userID, targetParentIDPtr, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	ownerID := folderOwner(userID, targetFolder)
+	// 获取目标文件夹下的所有文件，用于检查同名文件
+	index, err := fs.newNameIndex(ownerID, targetParentIDPtr)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]string, len(items))
+	fileIDs := make([]string, len(items))
+	for i, item := range items {
+		names[item.fileID] = item.name
+		fileIDs[i] = item.fileID
+	}
+	b := prepareBatch(mode, fileIDs, func(fileID string) (*batchStep, error) {
+		// 权限检查
+		file, err := fs.permissions.Authorize(userID, fileID, model.RoleEditor)
+		if err != nil {
+			return nil, err
+		}
+		if file.UserID != ownerID {
+			return nil, errors.New("不能在不同用户的文件之间移动")
+		}
+		// 检查是否将文件夹移动到其子文件夹中
+		if file.IsDir && targetFolder != nil {
+			if err := checkCircularReference(file, targetFolder); err != nil {
+				return nil, err
+			}
+		}
+		name := names[fileID]
+		if name == "" {
+			name = file.Name
+		}
+		// 已在目标位置
+		if sameParent(file.ParentID, targetParentIDPtr) && name == file.Name {
+			return &batchStep{apply: func(tx *dao.Tx) error { return nil }}, nil
+		}
+		// 处理同名文件
+		name, existing, skip, err := index.resolve(file, name, policy)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			return &batchStep{skip: true}, nil
+		}
+		return &batchStep{apply: func(tx *dao.Tx) error {
+			return place(tx, file, targetParentIDPtr, name, existing)
+		}}, nil
+	})
+	err = b.execute(fs.tx)
+	return b.resp(), err
+}
+
+// deletePayload 后台删除任务的参数
+type deletePayload struct {
+	FileIDs []string `json:"file_ids"`
+	Mode    string   `json:"mode"`
+}
+
+// BatchDeleteFiles 批量将文件或文件夹（连同其子树）移入回收站，存储对象在彻底删除时才会释放
+// 协作者删除的内容进入文件所有者的回收站；涉及的条目数超过阈值时创建后台任务，结果中只包含任务信息
+func (fs *fileService) BatchDeleteFiles(userID uint, fileIDs []string, mode string) (*model.BatchResp, error) {
+	b, total := fs.prepareDelete(userID, fileIDs, mode)
+	if b.mode == model.BatchModeAtomic {
+		if err := b.firstError(); err != nil {
+			return b.resp(), err
+		}
+	}
+	if total <= int64(fs.deleteThreshold) {
+		err := b.execute(fs.tx)
+		return b.resp(), err
+	}
+	job, err := fs.jobs.Enqueue(userID, model.JobTypeDelete, deletePayload{FileIDs: fileIDs, Mode: b.mode}, total)
+	if err != nil {
+		return nil, err
+	}
+	return &model.BatchResp{Mode: b.mode, Job: buildJobResp(job)}, nil
+}
+
+// prepareDelete 校验条目并生成删除计划，返回涉及的条目总数（含子树）
+func (fs *fileService) prepareDelete(userID uint, fileIDs []string, mode string) (*batch, int64) {
+	requested := make(map[string]bool, len(fileIDs))
+	for _, id := range fileIDs {
+		requested[id] = true
+	}
+	var total int64
+	b := prepareBatch(mode, fileIDs, func(fileID string) (*batchStep, error) {
+		file, err := fs.permissions.Authorize(userID, fileID, model.RoleEditor)
+		if err != nil {
+			return nil, err
+		}
+		// 祖先文件夹也在本次删除之列时，条目随祖先一起进入回收站
+		for _, ancestorID := range model.AncestorIDs(file.Path) {
+			if requested[ancestorID] {
+				return &batchStep{apply: func(tx *dao.Tx) error { return nil }}, nil
+			}
+		}
+		s := statsOf(file)
+		total += s.FileCount + s.FolderCount
+		return &batchStep{apply: func(tx *dao.Tx) error {
+			return trashFile(tx, file)
+		}}, nil
+	})
+	return b, total
+}
+
+// runDeleteJob 执行后台删除任务；逐项模式下部分条目已移入回收站，失败时不再重试
+func (fs *fileService) runDeleteJob(ctx context.Context, job *model.Job, progress *JobProgress) error {
+	var payload deletePayload
+	if err := decodePayload(job, &payload); err != nil {
+		return err
+	}
+	b, total := fs.prepareDelete(job.UserID, payload.FileIDs, payload.Mode)
+	if b.mode == model.BatchModeAtomic {
+		if err := b.firstError(); err != nil {
+			return permanent(err)
+		}
+	}
+	progress.SetTotal(total)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := b.execute(fs.tx); err != nil {
+		return err
+	}
+	progress.Advance(total)
+	return permanent(b.failures())
+}
+
+// DeleteFileOrFolder 将单个文件或文件夹移入回收站，子树较大时返回后台任务，否则返回 nil
+func (fs *fileService) DeleteFileOrFolder(ctx context.Context, userID uint, fileID string) (*model.JobResp, error) {
+	resp, err := fs.BatchDeleteFiles(userID, []string{fileID}, model.BatchModeAtomic)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Job, nil
+}
+
+// Rename 重命名单个条目，同名时默认报错
+func (fs *fileService) Rename(userID uint, fileID string, newName string, onConflict string) error {
+	_, err := fs.BatchRename(userID, []model.RenameItem{{FileID: fileID, NewName: newName}}, model.BatchModeAtomic, onConflict)
+	return err
+}
+
+// BatchRename 批量重命名，同名条目按 onConflict 处理，默认报错
+func (fs *fileService) BatchRename(userID uint, items []model.RenameItem, mode string, onConflict string) (*model.BatchResp, error) {
+	policy := conflictPolicy(onConflict, model.ConflictFail)
+	newNames := make(map[string]string, len(items))
+	fileIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		newNames[item.FileID] = item.NewName
+		fileIDs = append(fileIDs, item.FileID)
+	}
+	// 所有者/目录 -> 目录中的名称，本批次占用的名称一并记录，避免两个条目改成同一个名称
+	// 批次中可能有不同所有者的条目，各自的根目录需要分开索引
+	indexes := make(map[string]*nameIndex)
+	b := prepareBatch(mode, fileIDs, func(fileID string) (*batchStep, error) {
+		// 根据id获取file信息并校验编辑权限
+		file, err := fs.permissions.Authorize(userID, fileID, model.RoleEditor)
+		if err != nil {
+			return nil, err
+		}
+		newName := newNames[fileID]
+		if err := validateName(newName); err != nil {
+			return nil, err
+		}
+		if newName == file.Name {
+			return &batchStep{apply: func(tx *dao.Tx) error { return nil }}, nil
+		}
+		dir := ""
+		if file.ParentID != nil {
+			dir = *file.ParentID
+		}
+		key := fmt.Sprintf("%d/%s", file.UserID, dir)
+		if indexes[key] == nil {
+			if indexes[key], err = fs.newNameIndex(file.UserID, file.ParentID); err != nil {
+				return nil, err
+			}
+		}
+		name, existing, skip, err := indexes[key].resolve(file, newName, policy)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			return &batchStep{skip: true}, nil
+		}
+		return &batchStep{apply: func(tx *dao.Tx) error {
+			return place(tx, file, file.ParentID, name, existing)
+		}}, nil
+	})
+	err := b.execute(fs.tx)
+	return b.resp(), err
+}
+
+// sameParent 判断两个父目录ID是否指向同一目录，nil 表示根目录
+func sameParent(a *string, b *string) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
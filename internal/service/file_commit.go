@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"log"
+	"mime"
+	"path/filepath"
+	"time"
+)
+
+// maxCommitAttempts 登记文件时因并发占用同名而重试的次数上限
+const maxCommitAttempts = 5
+
+// fileCommitter 将已写入存储的内容登记到文件树中，普通上传、分片上传与秒传共用这一逻辑
+type fileCommitter struct {
+	fileDao    dao.FileDao
+	versionDao dao.VersionDao
+	tx         dao.Transactor
+	ingest     IngestService
+	blobs      *blobStore
+	quota      QuotaService
+}
+
+func newFileCommitter(fileDao dao.FileDao, versionDao dao.VersionDao, tx dao.Transactor, ingest IngestService, blobs *blobStore, quota QuotaService) *fileCommitter {
+	return &fileCommitter{fileDao: fileDao, versionDao: versionDao, tx: tx, ingest: ingest, blobs: blobs, quota: quota}
+}
+
+// commit 在 ownerID 的 parentID 下以 name 登记 blob 对应的内容，uploaderID 为实际上传者
+// 目录中已有同名条目时按 onConflict 处理，为空时开启版本管理则 overwrite（生成新版本），否则 rename
+// blob 的引用由调用方获取，失败或跳过时由 commit 负责释放
+func (fc *fileCommitter) commit(ctx context.Context, ownerID uint, uploaderID uint, name string, parentID *string, blob *model.Blob, onConflict string) (*model.File, error) {
+	if err := validateName(name); err != nil {
+		_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+		return nil, err
+	}
+	policy, err := fc.policy(ownerID)
+	if err != nil {
+		_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+		return nil, err
+	}
+	if onConflict == "" {
+		onConflict = model.ConflictRename
+		if policy.Enabled {
+			onConflict = model.ConflictOverwrite
+		}
+	}
+	existing, err := fc.fileDao.GetFileByName(ownerID, parentID, name)
+	if err != nil {
+		_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+		return nil, fmt.Errorf("查询同名文件失败: %w", err)
+	}
+	if existing != nil {
+		switch onConflict {
+		case model.ConflictSkip:
+			_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+			return existing, nil
+		case model.ConflictFail:
+			_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+			return nil, ErrNameExists
+		case model.ConflictOverwrite:
+			if existing.IsDir {
+				_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+				return nil, ErrConflictType
+			}
+			if policy.Enabled {
+				return fc.addVersion(ctx, uploaderID, existing, blob, policy)
+			}
+			return fc.replace(ctx, uploaderID, existing, blob)
+		default:
+			if name, err = fc.freeName(ownerID, parentID, name); err != nil {
+				_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+				return nil, err
+			}
+		}
+	}
+
+	newFile := newFileFromBlob(ownerID, uploaderID, name, parentID, blob)
+	renaming := onConflict != model.ConflictSkip && onConflict != model.ConflictFail && onConflict != model.ConflictOverwrite
+	for attempt := 1; ; attempt++ {
+		err := writeChange(fc.tx, model.ChangeCreate, newFile, func(tx *dao.Tx) error {
+			if err := tx.Files.CreateFile(newFile); err != nil {
+				return err
+			}
+			return newFolderStats(tx.Files).apply(parentID, statsOf(newFile))
+		})
+		if err == nil {
+			break
+		}
+		// 生成名称与写入不在同一事务中，并发上传可能先占用了该名称（由唯一索引拒绝），重新生成名称再试
+		if errors.Is(err, ErrNameExists) && renaming && attempt < maxCommitAttempts {
+			if newFile.Name, err = fc.freeName(ownerID, parentID, name); err == nil {
+				continue
+			}
+		}
+		_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+		return nil, fmt.Errorf("failed to create file metadata: %w", err)
+	}
+	fc.quota.RecordUsage(ownerID, newFile.MIMEType, newFile.Size, 1)
+	fc.ingest.Schedule(newFile)
+	return newFile, nil
+}
+
+// freeName 返回 parentID 下尚未被占用的名称，name 已被占用时生成 "name (1).ext" 形式的新名称
+func (fc *fileCommitter) freeName(ownerID uint, parentID *string, name string) (string, error) {
+	siblings, err := fc.fileDao.GetFilesByParentID(ownerID, parentID)
+	if err != nil {
+		return "", fmt.Errorf("获取文件列表失败: %w", err)
+	}
+	names := make(map[string]bool, len(siblings))
+	for _, f := range siblings {
+		names[f.Name] = true
+	}
+	return uniqueName(name, names), nil
+}
+
+// addVersion 将文件当前内容存为历史版本，并以 blob 作为新的当前版本
+func (fc *fileCommitter) addVersion(ctx context.Context, uploaderID uint, file *model.File, blob *model.Blob, policy *model.VersionPolicy) (*model.File, error) {
+	snapshot := snapshotVersion(file)
+	setContent(file, uploaderID, blob)
+	if err := writeChange(fc.tx, model.ChangeUpdate, file, func(tx *dao.Tx) error {
+		if err := tx.Versions.ArchiveAndUpdate(snapshot, file, ""); err != nil {
+			return err
+		}
+		return newFolderStats(tx.Files).apply(file.ParentID, model.FolderStats{Size: file.Size - snapshot.Size})
+	}); err != nil {
+		_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+		return nil, fmt.Errorf("保存文件版本失败: %w", err)
+	}
+	// 原内容转为历史版本后仍占用空间，新增的是新版本的大小
+	fc.quota.RecordUsage(file.UserID, file.MIMEType, file.Size, 0)
+	fc.ingest.Schedule(file)
+	fc.prune(ctx, file.UserID, file.ID, policy.MaxVersions)
+	return file, nil
+}
+
+// replace 以 blob 替换文件的当前内容，原内容不保留；文件ID不变，分享与授权继续有效
+func (fc *fileCommitter) replace(ctx context.Context, uploaderID uint, file *model.File, blob *model.Blob) (*model.File, error) {
+	old := *file
+	setContent(file, uploaderID, blob)
+	if err := writeChange(fc.tx, model.ChangeUpdate, file, func(tx *dao.Tx) error {
+		if err := tx.Files.UpdateFile(file); err != nil {
+			return err
+		}
+		return newFolderStats(tx.Files).apply(file.ParentID, model.FolderStats{Size: file.Size - old.Size})
+	}); err != nil {
+		_ = fc.blobs.release(ctx, blob.Hash, blob.StorageKey)
+		return nil, fmt.Errorf("替换文件内容失败: %w", err)
+	}
+	fc.quota.RecordUsage(file.UserID, old.MIMEType, -old.Size, 0)
+	fc.quota.RecordUsage(file.UserID, file.MIMEType, file.Size, 0)
+	fc.ingest.Schedule(file)
+	if err := fc.blobs.release(ctx, old.Hash, old.StorageKey); err != nil {
+		log.Printf("释放被替换内容的存储失败(%s): %v", file.ID, err)
+	}
+	return file, nil
+}
+
+// setContent 以 blob 作为文件的新内容，版本号加一
+func setContent(file *model.File, uploaderID uint, blob *model.Blob) {
+	file.Version = snapshotVersion(file).Version + 1
+	file.Size = blob.Size
+	file.Hash = blob.Hash
+	file.MIMEType = mime.TypeByExtension(filepath.Ext(file.Name))
+	file.StorageType = blob.StorageType
+	file.StorageKey = blob.StorageKey
+	file.UploaderID = uploaderID
+	file.UpdatedAt = time.Now()
+}
+
+// prune 只保留最近 maxVersions-1 个历史版本（当前版本占一个名额），并扣减所有者 ownerID 的用量
+func (fc *fileCommitter) prune(ctx context.Context, ownerID uint, fileID string, maxVersions int) {
+	versions, err := fc.versionDao.ListVersions(fileID)
+	if err != nil {
+		log.Printf("获取历史版本失败(%s): %v", fileID, err)
+		return
+	}
+	keep := maxVersions - 1
+	if keep < 0 {
+		keep = 0
+	}
+	for i := keep; i < len(versions); i++ {
+		if err := fc.versionDao.DeleteVersion(versions[i].ID); err != nil {
+			log.Printf("删除历史版本失败(%s): %v", versions[i].ID, err)
+			continue
+		}
+		fc.quota.RecordUsage(ownerID, versions[i].MIMEType, -versions[i].Size, 0)
+		if err := fc.blobs.release(ctx, versions[i].Hash, versions[i].StorageKey); err != nil {
+			log.Printf("释放历史版本存储失败(%s): %v", versions[i].ID, err)
+		}
+	}
+}
+
+// policy 获取用户的版本策略，未设置时使用配置的默认值
+func (fc *fileCommitter) policy(userID uint) (*model.VersionPolicy, error) {
+	policy, err := fc.versionDao.GetPolicy(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取版本策略失败: %w", err)
+	}
+	if policy != nil {
+		return policy, nil
+	}
+	cfg := config.AppConfigInstance.Version
+	maxVersions := cfg.MaxVersions
+	if maxVersions <= 0 {
+		maxVersions = defaultMaxVersions
+	}
+	return &model.VersionPolicy{UserID: userID, Enabled: cfg.Enabled, MaxVersions: maxVersions}, nil
+}
+
+// snapshotVersion 以文件当前内容构造历史版本记录
+func snapshotVersion(file *model.File) *model.FileVersion {
+	version := file.Version
+	if version <= 0 {
+		version = 1
+	}
+	uploaderID := file.UploaderID
+	if uploaderID == 0 {
+		uploaderID = file.UserID
+	}
+	return &model.FileVersion{
+		ID:          GenerateUUID(),
+		FileID:      file.ID,
+		Version:     version,
+		Size:        file.Size,
+		Hash:        file.Hash,
+		MIMEType:    file.MIMEType,
+		StorageType: file.StorageType,
+		StorageKey:  file.StorageKey,
+		UploaderID:  uploaderID,
+		CreatedAt:   file.UpdatedAt,
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	// ErrNameExists 由数据库唯一索引保证，写入时发生的同名冲突同样返回该错误
+	ErrNameExists   = dao.ErrNameExists
+	ErrConflictType = errors.New("同名条目类型不同，无法覆盖")
+	ErrInvalidName  = errors.New("文件名不合法")
+)
+
+// maxNameLength 文件名的最大字符数，与 files.name 列的 varchar(255) 一致
+const maxNameLength = 255
+
+// validateName 检查条目名称：不能为空、"." 或 ".."，不能包含路径分隔符，长度不超过 maxNameLength
+// 所有写入名称的路径（创建、上传、重命名、移动）都需要先校验，避免按路径访问或打包解压时产生歧义或逃逸
+func validateName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		return fmt.Errorf("%w: 长度超过 %d 个字符", ErrInvalidName, maxNameLength)
+	}
+	return nil
+}
+
+// conflictPolicy 返回 onConflict，为空时使用操作的默认策略 def
+func conflictPolicy(onConflict string, def string) string {
+	if onConflict == "" {
+		return def
+	}
+	return onConflict
+}
+
+// nameIndex 目标目录中已有的条目，以及包括本批次已占用名称在内的全部名称
+type nameIndex struct {
+	files map[string]*model.File
+	names map[string]bool
+}
+
+func (fs *fileService) newNameIndex(ownerID uint, parentID *string) (*nameIndex, error) {
+	files, err := fs.fileDao.GetFilesByParentID(ownerID, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取目标文件夹内容失败: %w", err)
+	}
+	ix := &nameIndex{files: make(map[string]*model.File, len(files)), names: make(map[string]bool, len(files))}
+	for i := range files {
+		ix.files[files[i].Name] = &files[i]
+		ix.names[files[i].Name] = true
+	}
+	return ix, nil
+}
+
+// resolve 按 policy 为 src 在目录中占用名称 name：返回最终名称，以及 overwrite 时需要覆盖的同名条目；
+// skip 为 true 时本条目不执行。本批次其他条目已占用的名称不能被覆盖
+// 这里只是提前发现冲突，并发请求之间的同名由写入时的唯一索引拒绝
+func (ix *nameIndex) resolve(src *model.File, name string, policy string) (string, *model.File, bool, error) {
+	if !ix.names[name] {
+		ix.names[name] = true
+		return name, nil, false, nil
+	}
+	switch policy {
+	case model.ConflictRename:
+		name = uniqueName(name, ix.names)
+		ix.names[name] = true
+		return name, nil, false, nil
+	case model.ConflictSkip:
+		return "", nil, true, nil
+	case model.ConflictOverwrite:
+		if existing := ix.files[name]; existing != nil {
+			if existing.IsDir != src.IsDir {
+				return "", nil, false, ErrConflictType
+			}
+			delete(ix.files, name)
+			return name, existing, false, nil
+		}
+	}
+	return "", nil, false, ErrNameExists
+}
+
+// place 在事务中将 file 以 name 移动到 parentID 下；existing 为需要覆盖的同名条目：
+// 文件被移入回收站，文件夹则将 file 的内容合并进去
+func place(tx *dao.Tx, file *model.File, parentID *string, name string, existing *model.File) error {
+	// 同批次中先执行的条目可能改写了路径与统计，写入前重新读取
+	current, err := reload(tx, file.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		target, err := reload(tx, existing.ID)
+		if err != nil {
+			return err
+		}
+		if current.IsDir {
+			return mergeInto(tx, current, target)
+		}
+		if err := trashFile(tx, target); err != nil {
+			return err
+		}
+	}
+	oldParentID := current.ParentID
+	// 更新文件信息，子树的祖先路径随之改写
+	if err := tx.Files.MoveFile(current, name, parentID); err != nil {
+		return fmt.Errorf("更新文件信息失败: %w", err)
+	}
+	moved := *current
+	moved.Name, moved.ParentID = name, parentID
+	changeType := model.ChangeRename
+	if !sameParent(oldParentID, parentID) {
+		changeType = model.ChangeMove
+	}
+	if err := tx.Changes.Record(changeOf(changeType, &moved)); err != nil {
+		return fmt.Errorf("记录变更失败: %w", err)
+	}
+	// 子树随条目整体移动，只需把其贡献从原祖先转到新祖先
+	stats := newFolderStats(tx.Files)
+	if err := stats.apply(oldParentID, negateStats(statsOf(current))); err != nil {
+		return err
+	}
+	return stats.apply(parentID, statsOf(current))
+}
+
+// mergeInto 将文件夹 src 的内容合并到同名文件夹 dst 中，子项再次同名时同样覆盖，最后删除已清空的 src
+func mergeInto(tx *dao.Tx, src *model.File, dst *model.File) error {
+	children, err := tx.Files.GetFilesByParentID(src.UserID, &src.ID)
+	if err != nil {
+		return fmt.Errorf("获取文件夹内容失败: %w", err)
+	}
+	for i := range children {
+		child := &children[i]
+		existing, err := tx.Files.GetFileByName(dst.UserID, &dst.ID, child.Name)
+		if err != nil {
+			return fmt.Errorf("查询同名文件失败: %w", err)
+		}
+		if existing != nil && existing.IsDir != child.IsDir {
+			return ErrConflictType
+		}
+		if err := place(tx, child, &dst.ID, child.Name, existing); err != nil {
+			return err
+		}
+	}
+	// 已清空的 src 不进入回收站，软删除只会留下无法恢复也不会被清理的记录
+	if err := tx.Files.PurgeFile(src.ID); err != nil {
+		return fmt.Errorf("删除文件夹失败: %w", err)
+	}
+	if err := tx.Changes.Record(changeOf(model.ChangeDelete, src)); err != nil {
+		return fmt.Errorf("记录变更失败: %w", err)
+	}
+	return newFolderStats(tx.Files).apply(src.ParentID, model.FolderStats{FolderCount: -1})
+}
+
+// trashFile 在事务中将条目（连同其子树）移入回收站
+// 子树大小取自文件夹的聚合统计，软删除按祖先路径一次完成，无需遍历子树
+func trashFile(tx *dao.Tx, file *model.File) error {
+	item := &model.TrashItem{
+		ID:               GenerateUUID(),
+		UserID:           file.UserID,
+		FileID:           file.ID,
+		Name:             file.Name,
+		IsDir:            file.IsDir,
+		Size:             statsOf(file).Size,
+		OriginalParentID: file.ParentID,
+		DeletedAt:        time.Now(),
+	}
+	if err := tx.Trash.MoveToTrash(item, file); err != nil {
+		return fmt.Errorf("删除操作失败: %w", err)
+	}
+	if err := tx.Changes.Record(changeOf(model.ChangeDelete, file)); err != nil {
+		return fmt.Errorf("记录变更失败: %w", err)
+	}
+	return newFolderStats(tx.Files).apply(file.ParentID, negateStats(statsOf(file)))
+}
+
+// reload 在事务中重新读取条目
+func reload(tx *dao.Tx, fileID string) (*model.File, error) {
+	file, err := tx.Files.GetFileMetaByFileID(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %w", err)
+	}
+	if file == nil {
+		return nil, ErrFileNotFound
+	}
+	return file, nil
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"time"
+)
+
+const defaultCopyAsyncThreshold = 200
+
+// copyPlan 一个待复制的顶层条目：subtree 按层排列，第一个元素为顶层条目本身
+// existing 为按 overwrite 策略需要覆盖的同名条目
+type copyPlan struct {
+	name     string
+	subtree  []model.File
+	existing *model.File
+}
+
+// copyRun 复制的执行环境；作为后台任务执行时替换为任务的 ctx 与进度
+type copyRun struct {
+	ctx      context.Context
+	progress func()
+}
+
+// copyPayload 后台复制任务的参数
+type copyPayload struct {
+	FileIDs        []string `json:"file_ids"`
+	TargetParentID string   `json:"target_parent_id"`
+	Mode           string   `json:"mode"`
+	OnConflict     string   `json:"on_conflict"`
+}
+
+// BatchCopyFiles 将文件或文件夹（连同子树）复制到目标文件夹，同名条目按 onConflict 处理，默认自动重命名
+// 条目数不超过阈值时同步完成并返回各条目结果；否则创建后台任务，结果中只包含任务信息
+func (fs *fileService) BatchCopyFiles(ctx context.Context, userID uint, fileIDs []string, targetParentID string, mode string, onConflict string) (*model.BatchResp, error) {
+	run := &copyRun{ctx: ctx, progress: func() {}}
+	b, total, err := fs.prepareCopy(run, userID, fileIDs, targetParentID, mode, onConflict)
+	if err != nil {
+		if b != nil {
+			return b.resp(), err
+		}
+		return nil, err
+	}
+	if total <= int64(fs.copyThreshold) {
+		err := b.execute(fs.tx)
+		return b.resp(), err
+	}
+
+	// 任务执行时重新校验并生成计划，此处的计划只用于提前返回校验错误
+	job, err := fs.jobs.Enqueue(userID, model.JobTypeCopy, copyPayload{
+		FileIDs:        fileIDs,
+		TargetParentID: targetParentID,
+		Mode:           b.mode,
+		OnConflict:     onConflict,
+	}, total)
+	if err != nil {
+		return nil, err
+	}
+	return &model.BatchResp{Mode: b.mode, Job: buildJobResp(job)}, nil
+}
+
+// prepareCopy 校验条目并生成复制计划，返回需要复制的条目总数
+// 全部成功模式下有条目校验失败时，同时返回 batch 以便返回各条目结果
+func (fs *fileService) prepareCopy(run *copyRun, userID uint, fileIDs []string, targetParentID string, mode string, onConflict string) (*batch, int64, error) {
+	policy := conflictPolicy(onConflict, model.ConflictRename)
+	var targetParentIDPtr *string
+	if targetParentID != "" {
+		targetParentIDPtr = &targetParentID
+	}
+	targetFolder, err := fs.permissions.AuthorizeFolder(userID, targetParentIDPtr, model.RoleEditor)
+	if err != nil {
+		return nil, 0, err
+	}
+	ownerID := folderOwner(userID, targetFolder)
+
+	index, err := fs.newNameIndex(ownerID, targetParentIDPtr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total, totalSize int64
+	b := prepareBatch(mode, fileIDs, func(fileID string) (*batchStep, error) {
+		file, err := fs.permissions.Authorize(userID, fileID, model.RoleViewer)
+		if err != nil {
+			return nil, err
+		}
+		subtree, err := fs.collectSubtree(file)
+		if err != nil {
+			return nil, err
+		}
+		if file.IsDir && targetFolder != nil && checkCircularReference(file, targetFolder) != nil {
+			return nil, errors.New("不能将文件夹复制到其子文件夹中")
+		}
+		name, existing, skip, err := index.resolve(file, file.Name, policy)
+		if err != nil {
+			return nil, err
+		}
+		if skip {
+			return &batchStep{skip: true}, nil
+		}
+		if existing != nil && existing.ID == file.ID {
+			return nil, errors.New("不能用条目覆盖其自身")
+		}
+		plan := &copyPlan{name: name, subtree: subtree, existing: existing}
+		total += int64(len(subtree))
+		for _, f := range subtree {
+			totalSize += f.Size
+		}
+
+		// 源文件ID -> 副本的内容对象
+		blobs := make(map[string]*model.Blob)
+		var created []*model.File
+		return &batchStep{
+			// 存储端复制与计算哈希可能较慢，在事务外完成，事务中只写入记录，避免长时间持有行锁
+			prepare: func() error {
+				for i := range plan.subtree {
+					src := &plan.subtree[i]
+					if src.IsDir {
+						continue
+					}
+					if err := run.ctx.Err(); err != nil {
+						return err
+					}
+					blob, err := fs.blobs.duplicate(run.ctx, ownerID, src)
+					if err != nil {
+						return err
+					}
+					blobs[src.ID] = blob
+				}
+				return nil
+			},
+			apply: func(tx *dao.Tx) error {
+				var err error
+				created, err = fs.copyTree(run, tx, ownerID, userID, plan, blobs, targetParentIDPtr)
+				return err
+			},
+			commit: func() {
+				for _, f := range created {
+					if !f.IsDir {
+						fs.quota.RecordUsage(ownerID, f.MIMEType, f.Size, 1)
+						fs.ingest.Schedule(f)
+					}
+				}
+			},
+			// 准备失败或事务回滚后副本记录不存在，释放已获取的存储对象引用；
+			// 回滚可能由取消引起，释放不能使用已结束的 ctx
+			rollback: func() {
+				for _, blob := range blobs {
+					_ = fs.blobs.release(context.Background(), blob.Hash, blob.StorageKey)
+				}
+				blobs = make(map[string]*model.Blob)
+				created = nil
+			},
+		}, nil
+	})
+	if b.mode == model.BatchModeAtomic {
+		if err := b.firstError(); err != nil {
+			return b, 0, err
+		}
+	}
+	if err := fs.quota.CheckQuota(ownerID, totalSize); err != nil {
+		return nil, 0, err
+	}
+	return b, total, nil
+}
+
+// runCopyJob 执行后台复制任务，逐项模式下失败的条目汇总到任务的错误信息中
+func (fs *fileService) runCopyJob(ctx context.Context, job *model.Job, progress *JobProgress) error {
+	var payload copyPayload
+	if err := decodePayload(job, &payload); err != nil {
+		return err
+	}
+	run := &copyRun{ctx: ctx, progress: func() { progress.Advance(1) }}
+	b, total, err := fs.prepareCopy(run, job.UserID, payload.FileIDs, payload.TargetParentID, payload.Mode, payload.OnConflict)
+	if err != nil {
+		if b != nil {
+			return permanent(err)
+		}
+		return err
+	}
+	progress.SetTotal(total)
+	// 全部成功模式失败时事务已回滚，可以重试；逐项模式下部分条目已经生效，不再重试
+	if err := b.execute(fs.tx); err != nil {
+		return err
+	}
+	return permanent(b.failures())
+}
+
+// copyTree 在事务中于 parentID 下创建 plan 的副本，文件内容使用事务前已获取的 blobs（源文件ID -> 内容对象）
+// 覆盖已有文件夹时把副本合并进去，其中同名的文件移入回收站后由副本替换
+// 返回已创建的条目，供事务结束后记账
+func (fs *fileService) copyTree(run *copyRun, tx *dao.Tx, ownerID uint, uploaderID uint, plan *copyPlan, blobs map[string]*model.Blob, parentID *string) ([]*model.File, error) {
+	// 源文件夹ID -> 副本文件夹；subtree 中父目录先于子项，处理子项时其父目录的副本已创建
+	copies := make(map[string]*model.File)
+	// 被合并的已有文件夹，其中的子项可能与副本同名
+	merged := make(map[string]bool)
+	created := make([]*model.File, 0, len(plan.subtree))
+	stats := newFolderStats(tx.Files)
+	for i := range plan.subtree {
+		if err := run.ctx.Err(); err != nil {
+			return created, err
+		}
+		src := &plan.subtree[i]
+		name, dstParentID, dstPath, existing := src.Name, parentID, "", plan.existing
+		if i == 0 {
+			name = plan.name
+		} else {
+			parent := copies[*src.ParentID]
+			dstParentID, dstPath, existing = &parent.ID, model.ChildPath(parent), nil
+			if merged[parent.ID] {
+				var err error
+				if existing, err = tx.Files.GetFileByName(ownerID, dstParentID, name); err != nil {
+					return created, fmt.Errorf("查询同名文件失败: %w", err)
+				}
+			}
+		}
+		if existing != nil {
+			if existing.IsDir != src.IsDir {
+				return created, ErrConflictType
+			}
+			if src.IsDir {
+				copies[src.ID] = existing
+				merged[existing.ID] = true
+				run.progress()
+				continue
+			}
+			current, err := reload(tx, existing.ID)
+			if err != nil {
+				return created, err
+			}
+			if err := trashFile(tx, current); err != nil {
+				return created, err
+			}
+		}
+
+		var dst *model.File
+		if src.IsDir {
+			dst = &model.File{
+				ID:          GenerateUUID(),
+				UserID:      ownerID,
+				Name:        name,
+				ParentID:    dstParentID,
+				Path:        dstPath,
+				IsDir:       true,
+				StorageType: "dir",
+				CreatedAt:   time.Now(),
+				UpdatedAt:   time.Now(),
+			}
+			if err := tx.Files.CreateFile(dst); err != nil {
+				return created, fmt.Errorf("创建文件夹失败: %w", err)
+			}
+		} else {
+			dst = newFileFromBlob(ownerID, uploaderID, name, dstParentID, blobs[src.ID])
+			dst.Path = dstPath
+			if src.MIMEType != "" {
+				dst.MIMEType = src.MIMEType
+			}
+			if err := tx.Files.CreateFile(dst); err != nil {
+				return created, fmt.Errorf("创建文件记录失败: %w", err)
+			}
+		}
+		if err := tx.Changes.Record(changeOf(model.ChangeCreate, dst)); err != nil {
+			return created, fmt.Errorf("记录变更失败: %w", err)
+		}
+		if err := stats.apply(dstParentID, statsOf(dst)); err != nil {
+			return created, err
+		}
+		copies[src.ID] = dst
+		created = append(created, dst)
+		run.progress()
+	}
+	return created, nil
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"llmcloud/internal/storage"
+	"log"
+	"mime/multipart"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultExtractMaxEntries = 10000
+	defaultExtractMaxSize    = 10 << 30
+	defaultExtractMaxRatio   = 100
+	// 解压后不足该大小时不检查压缩比，避免小文件（如大量空白文本）误判
+	extractRatioMinSize = 1 << 20
+)
+
+var (
+	ErrArchiveFormat = errors.New("不支持的压缩包格式")
+	ErrArchiveUnsafe = errors.New("压缩包包含不安全的内容或超出解压限制")
+)
+
+// extractLimits 解压限制，用于防御路径穿越与压缩炸弹
+type extractLimits struct {
+	maxEntries int
+	maxSize    int64
+	maxRatio   int64
+}
+
+// UploadAndExtract 暂存上传的压缩包并创建后台解压任务，压缩包的目录结构在 parentID 下重建
+func (fs *fileService) UploadAndExtract(ctx context.Context, userID uint, fileHeader *multipart.FileHeader, file multipart.File, parentID string, format string) (*model.JobResp, error) {
+	if format == "" {
+		format = detectArchiveFormat(fileHeader.Filename)
+	}
+	if format != model.ArchiveFormatZip && format != model.ArchiveFormatTar && format != model.ArchiveFormatTarGz {
+		return nil, ErrArchiveFormat
+	}
+	var parentIDPtr *string
+	if parentID != "" {
+		parentIDPtr = &parentID
+	}
+	parent, err := fs.permissions.AuthorizeFolder(userID, parentIDPtr, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	ownerID := folderOwner(userID, parent)
+	// 解压后的大小此时未知，先按压缩包大小预检，解压过程中再逐个条目检查
+	if err := fs.quota.CheckQuota(ownerID, fileHeader.Size); err != nil {
+		return nil, err
+	}
+
+	// 压缩包暂存到存储驱动，解压完成后删除
+	stagingKey := GenerateStorageKey(ownerID, "")
+	if err := fs.storageDriver.Upload(ctx, stagingKey, file, fileHeader.Size); err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+	job, err := fs.jobs.Enqueue(userID, model.JobTypeExtract, extractPayload{
+		StagingKey: stagingKey,
+		Format:     format,
+		OwnerID:    ownerID,
+		ParentID:   parentIDPtr,
+		ArchiveLen: fileHeader.Size,
+	}, 0)
+	if err != nil {
+		_ = fs.storageDriver.Delete(ctx, stagingKey)
+		return nil, err
+	}
+	return buildJobResp(job), nil
+}
+
+// extractPayload 后台解压任务的参数
+type extractPayload struct {
+	StagingKey string  `json:"staging_key"`
+	Format     string  `json:"format"`
+	OwnerID    uint    `json:"owner_id"`
+	ParentID   *string `json:"parent_id"`
+	ArchiveLen int64   `json:"archive_len"`
+}
+
+// runExtractJob 执行后台解压任务，结束后删除暂存的压缩包
+// 已解压的条目无法回滚，任务只执行一次：失败、服务停止或实例中断后都不再重新执行
+func (fs *fileService) runExtractJob(ctx context.Context, job *model.Job, progress *JobProgress) error {
+	var payload extractPayload
+	if err := decodePayload(job, &payload); err != nil {
+		return err
+	}
+	defer fs.abandonExtractJob(job)
+
+	ex := &extractor{
+		fs:         fs,
+		ctx:        ctx,
+		progress:   progress,
+		ownerID:    payload.OwnerID,
+		uploaderID: job.UserID,
+		parentID:   payload.ParentID,
+		limits:     fs.extractLimits,
+		archiveLen: payload.ArchiveLen,
+	}
+	return ex.extract(payload.StagingKey, payload.Format)
+}
+
+// abandonExtractJob 删除解压任务暂存的压缩包，任务结束或因实例中断被放弃时调用
+func (fs *fileService) abandonExtractJob(job *model.Job) {
+	var payload extractPayload
+	if err := decodePayload(job, &payload); err != nil {
+		return
+	}
+	if err := fs.storageDriver.Delete(context.Background(), payload.StagingKey); err != nil {
+		log.Printf("删除暂存压缩包失败(%s): %v", payload.StagingKey, err)
+	}
+}
+
+// extractor 执行一次解压任务
+type extractor struct {
+	fs         *fileService
+	ctx        context.Context
+	progress   *JobProgress
+	ownerID    uint
+	uploaderID uint
+	parentID   *string
+	limits     extractLimits
+	archiveLen int64
+
+	dirs     map[string]*string         // 压缩包内目录路径 -> 对应的文件夹ID，"" 为目标目录
+	names    map[string]map[string]bool // 压缩包内目录路径 -> 该目录下已使用的名称
+	entries  int
+	expanded int64
+}
+
+func (ex *extractor) extract(stagingKey string, format string) error {
+	existing, err := ex.fs.fileDao.GetFilesByParentID(ex.ownerID, ex.parentID)
+	if err != nil {
+		return fmt.Errorf("获取目标目录内容失败: %w", err)
+	}
+	rootNames := make(map[string]bool, len(existing))
+	for _, f := range existing {
+		rootNames[f.Name] = true
+	}
+	ex.dirs = map[string]*string{"": ex.parentID}
+	ex.names = map[string]map[string]bool{"": rootNames}
+
+	if format == model.ArchiveFormatZip {
+		return ex.extractZip(stagingKey)
+	}
+	reader, err := ex.fs.storageDriver.Download(ex.ctx, stagingKey)
+	if err != nil {
+		return fmt.Errorf("读取压缩包失败: %w", err)
+	}
+	defer reader.Close()
+	var stream io.Reader = reader
+	if format == model.ArchiveFormatTarGz {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return ErrArchiveFormat
+		}
+		defer gz.Close()
+		stream = gz
+	}
+	return ex.extractTar(stream)
+}
+
+func (ex *extractor) extractZip(stagingKey string) error {
+	readerAt, err := storage.OpenReaderAt(ex.ctx, ex.fs.storageDriver, stagingKey, ex.archiveLen)
+	if err != nil {
+		return fmt.Errorf("读取压缩包失败: %w", err)
+	}
+	defer readerAt.Close()
+	zr, err := zip.NewReader(readerAt, ex.archiveLen)
+	if err != nil {
+		return ErrArchiveFormat
+	}
+
+	// 解压前根据中央目录检查条目数、总大小与压缩比
+	if len(zr.File) > ex.limits.maxEntries {
+		return fmt.Errorf("%w: 条目数超过 %d", ErrArchiveUnsafe, ex.limits.maxEntries)
+	}
+	var declared int64
+	for _, f := range zr.File {
+		size := int64(f.UncompressedSize64)
+		if size < 0 || declared+size > ex.limits.maxSize {
+			return fmt.Errorf("%w: 解压后大小超过上限", ErrArchiveUnsafe)
+		}
+		declared += size
+		if exceedsRatio(size, int64(f.CompressedSize64), ex.limits.maxRatio) {
+			return fmt.Errorf("%w: %s 压缩比异常", ErrArchiveUnsafe, f.Name)
+		}
+	}
+	ex.progress.SetTotal(int64(len(zr.File)))
+
+	for _, f := range zr.File {
+		entryPath, err := sanitizeEntryPath(f.Name)
+		if err != nil {
+			return err
+		}
+		if entryPath == "" {
+			continue
+		}
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if _, err := ex.ensureDir(entryPath); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("读取压缩包条目失败(%s): %w", f.Name, err)
+			}
+			// archive/zip 在实际数据超过声明大小或校验和不符时返回错误
+			err = ex.addFile(entryPath, rc, int64(f.UncompressedSize64))
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		default:
+			// 跳过符号链接等特殊条目
+		}
+		if err := ex.advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ex *extractor) extractTar(stream io.Reader) error {
+	tr := tar.NewReader(stream)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrArchiveFormat, err)
+		}
+		if ex.entries >= ex.limits.maxEntries {
+			return fmt.Errorf("%w: 条目数超过 %d", ErrArchiveUnsafe, ex.limits.maxEntries)
+		}
+		entryPath, err := sanitizeEntryPath(header.Name)
+		if err != nil {
+			return err
+		}
+		if entryPath == "" {
+			if err := ex.advance(); err != nil {
+				return err
+			}
+			continue
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if _, err := ex.ensureDir(entryPath); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if header.Size < 0 || ex.expanded+header.Size > ex.limits.maxSize {
+				return fmt.Errorf("%w: 解压后大小超过上限", ErrArchiveUnsafe)
+			}
+			// tar.gz 无法预先得知单个条目的压缩大小，按整体压缩比检查
+			if exceedsRatio(ex.expanded+header.Size, ex.archiveLen, ex.limits.maxRatio) {
+				return fmt.Errorf("%w: 压缩比异常", ErrArchiveUnsafe)
+			}
+			if err := ex.addFile(entryPath, tr, header.Size); err != nil {
+				return err
+			}
+		default:
+			// 跳过链接、设备文件等特殊条目
+		}
+		if err := ex.advance(); err != nil {
+			return err
+		}
+	}
+}
+
+// ensureDir 确保压缩包内的目录在文件树中存在，返回其文件夹ID
+// 与目标目录中已有条目重名的顶层目录会自动重命名，压缩包内的同名目录合并为一个
+func (ex *extractor) ensureDir(dirPath string) (*string, error) {
+	if id, ok := ex.dirs[dirPath]; ok {
+		return id, nil
+	}
+	parentPath := path.Dir(dirPath)
+	if parentPath == "." {
+		parentPath = ""
+	}
+	parentID, err := ex.ensureDir(parentPath)
+	if err != nil {
+		return nil, err
+	}
+	name := uniqueName(path.Base(dirPath), ex.names[parentPath])
+	ex.names[parentPath][name] = true
+	folder := &model.File{
+		ID:          GenerateUUID(),
+		UserID:      ex.ownerID,
+		Name:        name,
+		ParentID:    parentID,
+		IsDir:       true,
+		StorageType: "dir",
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+	if err := writeChange(ex.fs.tx, model.ChangeCreate, folder, func(tx *dao.Tx) error {
+		if err := tx.Files.CreateFile(folder); err != nil {
+			return err
+		}
+		return newFolderStats(tx.Files).apply(parentID, statsOf(folder))
+	}); err != nil {
+		return nil, fmt.Errorf("创建文件夹失败: %w", err)
+	}
+	ex.dirs[dirPath] = &folder.ID
+	ex.names[dirPath] = make(map[string]bool)
+	return &folder.ID, nil
+}
+
+// addFile 将条目内容写入存储并登记到所在目录，同名时自动重命名
+func (ex *extractor) addFile(entryPath string, reader io.Reader, size int64) error {
+	dirPath := path.Dir(entryPath)
+	if dirPath == "." {
+		dirPath = ""
+	}
+	parentID, err := ex.ensureDir(dirPath)
+	if err != nil {
+		return err
+	}
+	name := uniqueName(path.Base(entryPath), ex.names[dirPath])
+	ex.names[dirPath][name] = true
+	if err := ex.fs.quota.CheckQuota(ex.ownerID, size); err != nil {
+		return fmt.Errorf("保存文件失败(%s): %w", entryPath, err)
+	}
+
+	blob, err := ex.fs.blobs.put(ex.ctx, ex.ownerID, io.LimitReader(reader, size), size)
+	if err != nil {
+		return fmt.Errorf("保存文件失败(%s): %w", entryPath, err)
+	}
+	ex.expanded += size
+	if _, err := ex.fs.committer.commit(ex.ctx, ex.ownerID, ex.uploaderID, name, parentID, blob, model.ConflictRename); err != nil {
+		return fmt.Errorf("保存文件失败(%s): %w", entryPath, err)
+	}
+	return nil
+}
+
+// advance 记录处理完一个条目，任务被取消时返回错误以停止解压
+func (ex *extractor) advance() error {
+	ex.entries++
+	ex.progress.Advance(1)
+	return ex.ctx.Err()
+}
+
+// sanitizeEntryPath 规范化压缩包内的路径，拒绝绝对路径与跳出解压目录的路径（zip-slip）
+// 返回空字符串表示条目即解压根目录本身，可忽略
+func sanitizeEntryPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	// 以 "/" 开头或带盘符（如 C:）的均视为绝对路径
+	if strings.HasPrefix(name, "/") || (len(name) >= 2 && name[1] == ':') {
+		return "", fmt.Errorf("%w: 非法路径 %s", ErrArchiveUnsafe, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("%w: 非法路径 %s", ErrArchiveUnsafe, name)
+		}
+	}
+	cleaned := path.Clean(name)
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// exceedsRatio 判断解压后大小与压缩后大小之比是否超过上限
+func exceedsRatio(expanded int64, compressed int64, maxRatio int64) bool {
+	if expanded < extractRatioMinSize {
+		return false
+	}
+	if compressed <= 0 {
+		return true
+	}
+	return expanded/compressed > maxRatio
+}
+
+// detectArchiveFormat 根据文件名推断压缩包格式
+func detectArchiveFormat(name string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return model.ArchiveFormatZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return model.ArchiveFormatTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return model.ArchiveFormatTar
+	}
+	return ""
+}
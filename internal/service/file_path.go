@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/internal/model"
+	"mime/multipart"
+	"path"
+	"strings"
+)
+
+// pathRoot 用户根目录在路径中的名称，与 GetFilePath 的输出一致
+const pathRoot = "/root"
+
+var ErrInvalidPath = errors.New("路径格式错误")
+
+// ResolvePath 将 "/root/a/b" 形式的路径解析为当前用户文件树中的条目，根目录返回 nil
+func (fs *fileService) ResolvePath(userID uint, p string) (*model.File, error) {
+	segments, err := splitPath(p)
+	if err != nil {
+		return nil, err
+	}
+	return fs.walk(userID, segments, false)
+}
+
+// StatPath 获取路径对应条目的元数据，根目录返回带聚合统计的虚拟文件夹
+func (fs *fileService) StatPath(userID uint, p string) (*model.File, error) {
+	file, err := fs.ResolvePath(userID, p)
+	if err != nil || file != nil {
+		return file, err
+	}
+	stats, err := fs.GetFolderStats(userID, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &model.File{
+		UserID:      userID,
+		Name:        "root",
+		IsDir:       true,
+		StorageType: "dir",
+		TreeSize:    stats.Size,
+		FileCount:   stats.FileCount,
+		FolderCount: stats.FolderCount,
+	}, nil
+}
+
+// MakeDirs 按路径创建文件夹；parents 为 true 时与 mkdir -p 一致，创建缺失的上级目录且目标已存在时不报错
+func (fs *fileService) MakeDirs(userID uint, p string, parents bool) (*model.File, error) {
+	segments, err := splitPath(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		if parents {
+			return nil, nil
+		}
+		return nil, ErrNameExists
+	}
+	parent, err := fs.resolveFolder(userID, segments[:len(segments)-1], parents)
+	if err != nil {
+		return nil, err
+	}
+	name := segments[len(segments)-1]
+	if parents {
+		return fs.ensureFolder(userID, idOf(parent), name)
+	}
+	// 同名由数据库唯一索引拒绝
+	return fs.createFolder(userID, name, idOf(parent))
+}
+
+// ensureFolder 返回 parentID 下名为 name 的文件夹，不存在时创建；同名的是文件时返回 ErrNameExists
+func (fs *fileService) ensureFolder(userID uint, parentID *string, name string) (*model.File, error) {
+	folder, err := fs.createFolder(userID, name, parentID)
+	if !errors.Is(err, ErrNameExists) {
+		return folder, err
+	}
+	// 已存在（可能由并发请求刚刚创建）时沿用该文件夹
+	existing, err := fs.fileDao.GetFileByName(userID, parentID, name)
+	if err != nil {
+		return nil, fmt.Errorf("查询文件失败: %w", err)
+	}
+	if existing == nil || !existing.IsDir {
+		return nil, ErrNameExists
+	}
+	return existing, nil
+}
+
+// UploadByPath 将文件上传到路径 p，路径最后一级为文件名；parents 为 true 时创建缺失的上级目录
+func (fs *fileService) UploadByPath(ctx context.Context, userID uint, fileHeader *multipart.FileHeader, file multipart.File, p string, parents bool, onConflict string) error {
+	segments, err := splitPath(p)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return ErrInvalidPath
+	}
+	parent, err := fs.resolveFolder(userID, segments[:len(segments)-1], parents)
+	if err != nil {
+		return err
+	}
+	header := *fileHeader
+	header.Filename = segments[len(segments)-1]
+	parentID := ""
+	if parent != nil {
+		parentID = parent.ID
+	}
+	return fs.UploadFile(ctx, userID, &header, file, parentID, onConflict)
+}
+
+// MovePath 按路径移动条目：to 为已存在的文件夹时移入其中，
+// 否则移动到 to 的上级目录并改名为 to 的最后一级，parents 为 true 时创建缺失的上级目录
+// 目标位置已有同名条目时按 onConflict 处理，默认报错
+func (fs *fileService) MovePath(userID uint, from string, to string, parents bool, onConflict string) error {
+	src, err := fs.ResolvePath(userID, from)
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		return errors.New("不能移动根目录")
+	}
+	segments, err := splitPath(to)
+	if err != nil {
+		return err
+	}
+
+	var folder *model.File
+	var name string
+	dst, err := fs.walk(userID, segments, false)
+	switch {
+	case err == nil && (dst == nil || dst.IsDir):
+		folder, name = dst, src.Name
+	case err == nil || errors.Is(err, ErrFileNotFound):
+		name = segments[len(segments)-1]
+		if err := validateName(name); err != nil {
+			return err
+		}
+		if folder, err = fs.resolveFolder(userID, segments[:len(segments)-1], parents); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+	targetID := ""
+	if folder != nil {
+		targetID = folder.ID
+	}
+	_, err = fs.moveFiles(userID, []moveItem{{fileID: src.ID, name: name}}, targetID, model.BatchModeAtomic,
+		conflictPolicy(onConflict, model.ConflictFail))
+	return err
+}
+
+// resolveFolder 解析 segments 对应的文件夹，create 为 true 时创建缺失的文件夹
+func (fs *fileService) resolveFolder(userID uint, segments []string, create bool) (*model.File, error) {
+	folder, err := fs.walk(userID, segments, create)
+	if err != nil {
+		return nil, err
+	}
+	if folder != nil && !folder.IsDir {
+		return nil, errors.New("目标路径不是文件夹")
+	}
+	return folder, nil
+}
+
+// walk 从用户根目录逐级查找 segments，返回最后一级（根目录为 nil）
+// create 为 true 时创建缺失的文件夹；中间某一级为文件时视为不存在
+func (fs *fileService) walk(userID uint, segments []string, create bool) (*model.File, error) {
+	var current *model.File
+	for _, name := range segments {
+		if current != nil && !current.IsDir {
+			return nil, ErrFileNotFound
+		}
+		next, err := fs.fileDao.GetFileByName(userID, idOf(current), name)
+		if err != nil {
+			return nil, fmt.Errorf("查询文件失败: %w", err)
+		}
+		if next == nil {
+			if !create {
+				return nil, ErrFileNotFound
+			}
+			if next, err = fs.ensureFolder(userID, idOf(current), name); err != nil {
+				return nil, err
+			}
+		}
+		current = next
+	}
+	return current, nil
+}
+
+// splitPath 规范化路径并拆分为各级名称，路径必须位于 /root 之下
+func splitPath(p string) ([]string, error) {
+	if !strings.HasPrefix(p, "/") {
+		return nil, ErrInvalidPath
+	}
+	cleaned := path.Clean(p)
+	if cleaned == pathRoot {
+		return nil, nil
+	}
+	if !strings.HasPrefix(cleaned, pathRoot+"/") {
+		return nil, ErrInvalidPath
+	}
+	return strings.Split(cleaned[len(pathRoot)+1:], "/"), nil
+}
+
+// idOf 返回文件夹的ID，nil（根目录）返回 nil
+func idOf(folder *model.File) *string {
+	if folder == nil {
+		return nil
+	}
+	return &folder.ID
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -8,34 +9,62 @@ import (
 	"llmcloud/internal/dao"
 	"llmcloud/internal/model"
 	"llmcloud/internal/storage"
-	"log"
 	"mime"
 	"mime/multipart"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type FileService interface {
-	UploadFile(userID uint, fileHeader *multipart.FileHeader, file multipart.File, parentID string) error
-	GetFileURL(key string) (string, error)
+	UploadFile(ctx context.Context, userID uint, fileHeader *multipart.FileHeader, file multipart.File, parentID string, onConflict string) error
+	GetFileURL(ctx context.Context, userID uint, fileID string) (string, error)
 	PageList(userID uint, parentID *string, page int, pageSize int, sort string) (int64, []model.File, error)
-	DownloadFile(fileID string) (*model.File, []byte, error)
-	DeleteFileOrFolder(userID uint, fileID string) error
+	DownloadFile(ctx context.Context, userID uint, fileID string) (*model.File, io.ReadSeekCloser, error)
+	DeleteFileOrFolder(ctx context.Context, userID uint, fileID string) (*model.JobResp, error)
+	PreCheckUpload(ctx context.Context, userID uint, req *model.PreCheckReq) (*model.PreCheckResp, error)
 	CreateFolder(userID uint, name string, parentID *string) error
-	BatchMoveFiles(userID uint, fileIDs []string, targetParentID string) error
+	BatchMoveFiles(userID uint, fileIDs []string, targetParentID string, mode string, onConflict string) (*model.BatchResp, error)
+	BatchDeleteFiles(userID uint, fileIDs []string, mode string) (*model.BatchResp, error)
+	BatchRename(userID uint, items []model.RenameItem, mode string, onConflict string) (*model.BatchResp, error)
+	BatchCopyFiles(ctx context.Context, userID uint, fileIDs []string, targetParentID string, mode string, onConflict string) (*model.BatchResp, error)
+	PrepareArchive(userID uint, fileIDs []string, format string) (*model.Archive, error)
+	WriteArchive(ctx context.Context, archive *model.Archive, w io.Writer) error
+	UploadAndExtract(ctx context.Context, userID uint, fileHeader *multipart.FileHeader, file multipart.File, parentID string, format string) (*model.JobResp, error)
 	SearchList(userID uint, key string, page int, size int, sort string) (int64, []model.File, error)
-	Rename(userID uint, fileID string, newName string) error
-	GetFilePath(fileID string) (string, error)
-	GetFileIDPath(fileID string) (string, error)
+	Rename(userID uint, fileID string, newName string, onConflict string) error
+	GetFilePath(userID uint, fileID string) (string, error)
+	GetFileIDPath(userID uint, fileID string) (string, error)
+	GetFolderStats(userID uint, parentID *string) (*model.FolderStatsResp, error)
+
+	// 基于路径的访问，路径形如 GetFilePath 返回的 "/root/a/b"
+	ResolvePath(userID uint, p string) (*model.File, error)
+	StatPath(userID uint, p string) (*model.File, error)
+	MakeDirs(userID uint, p string, parents bool) (*model.File, error)
+	UploadByPath(ctx context.Context, userID uint, fileHeader *multipart.FileHeader, file multipart.File, p string, parents bool, onConflict string) error
+	MovePath(userID uint, from string, to string, parents bool, onConflict string) error
 }
 
 type fileService struct {
-	fileDao       dao.FileDao
-	storageDriver storage.Driver
+	fileDao         dao.FileDao
+	trashDao        dao.TrashDao
+	tx              dao.Transactor
+	ingest          IngestService
+	jobs            JobQueue
+	permissions     PermissionService
+	quota           QuotaService
+	storageDriver   storage.Driver
+	blobs           *blobStore
+	committer       *fileCommitter
+	copyThreshold   int
+	deleteThreshold int
+	archiveMaxSize  int64
+	extractLimits   extractLimits
 }
 
+// SearchList 在用户自己的文件中按关键字搜索
 func (fs *fileService) SearchList(userID uint, key string, page int, pageSize int, sort string) (int64, []model.File, error) {
 	total, err := fs.fileDao.CountFilesByKeyword(key, userID)
 	if err != nil {
@@ -48,206 +77,202 @@ func (fs *fileService) SearchList(userID uint, key string, page int, pageSize in
 	return total, files, nil
 }
 
-func (fs *fileService) Rename(userID uint, fileID string, newName string) error {
-	// 根据id获取file信息
-	file, err := fs.fileDao.GetFileMetaByFileID(fileID)
+// GetFilePath 根据祖先路径一次查出全部祖先，生成文件路径
+func (fs *fileService) GetFilePath(userID uint, fileID string) (string, error) {
+	file, err := fs.permissions.Authorize(userID, fileID, model.RoleViewer)
 	if err != nil {
-		return errors.New("请检查文件是否存在")
+		return "", err
 	}
-	// 检查同名信息
-	existing, _ := fs.fileDao.GetFilesByParentID(userID, file.ParentID)
-	for _, f := range existing {
-		if f.Name == newName {
-			return errors.New("目标名称已占用")
-		}
+	ancestors, err := fs.ancestors(file)
+	if err != nil {
+		return "", err
 	}
-	// 更新信息
-	file.Name = newName
-	file.UpdatedAt = time.Now()
-	if err := fs.fileDao.UpdateFile(file); err != nil {
-		return errors.New("重命名失败")
+	names := make([]string, 0, len(ancestors)+1)
+	for _, a := range ancestors {
+		names = append(names, a.Name)
 	}
-	return nil
+	return "/root/" + strings.Join(append(names, file.Name), "/"), nil
 }
 
-// GetFilePath 通过递归查询生成文件路径
-func (fs *fileService) GetFilePath(fileID string) (string, error) {
-	file, err := fs.fileDao.GetFileMetaByFileID(fileID)
+// GetFileIDPath 生成基于文件ID的路径
+func (fs *fileService) GetFileIDPath(userID uint, fileID string) (string, error) {
+	file, err := fs.permissions.Authorize(userID, fileID, model.RoleViewer)
 	if err != nil {
 		return "", err
 	}
-	path := file.Name
-	currentParentID := file.ParentID
+	ids := model.AncestorIDs(file.Path)
+	return "/root/" + strings.Join(append(ids, file.ID), "/"), nil
+}
 
-	for currentParentID != nil {
-		parent, err := fs.fileDao.GetFileMetaByFileID(*currentParentID)
-		if err != nil {
-			return "", err
+// ancestors 按从根到父的顺序返回文件的全部祖先文件夹
+func (fs *fileService) ancestors(file *model.File) ([]model.File, error) {
+	ids := model.AncestorIDs(file.Path)
+	folders, err := fs.fileDao.GetFilesByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件夹信息失败: %w", err)
+	}
+	byID := make(map[string]*model.File, len(folders))
+	for i := range folders {
+		byID[folders[i].ID] = &folders[i]
+	}
+	result := make([]model.File, 0, len(ids))
+	for _, id := range ids {
+		if folder, ok := byID[id]; ok {
+			result = append(result, *folder)
 		}
-		path = parent.Name + "/" + path
-		currentParentID = parent.ParentID
 	}
-	return "/root/" + path, nil
+	return result, nil
 }
 
-// GetFileIDPath 生成基于文件ID的路径
-func (fs *fileService) GetFileIDPath(fileID string) (string, error) {
-	file, err := fs.fileDao.GetFileMetaByFileID(fileID)
+// UploadFile 将上传的文件以流的方式写入存储驱动，不在内存中缓存整个文件
+// 写入时同步计算 SHA-256，内容相同的文件共享同一个存储对象；同名文件按 onConflict 处理
+func (fs *fileService) UploadFile(ctx context.Context, userID uint, fileHeader *multipart.FileHeader, file multipart.File, parentID string, onConflict string) error {
+	if err := validateName(fileHeader.Filename); err != nil {
+		return err
+	}
+	var parentIDPtr *string
+	if parentID != "" {
+		parentIDPtr = &parentID
+	}
+	parent, err := fs.permissions.AuthorizeFolder(userID, parentIDPtr, model.RoleEditor)
 	if err != nil {
-		return "", err
+		return err
 	}
-
-	path := file.ID
-	currentParentID := file.ParentID
-
-	for currentParentID != nil {
-		parent, err := fs.fileDao.GetFileMetaByFileID(*currentParentID)
-		if err != nil {
-			return "", err
-		}
-		path = parent.ID + "/" + path
-		currentParentID = parent.ParentID
+	ownerID := folderOwner(userID, parent)
+	if err := fs.quota.CheckQuota(ownerID, fileHeader.Size); err != nil {
+		return err
 	}
 
-	return "/root/" + path, nil
+	// Stream file to storage
+	blob, err := fs.blobs.put(ctx, ownerID, file, fileHeader.Size)
+	if err != nil {
+		return err
+	}
+	// Save file metadata to database
+	_, err = fs.committer.commit(ctx, ownerID, userID, fileHeader.Filename, parentIDPtr, blob, onConflict)
+	return err
 }
 
-func (fs *fileService) UploadFile(userID uint, fileHeader *multipart.FileHeader, file multipart.File, parentID string) error {
-	fileID := GenerateUUID()
-	newFile := model.File{
-		ID:          fileID,
-		UserID:      userID,
-		Name:        fileHeader.Filename,
-		Size:        fileHeader.Size,
-		MIMEType:    mime.TypeByExtension(filepath.Ext(fileHeader.Filename)),
-		StorageType: config.AppConfigInstance.Storage.Type,
-		StorageKey:  GenerateStorageKey(userID, fileID),
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+// PreCheckUpload 秒传预检：调用方已持有相同内容时直接创建文件记录，无需再传输数据
+// 只匹配调用方自己拥有或上传过的内容：仅凭哈希与大小不能证明持有文件，否则知道哈希即可取得他人的私有文件
+func (fs *fileService) PreCheckUpload(ctx context.Context, userID uint, req *model.PreCheckReq) (*model.PreCheckResp, error) {
+	if err := validateName(req.FileName); err != nil {
+		return nil, err
 	}
-
-	if parentID != "" {
-		newFile.ParentID = &parentID
+	if req.ParentID != nil && *req.ParentID == "" {
+		req.ParentID = nil
+	}
+	parent, err := fs.permissions.AuthorizeFolder(userID, req.ParentID, model.RoleEditor)
+	if err != nil {
+		return nil, err
 	}
-	// TODO:校验ParentID的合法性
 
-	// Read file data
-	fileData, err := io.ReadAll(file)
+	if err := fs.quota.CheckQuota(folderOwner(userID, parent), req.Size); err != nil {
+		return nil, err
+	}
+	hash := strings.ToLower(req.Hash)
+	owned, err := fs.fileDao.HasContent(userID, hash, req.Size)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return nil, fmt.Errorf("查询存储对象失败: %w", err)
 	}
-	// Upload file to storage
-	if err := fs.storageDriver.Upload(fileData, newFile.StorageKey); err != nil {
-		return fmt.Errorf("failed to upload file: %w", err)
+	if !owned {
+		return &model.PreCheckResp{Instant: false}, nil
 	}
-	// Save file metadata to database
-	if err := fs.fileDao.CreateFile(&newFile); err != nil {
-		return fmt.Errorf("failed to create file metadata: %w", err)
+	blob, err := fs.blobs.acquire(hash, req.Size)
+	if err != nil {
+		return nil, fmt.Errorf("查询存储对象失败: %w", err)
 	}
-	return nil
+	if blob == nil {
+		return &model.PreCheckResp{Instant: false}, nil
+	}
+	newFile, err := fs.committer.commit(ctx, folderOwner(userID, parent), userID, req.FileName, req.ParentID, blob, req.OnConflict)
+	if err != nil {
+		return nil, err
+	}
+	return &model.PreCheckResp{Instant: true, File: newFile}, nil
 }
 
-func (fs *fileService) GetFileURL(key string) (string, error) {
-	return fs.storageDriver.GetURL(key)
+// GetFileURL 获取文件在存储端的访问地址
+func (fs *fileService) GetFileURL(ctx context.Context, userID uint, fileID string) (string, error) {
+	file, err := fs.permissions.Authorize(userID, fileID, model.RoleViewer)
+	if err != nil {
+		return "", err
+	}
+	if file.IsDir {
+		return "", errors.New("文件夹没有访问地址")
+	}
+	return fs.storageDriver.GetURL(ctx, file.StorageKey)
 }
 
 func (fs *fileService) ListFiles(userID uint, parentID *string) ([]model.File, error) {
 	return fs.fileDao.GetFilesByParentID(userID, parentID)
 }
 
+// PageList 分页列出目录内容，parentID 为 nil 时列出用户自己的根目录
 func (fs *fileService) PageList(userID uint, parentID *string, page int, pageSize int, sort string) (int64, []model.File, error) {
-	total, err := fs.fileDao.CountFilesByParentID(parentID, userID)
+	parent, err := fs.permissions.AuthorizeFolder(userID, parentID, model.RoleViewer)
 	if err != nil {
 		return 0, nil, err
 	}
-	files, err := fs.fileDao.ListFiles(userID, parentID, page, pageSize, sort)
+	ownerID := folderOwner(userID, parent)
+	total, err := fs.fileDao.CountFilesByParentID(parentID, ownerID)
+	if err != nil {
+		return 0, nil, err
+	}
+	files, err := fs.fileDao.ListFiles(ownerID, parentID, page, pageSize, sort)
 	if err != nil {
 		return 0, nil, err
 	}
 	return total, files, nil
 }
 
-func (fs *fileService) DownloadFile(fileID string) (*model.File, []byte, error) {
+// DownloadFile 获取文件元数据并打开可 Seek 的文件流，调用方负责关闭返回的 reader
+// Seek 到新位置时会通过存储驱动的区间读取重新打开流，用于支持 Range 请求
+func (fs *fileService) DownloadFile(ctx context.Context, userID uint, fileID string) (*model.File, io.ReadSeekCloser, error) {
 	// 1. 验证文件权限并获取元数据
-	fileMeta, err := fs.fileDao.GetFileMetaByFileID(fileID)
+	fileMeta, err := fs.permissions.Authorize(userID, fileID, model.RoleViewer)
 	if err != nil {
-		return nil, nil, fmt.Errorf("数据库查询失败: %w", err)
+		return nil, nil, err
+	}
+	if fileMeta.IsDir {
+		return nil, nil, errors.New("不能直接下载文件夹")
 	}
-	log.Printf("storagekey为：%s", fileMeta.StorageKey)
-	// 2. 从存储驱动获取文件内容
-	fileData, err := fs.storageDriver.Download(fileMeta.StorageKey)
+	// 2. 从存储驱动打开文件流
+	reader, err := storage.OpenSeeker(ctx, fs.storageDriver, fileMeta.StorageKey, fileMeta.Size)
 	if err != nil {
 		return nil, nil, fmt.Errorf("文件下载失败: %w", err)
 	}
-	// 3. 验证文件大小一致性
-	if int64(len(fileData)) != fileMeta.Size {
-		return nil, nil, fmt.Errorf("文件大小不匹配")
-	}
-	return fileMeta, fileData, nil
+	return fileMeta, reader, nil
 }
 
-func (fs *fileService) DeleteFileOrFolder(userID uint, fileID string) error {
-	file, err := fs.fileDao.GetFileMetaByFileID(fileID)
+// collectSubtree 返回 root 及其全部后代，父目录排在其子项之前
+func (fs *fileService) collectSubtree(root *model.File) ([]model.File, error) {
+	subtree, err := fs.fileDao.GetSubtree(root)
 	if err != nil {
-		return fmt.Errorf("获取文件信息失败：%v", err)
-	}
-	if file.IsDir {
-		fileIDPtr := &fileID
-		children, err := fs.fileDao.GetFilesByParentID(userID, fileIDPtr)
-		if err != nil {
-			return fmt.Errorf("获取子文件失败：%v", err)
-		}
-		for _, child := range children {
-			if err := fs.DeleteFileOrFolder(userID, child.ID); err != nil {
-				return err
-			}
-		}
-	}
-	//删除数据库
-	if !file.IsDir {
-		storageKey := file.StorageKey
-		if err := fs.storageDriver.Delete(storageKey); err != nil {
-			return err
-		}
-	}
-	//删除存储
-	if err := fs.fileDao.DeleteFile(file.ID); err != nil {
-		return fmt.Errorf("删除操作失败:%v", err)
+		return nil, fmt.Errorf("获取子文件失败：%v", err)
 	}
-	return nil
+	return subtree, nil
 }
 
 func (fs *fileService) CreateFolder(userID uint, name string, parentID *string) error {
-	var parent *model.File
-
-	if parentID != nil {
-		var err error
-		parent, err = fs.fileDao.GetFileMetaByFileID(*parentID)
-		if err != nil || parent == nil {
-			return errors.New("父目录不存在")
-		}
-		if !parent.IsDir {
-			return errors.New("父路径不是目录")
-		}
-		if parent.UserID != userID {
-			return errors.New("权限不足")
-		}
+	parent, err := fs.permissions.AuthorizeFolder(userID, parentID, model.RoleEditor)
+	if err != nil {
+		return err
 	}
+	// 同名由数据库唯一索引拒绝
+	_, err = fs.createFolder(folderOwner(userID, parent), name, parentID)
+	return err
+}
 
-	// 同名检查
-	existing, _ := fs.fileDao.GetFilesByParentID(userID, parentID)
-	for _, f := range existing {
-		if f.Name == name {
-			if f.IsDir {
-				return errors.New("文件夹已存在")
-			}
-		}
+// createFolder 在 ownerID 的 parentID 下创建文件夹记录并更新祖先的统计，不做权限检查
+// 名称不合法时返回 ErrInvalidName，同名时返回 ErrNameExists
+func (fs *fileService) createFolder(ownerID uint, name string, parentID *string) (*model.File, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
 	}
-
-	// 创建记录
 	newFolder := &model.File{
 		ID:          GenerateUUID(),
-		UserID:      userID,
+		UserID:      ownerID,
 		Name:        name,
 		ParentID:    parentID,
 		IsDir:       true,
@@ -255,127 +280,94 @@ func (fs *fileService) CreateFolder(userID uint, name string, parentID *string)
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
-
-	if err := fs.fileDao.CreateFile(newFolder); err != nil {
-		return fmt.Errorf("failed to create folder: %w", err)
-	}
-	return nil
-}
-
-// BatchMoveFiles 批量移动
-func (fs *fileService) BatchMoveFiles(userID uint, fileIDs []string, targetParentID string) error {
-	// 验证目标文件夹是否存在且合法
-	if targetParentID != "" {
-		targetFolder, err := fs.fileDao.GetFileMetaByFileID(targetParentID)
-		if err != nil || targetFolder == nil {
-			return errors.New("目标文件夹不存在")
-		}
-		if !targetFolder.IsDir {
-			return errors.New("目标路径不是文件夹")
-		}
-		if targetFolder.UserID != userID {
-			return errors.New("没有目标文件夹的访问权限")
+	if err := writeChange(fs.tx, model.ChangeCreate, newFolder, func(tx *dao.Tx) error {
+		if err := tx.Files.CreateFile(newFolder); err != nil {
+			return err
 		}
+		return newFolderStats(tx.Files).apply(parentID, statsOf(newFolder))
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create folder: %w", err)
 	}
-	// 获取目标文件夹下的所有文件，用于检查同名文件
-	var targetParentIDPtr *string
-	if targetParentID != "" {
-		targetParentIDPtr = &targetParentID
-	}
-	existingFiles, err := fs.fileDao.GetFilesByParentID(userID, targetParentIDPtr)
-	if err != nil {
-		return fmt.Errorf("获取目标文件夹内容失败: %w", err)
-	}
-	existingNames := make(map[string]bool)
-	for _, file := range existingFiles {
-		existingNames[file.Name] = true
-	}
-
-	// 处理每个要移动的文件
-	for _, fileID := range fileIDs {
-		file, err := fs.fileDao.GetFileMetaByFileID(fileID)
-		if err != nil {
-			return fmt.Errorf("获取文件信息失败: %w", err)
-		}
-
-		// 权限检查
-		if file.UserID != userID {
-			return errors.New("没有文件的访问权限")
-		}
-
-		// 检查是否将文件夹移动到其子文件夹中
-		if file.IsDir && targetParentID != "" {
-			if err := fs.checkCircularReference(fileID, targetParentID); err != nil {
-				return err
-			}
-		}
-
-		// 处理同名文件
-		originalName := file.Name
-		newName := originalName
-		counter := 1
-		for existingNames[newName] {
-			ext := filepath.Ext(originalName)
-			nameWithoutExt := originalName[:len(originalName)-len(ext)]
-			if ext == "" { // 对于文件夹
-				newName = fmt.Sprintf("%s (%d)", nameWithoutExt, counter)
-			} else { // 对于文件
-				newName = fmt.Sprintf("%s (%d)%s", nameWithoutExt, counter, ext)
-			}
-			counter++
-		}
-		// 更新文件信息
-		file.Name = newName
-		file.ParentID = targetParentIDPtr
-		file.UpdatedAt = time.Now()
+	return newFolder, nil
+}
 
-		if err := fs.fileDao.UpdateFile(file); err != nil {
-			return fmt.Errorf("更新文件信息失败: %w", err)
+// uniqueName 在 existing 中已有同名项时生成 "name (1).ext" 形式的新名称
+func uniqueName(originalName string, existing map[string]bool) string {
+	newName := originalName
+	counter := 1
+	for existing[newName] {
+		ext := filepath.Ext(originalName)
+		nameWithoutExt := originalName[:len(originalName)-len(ext)]
+		if ext == "" { // 对于文件夹
+			newName = fmt.Sprintf("%s (%d)", nameWithoutExt, counter)
+		} else { // 对于文件
+			newName = fmt.Sprintf("%s (%d)%s", nameWithoutExt, counter, ext)
 		}
-
-		existingNames[newName] = true
+		counter++
 	}
-	return nil
+	return newName
 }
 
-func (fs *fileService) checkCircularReference(sourceID, targetParentID string) error {
-	current := targetParentID
-	visited := make(map[string]bool)
-
-	for current != "" {
-		if current == sourceID {
-			return errors.New("不能将文件夹移动到其子文件夹中")
-		}
-
-		if visited[current] {
-			return errors.New("检测到文件夹循环引用")
-		}
-		visited[current] = true
-
-		folder, err := fs.fileDao.GetFileMetaByFileID(current)
-		if err != nil {
-			return fmt.Errorf("获取文件夹信息失败: %w", err)
-		}
-
-		if folder.ParentID == nil {
-			break
-		}
-		current = *folder.ParentID
+// checkCircularReference 检查 target 是否为 source 自身或其后代，只需比较祖先路径
+func checkCircularReference(source *model.File, target *model.File) error {
+	if target.ID == source.ID || strings.HasPrefix(target.Path, model.ChildPath(source)) {
+		return errors.New("不能将文件夹移动到其子文件夹中")
 	}
 	return nil
 }
 
-func NewFileService(fileDao dao.FileDao) FileService {
-	cfg := config.AppConfigInstance.Storage
-	driver, err := storage.NewDriver(cfg)
-	if err != nil {
-		log.Printf("Failed to initialize storage driver: %v", err)
-		return nil
-	}
-	return &fileService{
-		fileDao:       fileDao,
-		storageDriver: driver,
-	}
+func NewFileService(fileDao dao.FileDao, blobDao dao.BlobDao, trashDao dao.TrashDao, versionDao dao.VersionDao,
+	ingest IngestService, jobs JobQueue, tx dao.Transactor, permissions PermissionService, quota QuotaService, driver storage.Driver) FileService {
+	copyThreshold := config.AppConfigInstance.Copy.AsyncThreshold
+	if copyThreshold <= 0 {
+		copyThreshold = defaultCopyAsyncThreshold
+	}
+	deleteThreshold := config.AppConfigInstance.Trash.AsyncThreshold
+	if deleteThreshold <= 0 {
+		deleteThreshold = defaultDeleteAsyncThreshold
+	}
+	archiveCfg := config.AppConfigInstance.Archive
+	archiveMaxSize := archiveCfg.MaxSize
+	if archiveMaxSize <= 0 {
+		archiveMaxSize = defaultArchiveMaxSize
+	}
+	limits := extractLimits{
+		maxEntries: archiveCfg.ExtractMaxEntries,
+		maxSize:    archiveCfg.ExtractMaxSize,
+		maxRatio:   archiveCfg.ExtractMaxRatio,
+	}
+	if limits.maxEntries <= 0 {
+		limits.maxEntries = defaultExtractMaxEntries
+	}
+	if limits.maxSize <= 0 {
+		limits.maxSize = defaultExtractMaxSize
+	}
+	if limits.maxRatio <= 0 {
+		limits.maxRatio = defaultExtractMaxRatio
+	}
+	blobs := newBlobStore(blobDao, driver)
+	fs := &fileService{
+		fileDao:         fileDao,
+		trashDao:        trashDao,
+		tx:              tx,
+		ingest:          ingest,
+		jobs:            jobs,
+		permissions:     permissions,
+		quota:           quota,
+		storageDriver:   driver,
+		blobs:           blobs,
+		committer:       newFileCommitter(fileDao, versionDao, tx, ingest, blobs, quota),
+		copyThreshold:   copyThreshold,
+		deleteThreshold: deleteThreshold,
+		archiveMaxSize:  archiveMaxSize,
+		extractLimits:   limits,
+	}
+	jobs.Register(model.JobTypeCopy, 0, fs.runCopyJob)
+	jobs.Register(model.JobTypeDelete, 0, fs.runDeleteJob)
+	// 解压出的条目不会回滚，重试会产生重复内容
+	jobs.Register(model.JobTypeExtract, 1, fs.runExtractJob)
+	jobs.OnAbandon(model.JobTypeExtract, fs.abandonExtractJob)
+	return fs
 }
 
 func GenerateUUID() string {
@@ -385,3 +377,22 @@ func GenerateUUID() string {
 func GenerateStorageKey(userID uint, fileID string) string {
 	return fmt.Sprintf("user%d-%s", userID, GenerateUUID())
 }
+
+// newFileFromBlob 构造引用指定存储对象的文件记录
+func newFileFromBlob(ownerID uint, uploaderID uint, name string, parentID *string, blob *model.Blob) *model.File {
+	return &model.File{
+		ID:          GenerateUUID(),
+		UserID:      ownerID,
+		Name:        name,
+		Size:        blob.Size,
+		Hash:        blob.Hash,
+		MIMEType:    mime.TypeByExtension(filepath.Ext(name)),
+		ParentID:    parentID,
+		StorageType: blob.StorageType,
+		StorageKey:  blob.StorageKey,
+		Version:     1,
+		UploaderID:  uploaderID,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
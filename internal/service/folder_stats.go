@@ -0,0 +1,107 @@
+package service
+
+import (
+	"fmt"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"sort"
+)
+
+// folderStats 维护文件夹的子树聚合统计（总大小、文件数、文件夹数）
+// 文件增删、移动或内容变化时，将条目的贡献增量累加到其所在文件夹及全部祖先上
+type folderStats struct {
+	fileDao dao.FileDao
+}
+
+func newFolderStats(fileDao dao.FileDao) *folderStats {
+	return &folderStats{fileDao: fileDao}
+}
+
+// apply 将 delta 累加到 parentID 及其全部祖先文件夹，parentID 为 nil（根目录）时无需更新
+// 应在修改条目的同一事务中调用，失败时返回错误使事务回滚，统计不会与文件树不一致
+func (st *folderStats) apply(parentID *string, delta model.FolderStats) error {
+	if parentID == nil || delta == (model.FolderStats{}) {
+		return nil
+	}
+	ids, err := st.ancestors(*parentID)
+	if err != nil {
+		return fmt.Errorf("获取祖先文件夹失败: %w", err)
+	}
+	if err := st.fileDao.AddFolderStats(ids, delta); err != nil {
+		return fmt.Errorf("更新文件夹统计失败: %w", err)
+	}
+	return nil
+}
+
+// ancestors 返回 folderID 及其全部祖先文件夹的ID
+func (st *folderStats) ancestors(folderID string) ([]string, error) {
+	folder, err := st.fileDao.GetFileMetaByFileID(folderID)
+	if err != nil {
+		return nil, err
+	}
+	if folder == nil {
+		return nil, nil
+	}
+	return append(model.AncestorIDs(folder.Path), folder.ID), nil
+}
+
+// contentStats 返回条目包含的内容：文件夹为其子树统计（不含自身），文件为其本身
+func contentStats(file *model.File) model.FolderStats {
+	if file.IsDir {
+		return model.FolderStats{Size: file.TreeSize, FileCount: file.FileCount, FolderCount: file.FolderCount}
+	}
+	return model.FolderStats{Size: file.Size, FileCount: 1}
+}
+
+// statsOf 返回条目对所在文件夹统计的贡献，文件夹需计入自身
+func statsOf(file *model.File) model.FolderStats {
+	s := contentStats(file)
+	if file.IsDir {
+		s.FolderCount++
+	}
+	return s
+}
+
+func negateStats(s model.FolderStats) model.FolderStats {
+	return model.FolderStats{Size: -s.Size, FileCount: -s.FileCount, FolderCount: -s.FolderCount}
+}
+
+// GetFolderStats 获取文件夹的子树统计及其直接子项的占用情况，parentID 为 nil 时统计用户的根目录
+func (fs *fileService) GetFolderStats(userID uint, parentID *string) (*model.FolderStatsResp, error) {
+	folder, err := fs.permissions.AuthorizeFolder(userID, parentID, model.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	children, err := fs.fileDao.GetFilesByParentID(folderOwner(userID, folder), parentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件列表失败: %w", err)
+	}
+
+	resp := &model.FolderStatsResp{Name: "root", Children: make([]model.ChildStatsResp, 0, len(children))}
+	for i := range children {
+		resp.Children = append(resp.Children, model.ChildStatsResp{
+			FileID:      children[i].ID,
+			Name:        children[i].Name,
+			IsDir:       children[i].IsDir,
+			FolderStats: contentStats(&children[i]),
+		})
+	}
+	sort.SliceStable(resp.Children, func(i, j int) bool {
+		return resp.Children[i].Size > resp.Children[j].Size
+	})
+
+	if folder != nil {
+		resp.FileID = folder.ID
+		resp.Name = folder.Name
+		resp.FolderStats = contentStats(folder)
+		return resp, nil
+	}
+	// 根目录没有对应的记录，由顶层条目汇总
+	for i := range children {
+		s := statsOf(&children[i])
+		resp.Size += s.Size
+		resp.FileCount += s.FileCount
+		resp.FolderCount += s.FolderCount
+	}
+	return resp, nil
+}
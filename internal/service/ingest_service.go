@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"llmcloud/internal/rag"
+	"llmcloud/internal/storage"
+	"log"
+)
+
+// IngestService 为文件建立检索索引：内容变化后提交后台任务，依次提取文本、切分、嵌入并写入向量库
+type IngestService interface {
+	// Schedule 在文件内容写入后调用，为当前版本排队建立索引；不支持的文件类型只清除旧索引
+	Schedule(file *model.File)
+	// Remove 删除文件的全部索引，在文件被彻底删除时调用
+	Remove(ctx context.Context, fileID string)
+}
+
+// ingestPayload 建立索引任务的参数；Version 用于识别已被更新版本取代的任务
+type ingestPayload struct {
+	FileID  string `json:"file_id"`
+	Version int    `json:"version"`
+}
+
+type ingestService struct {
+	fileDao       dao.FileDao
+	jobs          JobQueue
+	storageDriver storage.Driver
+	pipeline      *rag.Pipeline
+	enabled       bool
+}
+
+func (is *ingestService) Schedule(file *model.File) {
+	if !is.enabled || file.IsDir {
+		return
+	}
+	if !is.pipeline.Supports(file.Name, file.MIMEType) {
+		// 新内容不再需要索引时，清除旧版本留下的记录
+		if file.IngestStatus != "" {
+			is.Remove(context.Background(), file.ID)
+			is.setStatus(file.ID, "", "")
+		}
+		return
+	}
+	is.setStatus(file.ID, model.IngestPending, "")
+	if _, err := is.jobs.Enqueue(file.UserID, model.JobTypeIngest, ingestPayload{FileID: file.ID, Version: file.Version}, 0); err != nil {
+		log.Printf("提交索引任务失败(%s): %v", file.ID, err)
+		is.setStatus(file.ID, model.IngestFailed, err.Error())
+	}
+}
+
+func (is *ingestService) Remove(ctx context.Context, fileID string) {
+	if !is.enabled {
+		return
+	}
+	if err := is.pipeline.Remove(ctx, fileID); err != nil {
+		log.Printf("删除文件索引失败(%s): %v", fileID, err)
+	}
+}
+
+// run 执行建立索引任务；失败时记录原因，由任务队列决定是否重试
+func (is *ingestService) run(ctx context.Context, job *model.Job, progress *JobProgress) error {
+	var payload ingestPayload
+	if err := decodePayload(job, &payload); err != nil {
+		return err
+	}
+	file, err := is.fileDao.GetFileMetaByFileID(payload.FileID)
+	if err != nil {
+		return fmt.Errorf("获取文件信息失败: %w", err)
+	}
+	// 文件已删除，或内容已更新（新版本另有任务）
+	if file == nil || file.Version != payload.Version {
+		return nil
+	}
+	progress.SetTotal(1)
+	is.setStatus(file.ID, model.IngestIndexing, "")
+	chunks, err := is.ingest(ctx, file)
+	if errors.Is(err, rag.ErrSuperseded) {
+		// 状态由新版本的任务维护
+		return nil
+	}
+	if err != nil {
+		if !jobStopping(ctx) {
+			is.setStatus(file.ID, model.IngestFailed, err.Error())
+		}
+		if errors.Is(err, rag.ErrUnsupported) || errors.Is(err, rag.ErrTooLarge) {
+			return permanent(err)
+		}
+		return err
+	}
+	is.setStatus(file.ID, model.IngestIndexed, "")
+	progress.Advance(1)
+	log.Printf("文件 %s 已建立索引，共 %d 个片段", file.ID, chunks)
+	return nil
+}
+
+func (is *ingestService) ingest(ctx context.Context, file *model.File) (int, error) {
+	reader, err := is.storageDriver.Download(ctx, file.StorageKey)
+	if err != nil {
+		return 0, fmt.Errorf("读取文件失败: %w", err)
+	}
+	defer reader.Close()
+	return is.pipeline.Ingest(ctx, rag.Document{
+		FileID:   file.ID,
+		UserID:   file.UserID,
+		Name:     file.Name,
+		MIMEType: file.MIMEType,
+		Current: func() (bool, error) {
+			latest, err := is.fileDao.GetFileMetaByFileID(file.ID)
+			if err != nil {
+				return false, fmt.Errorf("获取文件信息失败: %w", err)
+			}
+			return latest != nil && latest.Version == file.Version, nil
+		},
+	}, reader)
+}
+
+func (is *ingestService) setStatus(fileID string, status string, errMsg string) {
+	if err := is.fileDao.UpdateIngestStatus(fileID, status, errMsg); err != nil {
+		log.Printf("更新索引状态失败(%s): %v", fileID, err)
+	}
+}
+
+func NewIngestService(fileDao dao.FileDao, jobs JobQueue, driver storage.Driver, pipeline *rag.Pipeline) IngestService {
+	is := &ingestService{
+		fileDao:       fileDao,
+		jobs:          jobs,
+		storageDriver: driver,
+		pipeline:      pipeline,
+		enabled:       config.AppConfigInstance.RAG.Enabled,
+	}
+	jobs.Register(model.JobTypeIngest, 0, is.run)
+	return is
+}
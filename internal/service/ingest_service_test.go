@@ -0,0 +1,238 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"llmcloud/internal/rag"
+	"llmcloud/internal/storage"
+	"reflect"
+	"testing"
+)
+
+// ingestFiles 记录索引状态变化的文件表，未实现的方法调用时 panic
+type ingestFiles struct {
+	dao.FileDao
+	files    map[string]*model.File
+	statuses []string
+}
+
+func (f *ingestFiles) GetFileMetaByFileID(id string) (*model.File, error) {
+	return f.files[id], nil
+}
+
+func (f *ingestFiles) UpdateIngestStatus(id string, status string, errMsg string) error {
+	f.statuses = append(f.statuses, status)
+	if file := f.files[id]; file != nil {
+		file.IngestStatus, file.IngestError = status, errMsg
+	}
+	return nil
+}
+
+// ingestObjects 保存在内存中的存储对象
+type ingestObjects struct {
+	storage.Driver
+	objects map[string][]byte
+}
+
+func (d *ingestObjects) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := d.objects[key]
+	if !ok {
+		return nil, errors.New("object not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// ingestJobs 只记录提交的任务，由测试直接调用处理函数
+type ingestJobs struct {
+	handlers map[string]JobHandler
+	queued   []*model.Job
+}
+
+func (q *ingestJobs) Register(jobType string, maxAttempts int, handler JobHandler) {
+	q.handlers[jobType] = handler
+}
+
+func (q *ingestJobs) OnAbandon(jobType string, cleanup func(job *model.Job)) {}
+
+func (q *ingestJobs) Enqueue(userID uint, jobType string, payload interface{}, total int64) (*model.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := &model.Job{ID: GenerateUUID(), UserID: userID, Type: jobType, Payload: string(data), Attempts: 1}
+	q.queued = append(q.queued, job)
+	return job, nil
+}
+
+func (q *ingestJobs) Run(ctx context.Context) {}
+
+// progressJobs 忽略进度更新
+type progressJobs struct {
+	dao.JobDao
+}
+
+func (progressJobs) UpdateProgress(id string, done int64, total int64) error { return nil }
+
+type ingestFixture struct {
+	files   *ingestFiles
+	objects *ingestObjects
+	jobs    *ingestJobs
+	store   *rag.MemoryStore
+	service IngestService
+}
+
+func newIngestFixture(t *testing.T) *ingestFixture {
+	t.Helper()
+	previous := config.AppConfigInstance
+	config.AppConfigInstance = &config.AppConfig{RAG: config.RAGConfig{Enabled: true}}
+	t.Cleanup(func() { config.AppConfigInstance = previous })
+
+	embedder := rag.NewHashEmbedder(64)
+	store, err := rag.NewMemoryStore(config.MemoryStoreConfig{Index: "flat"}, embedder.Dimension())
+	if err != nil {
+		t.Fatalf("NewMemoryStore: %v", err)
+	}
+	pipeline := &rag.Pipeline{
+		Extractor: rag.NewTextExtractor(0),
+		Chunker:   rag.NewTextChunker(60, 10),
+		Embedder:  embedder,
+		Store:     store,
+	}
+	fx := &ingestFixture{
+		files:   &ingestFiles{files: make(map[string]*model.File)},
+		objects: &ingestObjects{objects: make(map[string][]byte)},
+		jobs:    &ingestJobs{handlers: make(map[string]JobHandler)},
+		store:   store,
+	}
+	fx.service = NewIngestService(fx.files, fx.jobs, fx.objects, pipeline)
+	return fx
+}
+
+// addFile 登记一个内容为 content 的文件
+func (fx *ingestFixture) addFile(name string, content string) *model.File {
+	file := &model.File{ID: GenerateUUID(), UserID: 1, Name: name, Version: 1, StorageKey: "key-" + name}
+	fx.files.files[file.ID] = file
+	fx.objects.objects[file.StorageKey] = []byte(content)
+	return file
+}
+
+// runQueued 依次执行已提交的索引任务，返回各任务的结果
+func (fx *ingestFixture) runQueued(t *testing.T) []error {
+	t.Helper()
+	handler := fx.jobs.handlers[model.JobTypeIngest]
+	if handler == nil {
+		t.Fatal("ingest handler not registered")
+	}
+	var errs []error
+	for _, job := range fx.jobs.queued {
+		progress := &JobProgress{jobDao: progressJobs{}, jobID: job.ID}
+		errs = append(errs, handler(context.Background(), job, progress))
+	}
+	fx.jobs.queued = nil
+	return errs
+}
+
+func (fx *ingestFixture) records(t *testing.T, fileID string) int {
+	t.Helper()
+	hits, err := fx.store.Search(context.Background(), make([]float32, 64), 1000, rag.Filter{FileIDs: []string{fileID}})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	return len(hits)
+}
+
+func TestIngestIndexesFile(t *testing.T) {
+	fx := newIngestFixture(t)
+	file := fx.addFile("notes.md", "第一段内容，介绍文件存储。\n\nThe second paragraph talks about vector search and chunking.\n\n第三段。")
+
+	fx.service.Schedule(file)
+	if len(fx.jobs.queued) != 1 {
+		t.Fatalf("queued %d jobs, want 1", len(fx.jobs.queued))
+	}
+	if errs := fx.runQueued(t); errs[0] != nil {
+		t.Fatalf("ingest job: %v", errs[0])
+	}
+	want := []string{model.IngestPending, model.IngestIndexing, model.IngestIndexed}
+	if !reflect.DeepEqual(fx.files.statuses, want) {
+		t.Fatalf("statuses = %v, want %v", fx.files.statuses, want)
+	}
+	if n := fx.records(t, file.ID); n == 0 {
+		t.Fatal("no records written to the vector store")
+	}
+}
+
+func TestIngestMarksFailure(t *testing.T) {
+	fx := newIngestFixture(t)
+	file := fx.addFile("data.txt", "\xff\xfe\x00 not utf-8")
+
+	fx.service.Schedule(file)
+	errs := fx.runQueued(t)
+	if !errors.Is(errs[0], rag.ErrUnsupported) || !isPermanent(errs[0]) {
+		t.Fatalf("ingest job err = %v, want permanent ErrUnsupported", errs[0])
+	}
+	want := []string{model.IngestPending, model.IngestIndexing, model.IngestFailed}
+	if !reflect.DeepEqual(fx.files.statuses, want) {
+		t.Fatalf("statuses = %v, want %v", fx.files.statuses, want)
+	}
+	if file.IngestError == "" {
+		t.Error("failure reason not recorded")
+	}
+}
+
+func TestIngestRetriesStorageErrors(t *testing.T) {
+	fx := newIngestFixture(t)
+	file := fx.addFile("notes.txt", "content")
+	delete(fx.objects.objects, file.StorageKey)
+
+	fx.service.Schedule(file)
+	errs := fx.runQueued(t)
+	if errs[0] == nil || isPermanent(errs[0]) {
+		t.Fatalf("ingest job err = %v, want a retryable error", errs[0])
+	}
+	if got := fx.files.statuses[len(fx.files.statuses)-1]; got != model.IngestFailed {
+		t.Fatalf("final status = %s, want %s", got, model.IngestFailed)
+	}
+}
+
+func TestIngestSkipsSupersededVersion(t *testing.T) {
+	fx := newIngestFixture(t)
+	file := fx.addFile("notes.txt", "old content")
+
+	fx.service.Schedule(file)
+	file.Version++ // 任务执行前内容已更新，由新版本的任务建立索引
+	if errs := fx.runQueued(t); errs[0] != nil {
+		t.Fatalf("ingest job: %v", errs[0])
+	}
+	if want := []string{model.IngestPending}; !reflect.DeepEqual(fx.files.statuses, want) {
+		t.Fatalf("statuses = %v, want %v", fx.files.statuses, want)
+	}
+	if n := fx.records(t, file.ID); n != 0 {
+		t.Fatalf("superseded version wrote %d records", n)
+	}
+}
+
+func TestIngestUnsupportedTypeClearsIndex(t *testing.T) {
+	fx := newIngestFixture(t)
+	file := fx.addFile("notes.txt", "indexed text")
+	fx.service.Schedule(file)
+	fx.runQueued(t)
+
+	// 新内容不再支持建立索引：不提交任务，并清除旧记录与状态
+	file.Name, file.Version = "notes.png", file.Version+1
+	fx.service.Schedule(file)
+	if len(fx.jobs.queued) != 0 {
+		t.Fatalf("queued %d jobs for an unsupported file", len(fx.jobs.queued))
+	}
+	if file.IngestStatus != "" {
+		t.Fatalf("status = %q, want cleared", file.IngestStatus)
+	}
+	if n := fx.records(t, file.ID); n != 0 {
+		t.Fatalf("stale index still has %d records", n)
+	}
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultJobWorkers      = 4
+	defaultJobPollInterval = 2 * time.Second
+	defaultJobMaxAttempts  = 3
+	defaultJobRetryBackoff = 10 * time.Second
+	defaultJobStaleTimeout = time.Minute
+	maxJobRetryBackoff     = 30 * time.Minute
+	jobHeartbeatInterval   = 2 * time.Second
+	jobProgressInterval    = time.Second // 进度最多每隔该时间写一次库
+)
+
+var errJobCanceled = errors.New("任务已取消")
+
+// JobHandler 执行一个任务；ctx 在用户取消任务或服务停止时结束，返回的错误决定任务是否重试
+// 任务可能被重复执行（重试、实例中断后重新领取），处理函数应能从头安全地再执行一次
+type JobHandler func(ctx context.Context, job *model.Job, progress *JobProgress) error
+
+// JobQueue 基于数据库的后台任务队列：任务先持久化，再由工作协程领取执行
+type JobQueue interface {
+	// Register 注册任务类型的处理函数，maxAttempts <= 0 时使用配置的默认值
+	// maxAttempts 为 1 的任务不可重复执行：服务停止或实例中断后不再重新执行，直接标记为失败
+	Register(jobType string, maxAttempts int, handler JobHandler)
+	// OnAbandon 注册任务被放弃时的清理函数：实例中断后任务因执行次数用完被标记为失败时，
+	// 处理函数没有机会清理自己占用的资源（如暂存文件），由回收中断任务的实例调用
+	OnAbandon(jobType string, cleanup func(job *model.Job))
+	// Enqueue 创建任务，payload 序列化为 JSON 保存，total 为预计处理的条目数（未知时为 0）
+	Enqueue(userID uint, jobType string, payload interface{}, total int64) (*model.Job, error)
+	// Run 启动工作协程并周期性回收中断的任务，直到 ctx 结束
+	Run(ctx context.Context)
+}
+
+// permanentError 不应重试的失败，如参数错误或已部分生效的操作
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// permanent 将错误标记为不重试
+func permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// isPermanent 判断失败是否不必重试：显式标记的错误，以及重试也无法改变结果的业务错误
+func isPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe) ||
+		errors.Is(err, ErrFileNotFound) ||
+		errors.Is(err, ErrPermissionDenied) ||
+		errors.Is(err, ErrQuotaExceeded) ||
+		errors.Is(err, ErrNameExists) ||
+		errors.Is(err, ErrConflictType)
+}
+
+// jobStopping 判断任务的 ctx 是否因服务停止而结束，此时任务会被归还并在之后重新执行
+func jobStopping(ctx context.Context) bool {
+	return ctx.Err() != nil && !errors.Is(context.Cause(ctx), errJobCanceled)
+}
+
+// decodePayload 解析任务参数，参数无法解析时不再重试
+func decodePayload(job *model.Job, v interface{}) error {
+	if err := json.Unmarshal([]byte(job.Payload), v); err != nil {
+		return permanent(fmt.Errorf("解析任务参数失败: %w", err))
+	}
+	return nil
+}
+
+// JobProgress 记录任务进度，写库按时间节流，任务结束时写入最终值
+type JobProgress struct {
+	jobDao  dao.JobDao
+	jobID   string
+	mu      sync.Mutex
+	done    int64
+	total   int64
+	flushed time.Time
+}
+
+// SetTotal 设置需要处理的条目数，每次执行从零开始计数
+func (p *JobProgress) SetTotal(total int64) {
+	p.mu.Lock()
+	p.total = total
+	p.mu.Unlock()
+	p.flush(false)
+}
+
+// Advance 记录又处理了 n 个条目
+func (p *JobProgress) Advance(n int64) {
+	p.mu.Lock()
+	p.done += n
+	if p.done > p.total {
+		p.total = p.done
+	}
+	p.mu.Unlock()
+	p.flush(false)
+}
+
+func (p *JobProgress) flush(force bool) {
+	p.mu.Lock()
+	if !force && time.Since(p.flushed) < jobProgressInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.flushed = time.Now()
+	done, total := p.done, p.total
+	p.mu.Unlock()
+	if err := p.jobDao.UpdateProgress(p.jobID, done, total); err != nil {
+		log.Printf("更新任务进度失败(%s): %v", p.jobID, err)
+	}
+}
+
+type jobRegistration struct {
+	handler     JobHandler
+	maxAttempts int
+	abandon     func(job *model.Job)
+}
+
+type jobQueue struct {
+	jobDao       dao.JobDao
+	mu           sync.RWMutex
+	handlers     map[string]jobRegistration
+	wake         chan struct{}
+	instanceID   string
+	workers      int
+	pollInterval time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+	staleTimeout time.Duration
+}
+
+func (q *jobQueue) Register(jobType string, maxAttempts int, handler JobHandler) {
+	if maxAttempts <= 0 {
+		maxAttempts = q.maxAttempts
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = jobRegistration{handler: handler, maxAttempts: maxAttempts, abandon: q.handlers[jobType].abandon}
+}
+
+func (q *jobQueue) OnAbandon(jobType string, cleanup func(job *model.Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	reg := q.handlers[jobType]
+	reg.abandon = cleanup
+	q.handlers[jobType] = reg
+}
+
+func (q *jobQueue) Enqueue(userID uint, jobType string, payload interface{}, total int64) (*model.Job, error) {
+	q.mu.RLock()
+	reg, ok := q.handlers[jobType]
+	q.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未注册的任务类型: %s", jobType)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务参数失败: %w", err)
+	}
+	now := time.Now()
+	job := &model.Job{
+		ID:          GenerateUUID(),
+		UserID:      userID,
+		Type:        jobType,
+		Status:      model.JobStatusPending,
+		Payload:     string(data),
+		Total:       total,
+		MaxAttempts: reg.maxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+	}
+	if err := q.jobDao.CreateJob(job); err != nil {
+		return nil, fmt.Errorf("创建任务失败: %w", err)
+	}
+	// 唤醒空闲的工作协程，无需等到下次轮询
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (q *jobQueue) Run(ctx context.Context) {
+	q.requeueStale()
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func(workerID string) {
+			defer wg.Done()
+			q.work(ctx, workerID)
+		}(fmt.Sprintf("%s-%d", q.instanceID, i))
+	}
+	ticker := time.NewTicker(q.staleTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+			q.requeueStale()
+		}
+	}
+}
+
+// requeueStale 回收心跳超时的任务，通常是执行它的实例已经退出
+// 执行次数已用完的任务不再重新执行，调用其清理函数
+func (q *jobQueue) requeueStale() {
+	n, failed, err := q.jobDao.RequeueStale(time.Now().Add(-q.staleTimeout))
+	if err != nil {
+		log.Printf("回收中断的任务失败: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("已将 %d 个中断的任务重新放回队列", n)
+	}
+	for i := range failed {
+		job := &failed[i]
+		log.Printf("中断的任务已达到最大执行次数，不再重试(%s)", job.ID)
+		q.mu.RLock()
+		cleanup := q.handlers[job.Type].abandon
+		q.mu.RUnlock()
+		if cleanup != nil {
+			cleanup(job)
+		}
+	}
+}
+
+// work 循环领取并执行任务，没有任务时等待新任务唤醒或下次轮询
+func (q *jobQueue) work(ctx context.Context, workerID string) {
+	for ctx.Err() == nil {
+		job, err := q.jobDao.ClaimJob(workerID, time.Now())
+		if err != nil {
+			log.Printf("领取任务失败: %v", err)
+		}
+		if job != nil {
+			q.execute(ctx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-q.wake:
+		case <-time.After(q.pollInterval):
+		}
+	}
+}
+
+// execute 执行一个已领取的任务，并根据结果结束、重试或归还任务
+func (q *jobQueue) execute(ctx context.Context, job *model.Job) {
+	q.mu.RLock()
+	reg, ok := q.handlers[job.Type]
+	q.mu.RUnlock()
+	if !ok {
+		q.finish(job, model.JobStatusFailed, fmt.Sprintf("未注册的任务类型: %s", job.Type))
+		return
+	}
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	stop := make(chan struct{})
+	go q.watch(job.ID, cancel, stop)
+
+	progress := &JobProgress{jobDao: q.jobDao, jobID: job.ID, total: job.Total}
+	err := q.invoke(jobCtx, reg.handler, job, progress)
+	close(stop)
+	progress.flush(true)
+
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = reg.maxAttempts
+	}
+	switch {
+	case err == nil:
+		q.finish(job, model.JobStatusSucceeded, "")
+	case errors.Is(context.Cause(jobCtx), errJobCanceled):
+		q.finish(job, model.JobStatusCanceled, errJobCanceled.Error())
+	case jobStopping(jobCtx) && maxAttempts == 1:
+		// 不可重复执行的任务已部分生效，不能交给下次启动重新执行
+		q.finish(job, model.JobStatusFailed, "服务停止，任务执行中断")
+	case jobStopping(jobCtx):
+		// 服务停止，任务交给下次启动（或其他实例）继续执行，本次不计入执行次数
+		if err := q.jobDao.ReleaseJob(job.ID); err != nil {
+			log.Printf("归还任务失败(%s): %v", job.ID, err)
+		}
+	case isPermanent(err) || job.Attempts >= maxAttempts:
+		q.finish(job, model.JobStatusFailed, err.Error())
+	default:
+		runAt := time.Now().Add(q.backoff(job.Attempts))
+		if err := q.jobDao.RetryJob(job.ID, err.Error(), runAt); err != nil {
+			log.Printf("更新任务状态失败(%s): %v", job.ID, err)
+		}
+	}
+}
+
+// invoke 调用处理函数，处理函数 panic 时按失败处理
+func (q *jobQueue) invoke(ctx context.Context, handler JobHandler, job *model.Job, progress *JobProgress) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = permanent(fmt.Errorf("任务执行异常: %v", r))
+		}
+	}()
+	return handler(ctx, job, progress)
+}
+
+// watch 定期刷新任务心跳，发现用户请求取消时以 errJobCanceled 结束任务的 ctx
+func (q *jobQueue) watch(jobID string, cancel context.CancelCauseFunc, stop <-chan struct{}) {
+	ticker := time.NewTicker(jobHeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			requested, err := q.jobDao.Heartbeat(jobID)
+			if err != nil {
+				log.Printf("更新任务心跳失败(%s): %v", jobID, err)
+				continue
+			}
+			if requested {
+				cancel(errJobCanceled)
+			}
+		}
+	}
+}
+
+func (q *jobQueue) finish(job *model.Job, status string, errMsg string) {
+	if err := q.jobDao.FinishJob(job.ID, status, errMsg); err != nil {
+		log.Printf("更新任务状态失败(%s): %v", job.ID, err)
+	}
+}
+
+// backoff 第 attempts 次执行失败后的等待时间，按指数增长并设有上限
+func (q *jobQueue) backoff(attempts int) time.Duration {
+	d := q.retryBackoff
+	for i := 1; i < attempts && d < maxJobRetryBackoff; i++ {
+		d *= 2
+	}
+	if d > maxJobRetryBackoff {
+		d = maxJobRetryBackoff
+	}
+	return d
+}
+
+func NewJobQueue(jobDao dao.JobDao) JobQueue {
+	cfg := config.AppConfigInstance.Job
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultJobWorkers
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultJobMaxAttempts
+	}
+	// 超时必须明显长于心跳间隔，否则执行中的任务会被误判为中断
+	staleTimeout := parseDuration(cfg.StaleTimeout, defaultJobStaleTimeout)
+	if staleTimeout < 5*jobHeartbeatInterval {
+		staleTimeout = 5 * jobHeartbeatInterval
+	}
+	hostname, _ := os.Hostname()
+	return &jobQueue{
+		jobDao:       jobDao,
+		handlers:     make(map[string]jobRegistration),
+		wake:         make(chan struct{}, workers),
+		instanceID:   fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		workers:      workers,
+		pollInterval: parseDuration(cfg.PollInterval, defaultJobPollInterval),
+		maxAttempts:  maxAttempts,
+		retryBackoff: parseDuration(cfg.RetryBackoff, defaultJobRetryBackoff),
+		staleTimeout: staleTimeout,
+	}
+}
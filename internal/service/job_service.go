@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"math"
+)
+
+var (
+	ErrJobNotFound = errors.New("任务不存在")
+	ErrJobFinished = dao.ErrJobFinished
+)
+
+// JobService 后台任务查询与取消服务
+type JobService interface {
+	GetJob(userID uint, jobID string) (*model.JobResp, error)
+	ListJobs(userID uint, status string, page int, pageSize int) (int64, []*model.JobResp, error)
+	CancelJob(userID uint, jobID string) (*model.JobResp, error)
+}
+
+type jobService struct {
+	jobDao dao.JobDao
+}
+
+// GetJob 查询用户自己发起的任务进度
+func (js *jobService) GetJob(userID uint, jobID string) (*model.JobResp, error) {
+	job, err := js.jobDao.GetJob(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	if job == nil || job.UserID != userID {
+		return nil, ErrJobNotFound
+	}
+	return buildJobResp(job), nil
+}
+
+// ListJobs 按创建时间倒序分页列出用户的任务，status 为空时列出全部
+func (js *jobService) ListJobs(userID uint, status string, page int, pageSize int) (int64, []*model.JobResp, error) {
+	total, err := js.jobDao.CountJobs(userID, status)
+	if err != nil {
+		return 0, nil, err
+	}
+	jobs, err := js.jobDao.ListJobs(userID, status, page, pageSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp := make([]*model.JobResp, len(jobs))
+	for i := range jobs {
+		resp[i] = buildJobResp(&jobs[i])
+	}
+	return total, resp, nil
+}
+
+// CancelJob 取消用户自己的任务：等待中的任务立即取消，执行中的任务在工作协程下次心跳时停止
+func (js *jobService) CancelJob(userID uint, jobID string) (*model.JobResp, error) {
+	job, err := js.jobDao.GetJob(jobID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	if job == nil || job.UserID != userID {
+		return nil, ErrJobNotFound
+	}
+	job, err = js.jobDao.CancelJob(jobID)
+	if err != nil {
+		if errors.Is(err, ErrJobFinished) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("取消任务失败: %w", err)
+	}
+	return buildJobResp(job), nil
+}
+
+func buildJobResp(job *model.Job) *model.JobResp {
+	return &model.JobResp{
+		JobID:           job.ID,
+		Type:            job.Type,
+		Status:          job.Status,
+		Total:           job.Total,
+		Done:            job.Done,
+		Progress:        jobProgress(job),
+		Attempts:        job.Attempts,
+		MaxAttempts:     job.MaxAttempts,
+		CancelRequested: job.CancelRequested,
+		Error:           job.Error,
+		CreatedAt:       job.CreatedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+}
+
+// jobProgress 任务完成的百分比，保留一位小数；成功结束的任务为 100
+func jobProgress(job *model.Job) float64 {
+	if job.Status == model.JobStatusSucceeded {
+		return 100
+	}
+	if job.Total <= 0 {
+		return 0
+	}
+	done := job.Done
+	if done > job.Total {
+		done = job.Total
+	}
+	return math.Round(float64(done)*1000/float64(job.Total)) / 10
+}
+
+func NewJobService(jobDao dao.JobDao) JobService {
+	return &jobService{jobDao: jobDao}
+}
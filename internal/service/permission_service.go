@@ -0,0 +1,251 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+)
+
+var (
+	ErrFileNotFound       = errors.New("文件不存在")
+	ErrPermissionDenied   = errors.New("权限不足")
+	ErrPermissionNotFound = errors.New("授权不存在")
+	ErrGranteeNotFound    = errors.New("用户不存在")
+)
+
+// roleRank 授权级别的高低，数值大的包含数值小的全部权限
+var roleRank = map[string]int{
+	model.RoleViewer: 1,
+	model.RoleEditor: 2,
+	model.RoleOwner:  3,
+}
+
+// PermissionService 统一的文件访问控制：文件所有者拥有全部权限，
+// 其他用户的权限来自文件自身或任一祖先文件夹上的授权，取其中最高的级别
+type PermissionService interface {
+	// Authorize 校验用户对文件至少拥有 role 级别的权限，通过时返回文件元数据
+	Authorize(userID uint, fileID string, role string) (*model.File, error)
+	// AuthorizeFolder 校验用户能否以 role 级别操作目标目录，parentID 为 nil 表示用户自己的根目录，此时返回 nil
+	AuthorizeFolder(userID uint, parentID *string, role string) (*model.File, error)
+	RoleOf(userID uint, file *model.File) (string, error)
+
+	GrantPermission(userID uint, req *model.GrantPermissionReq) (*model.PermissionResp, error)
+	ListPermissions(userID uint, fileID string) ([]model.PermissionResp, error)
+	RevokePermission(userID uint, permissionID string) error
+	SharedWithMe(userID uint) ([]model.SharedFileResp, error)
+}
+
+type permissionService struct {
+	permissionDao dao.PermissionDao
+	fileDao       dao.FileDao
+	userDao       dao.UserDao
+}
+
+func (ps *permissionService) Authorize(userID uint, fileID string, role string) (*model.File, error) {
+	file, err := ps.fileDao.GetFileMetaByFileID(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %w", err)
+	}
+	if file == nil {
+		return nil, ErrFileNotFound
+	}
+	actual, err := ps.RoleOf(userID, file)
+	if err != nil {
+		return nil, err
+	}
+	// 没有任何权限时不暴露文件的存在
+	if actual == "" {
+		return nil, ErrFileNotFound
+	}
+	if roleRank[actual] < roleRank[role] {
+		return nil, ErrPermissionDenied
+	}
+	return file, nil
+}
+
+func (ps *permissionService) AuthorizeFolder(userID uint, parentID *string, role string) (*model.File, error) {
+	if parentID == nil {
+		return nil, nil
+	}
+	folder, err := ps.Authorize(userID, *parentID, role)
+	if err != nil {
+		return nil, err
+	}
+	if !folder.IsDir {
+		return nil, errors.New("目标路径不是文件夹")
+	}
+	return folder, nil
+}
+
+// RoleOf 计算用户对文件的有效权限，无权限时返回空字符串
+func (ps *permissionService) RoleOf(userID uint, file *model.File) (string, error) {
+	if file.UserID == userID {
+		return model.RoleOwner, nil
+	}
+	ancestors := append(model.AncestorIDs(file.Path), file.ID)
+	permissions, err := ps.permissionDao.ListPermissionsForGrantee(userID, ancestors)
+	if err != nil {
+		return "", fmt.Errorf("获取授权信息失败: %w", err)
+	}
+	role := ""
+	for _, p := range permissions {
+		if roleRank[p.Role] > roleRank[role] {
+			role = p.Role
+		}
+	}
+	return role, nil
+}
+
+// GrantPermission 将文件授权给指定用户，需要拥有者权限；已有授权时修改其级别
+func (ps *permissionService) GrantPermission(userID uint, req *model.GrantPermissionReq) (*model.PermissionResp, error) {
+	file, err := ps.Authorize(userID, req.FileID, model.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	grantee, err := ps.userDao.GetUserByName(req.Username)
+	if err != nil || grantee == nil {
+		return nil, ErrGranteeNotFound
+	}
+	if grantee.ID == file.UserID || grantee.ID == userID {
+		return nil, errors.New("不能授权给文件所有者或自己")
+	}
+
+	permission := &model.FilePermission{
+		ID:        GenerateUUID(),
+		FileID:    file.ID,
+		OwnerID:   file.UserID,
+		GranteeID: grantee.ID,
+		Role:      req.Role,
+		GrantedBy: userID,
+	}
+	existing, err := ps.permissionDao.ListPermissionsForGrantee(grantee.ID, []string{file.ID})
+	if err != nil {
+		return nil, fmt.Errorf("获取授权信息失败: %w", err)
+	}
+	if len(existing) > 0 {
+		permission.ID = existing[0].ID
+		permission.CreatedAt = existing[0].CreatedAt
+	}
+	if err := ps.permissionDao.SavePermission(permission); err != nil {
+		return nil, fmt.Errorf("保存授权失败: %w", err)
+	}
+	return buildPermissionResp(permission, grantee.Username), nil
+}
+
+// ListPermissions 列出直接授予在文件上的协作者
+func (ps *permissionService) ListPermissions(userID uint, fileID string) ([]model.PermissionResp, error) {
+	if _, err := ps.Authorize(userID, fileID, model.RoleViewer); err != nil {
+		return nil, err
+	}
+	permissions, err := ps.permissionDao.ListPermissionsByFile(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("获取授权信息失败: %w", err)
+	}
+	ids := make([]uint, 0, len(permissions))
+	for _, p := range permissions {
+		ids = append(ids, p.GranteeID)
+	}
+	names, err := ps.usernames(ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.PermissionResp, 0, len(permissions))
+	for i := range permissions {
+		result = append(result, *buildPermissionResp(&permissions[i], names[permissions[i].GranteeID]))
+	}
+	return result, nil
+}
+
+// RevokePermission 撤销授权：拥有者可撤销任意授权，被授权用户可退出共享
+func (ps *permissionService) RevokePermission(userID uint, permissionID string) error {
+	permission, err := ps.permissionDao.GetPermission(permissionID)
+	if err != nil {
+		return fmt.Errorf("获取授权信息失败: %w", err)
+	}
+	if permission == nil {
+		return ErrPermissionNotFound
+	}
+	if permission.GranteeID != userID {
+		if _, err := ps.Authorize(userID, permission.FileID, model.RoleOwner); err != nil {
+			if errors.Is(err, ErrFileNotFound) {
+				return ErrPermissionNotFound
+			}
+			return err
+		}
+	}
+	return ps.permissionDao.DeletePermission(permission.ID)
+}
+
+// SharedWithMe 列出其他用户直接授权给当前用户的文件，已删除的文件不会出现
+func (ps *permissionService) SharedWithMe(userID uint) ([]model.SharedFileResp, error) {
+	permissions, err := ps.permissionDao.ListPermissionsByGrantee(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取授权信息失败: %w", err)
+	}
+	ownerIDs := make([]uint, 0, len(permissions))
+	for _, p := range permissions {
+		ownerIDs = append(ownerIDs, p.OwnerID)
+	}
+	names, err := ps.usernames(ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.SharedFileResp, 0, len(permissions))
+	for _, p := range permissions {
+		file, err := ps.fileDao.GetFileMetaByFileID(p.FileID)
+		if err != nil {
+			return nil, fmt.Errorf("获取文件信息失败: %w", err)
+		}
+		if file == nil {
+			continue
+		}
+		result = append(result, model.SharedFileResp{
+			File:      *file,
+			Role:      p.Role,
+			OwnerID:   p.OwnerID,
+			OwnerName: names[p.OwnerID],
+			SharedAt:  p.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (ps *permissionService) usernames(ids []uint) (map[uint]string, error) {
+	users, err := ps.userDao.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %w", err)
+	}
+	names := make(map[uint]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.Username
+	}
+	return names, nil
+}
+
+func buildPermissionResp(permission *model.FilePermission, username string) *model.PermissionResp {
+	return &model.PermissionResp{
+		PermissionID: permission.ID,
+		FileID:       permission.FileID,
+		UserID:       permission.GranteeID,
+		Username:     username,
+		Role:         permission.Role,
+		CreatedAt:    permission.CreatedAt,
+	}
+}
+
+// folderOwner 返回目标目录所属的用户：文件总是归属于所在文件树的所有者
+func folderOwner(userID uint, folder *model.File) uint {
+	if folder == nil {
+		return userID
+	}
+	return folder.UserID
+}
+
+func NewPermissionService(permissionDao dao.PermissionDao, fileDao dao.FileDao, userDao dao.UserDao) PermissionService {
+	return &permissionService{
+		permissionDao: permissionDao,
+		fileDao:       fileDao,
+		userDao:       userDao,
+	}
+}
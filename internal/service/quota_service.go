@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"log"
+	"strings"
+)
+
+var ErrQuotaExceeded = errors.New("存储空间不足")
+
+// QuotaService 用户配额与空间统计
+// 各类写入操作在写入前调用 CheckQuota 预检，并在文件或版本增删后调用 RecordUsage 增量记账
+type QuotaService interface {
+	CheckQuota(userID uint, size int64) error
+	RecordUsage(userID uint, mimeType string, bytes int64, files int64)
+
+	GetUsage(userID uint) (*model.UsageResp, error)
+	SetQuota(adminID uint, req *model.SetQuotaReq) error
+}
+
+type quotaService struct {
+	quotaDao     dao.QuotaDao
+	userDao      dao.UserDao
+	defaultQuota int64
+	admins       map[uint]bool
+}
+
+// CheckQuota 检查写入 size 字节后是否超出用户配额
+func (qs *quotaService) CheckQuota(userID uint, size int64) error {
+	used, limit, err := qs.usage(userID)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && used+size > limit {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// RecordUsage 增量记录用量变化，记账失败只记录日志，不影响已完成的文件操作
+func (qs *quotaService) RecordUsage(userID uint, mimeType string, bytes int64, files int64) {
+	if bytes == 0 && files == 0 {
+		return
+	}
+	if err := qs.quotaDao.AddUsage(userID, mimeCategory(mimeType), bytes, files); err != nil {
+		log.Printf("记录用户用量失败(user %d, %d bytes): %v", userID, bytes, err)
+	}
+}
+
+// GetUsage 获取用户的已用空间、配额与按类别的明细
+func (qs *quotaService) GetUsage(userID uint) (*model.UsageResp, error) {
+	used, limit, err := qs.usage(userID)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := qs.quotaDao.ListCategories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用量明细失败: %w", err)
+	}
+	resp := &model.UsageResp{Used: used, Total: limit, Categories: make([]model.CategoryUsageResp, 0, len(categories))}
+	for _, c := range categories {
+		if c.Bytes == 0 && c.Files == 0 {
+			continue
+		}
+		resp.Categories = append(resp.Categories, model.CategoryUsageResp{Category: c.Category, Bytes: c.Bytes, Files: c.Files})
+	}
+	return resp, nil
+}
+
+// SetQuota 管理员调整指定用户的配额，管理员按用户ID配置：用户名可以被抢先注册，不能作为权限依据
+func (qs *quotaService) SetQuota(adminID uint, req *model.SetQuotaReq) error {
+	if !qs.admins[adminID] {
+		return ErrPermissionDenied
+	}
+	user, err := qs.userDao.GetUserByName(req.Username)
+	if err != nil || user == nil {
+		return ErrGranteeNotFound
+	}
+	return qs.quotaDao.SetQuota(user.ID, req.Quota)
+}
+
+// usage 返回用户已用空间与生效的配额
+func (qs *quotaService) usage(userID uint) (int64, int64, error) {
+	quota, err := qs.quotaDao.GetQuota(userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取用户配额失败: %w", err)
+	}
+	if quota == nil {
+		return 0, qs.defaultQuota, nil
+	}
+	limit := qs.defaultQuota
+	if quota.Quota != nil {
+		limit = *quota.Quota
+	}
+	return quota.Used, limit, nil
+}
+
+// mimeCategory 将 MIME 类型归入统计类别
+func mimeCategory(mimeType string) string {
+	mimeType = strings.ToLower(mimeType)
+	if i := strings.Index(mimeType, ";"); i >= 0 {
+		mimeType = mimeType[:i]
+	}
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return model.CategoryImage
+	case strings.HasPrefix(mimeType, "video/"):
+		return model.CategoryVideo
+	case strings.HasPrefix(mimeType, "audio/"):
+		return model.CategoryAudio
+	case strings.HasPrefix(mimeType, "text/"),
+		mimeType == "application/pdf",
+		mimeType == "application/json",
+		mimeType == "application/rtf",
+		mimeType == "application/msword",
+		strings.HasPrefix(mimeType, "application/vnd.ms-"),
+		strings.HasPrefix(mimeType, "application/vnd.openxmlformats-officedocument."),
+		strings.HasPrefix(mimeType, "application/vnd.oasis.opendocument."):
+		return model.CategoryDocument
+	case mimeType == "application/zip",
+		mimeType == "application/gzip",
+		mimeType == "application/x-gzip",
+		mimeType == "application/x-tar",
+		mimeType == "application/x-7z-compressed",
+		mimeType == "application/vnd.rar",
+		mimeType == "application/x-rar-compressed",
+		mimeType == "application/x-bzip2":
+		return model.CategoryArchive
+	}
+	return model.CategoryOther
+}
+
+func NewQuotaService(quotaDao dao.QuotaDao, userDao dao.UserDao) QuotaService {
+	cfg := config.AppConfigInstance.Quota
+	admins := make(map[uint]bool, len(cfg.AdminIDs))
+	for _, id := range cfg.AdminIDs {
+		admins[id] = true
+	}
+	return &quotaService{
+		quotaDao:     quotaDao,
+		userDao:      userDao,
+		defaultQuota: cfg.Default,
+		admins:       admins,
+	}
+}
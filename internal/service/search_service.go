@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"llmcloud/internal/rag"
+	"sort"
+)
+
+const (
+	defaultSearchTopK = 10
+	maxSearchTopK     = 50
+	// 向量库按所有者过滤后还要排除无权访问与已删除的文件，因此多取一些候选，不足时加倍重取
+	searchOversample    = 4
+	maxSearchCandidates = 1000
+)
+
+var (
+	ErrSearchDisabled = errors.New("未开启文档检索")
+	ErrNotFolder      = errors.New("目标不是文件夹")
+)
+
+// SearchService 基于检索索引的语义搜索
+type SearchService interface {
+	// SemanticSearch 在调用方可访问的文件中检索与 query 最相关的至多 topK 个片段，按文件分组返回；
+	// folderID 非空时只检索该文件夹的子树
+	SemanticSearch(ctx context.Context, userID uint, query string, topK int, folderID string) ([]model.SemanticSearchResult, error)
+}
+
+// retrieved 一个检索命中的片段及其所属文件
+type retrieved struct {
+	file *model.File
+	hit  rag.Hit
+}
+
+// searchScope 调用方可检索的范围：自己的文件、授权给自己的文件（含授权文件夹的子树），或限定的文件夹子树
+type searchScope struct {
+	userID  uint
+	granted map[string]bool // 授权给调用方的文件与文件夹
+	folder  *model.File
+	filter  rag.Filter
+}
+
+// allows 判断文件是否在检索范围内，按 GetFileIDPath 的祖先链判断文件是否位于授权或限定的文件夹中
+func (s *searchScope) allows(file *model.File) bool {
+	ancestors := model.AncestorIDs(file.Path)
+	if s.folder != nil {
+		for _, id := range ancestors {
+			if id == s.folder.ID {
+				return true
+			}
+		}
+		return false
+	}
+	if file.UserID == s.userID || s.granted[file.ID] {
+		return true
+	}
+	for _, id := range ancestors {
+		if s.granted[id] {
+			return true
+		}
+	}
+	return false
+}
+
+type searchService struct {
+	fileDao       dao.FileDao
+	permissionDao dao.PermissionDao
+	permissions   PermissionService
+	pipeline      *rag.Pipeline
+	enabled       bool
+}
+
+func (ss *searchService) SemanticSearch(ctx context.Context, userID uint, query string, topK int, folderID string) ([]model.SemanticSearchResult, error) {
+	if topK <= 0 {
+		topK = defaultSearchTopK
+	}
+	topK = min(topK, maxSearchTopK)
+	chunks, err := ss.retrieve(ctx, userID, query, topK, folderID)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]model.SemanticSearchResult, 0)
+	byFile := make(map[string]int)
+	for _, c := range chunks {
+		i, ok := byFile[c.file.ID]
+		if !ok {
+			// 片段已按相似度降序排列，文件的第一个片段即其最高分
+			i = len(results)
+			byFile[c.file.ID] = i
+			results = append(results, model.SemanticSearchResult{File: *c.file, Score: c.hit.Score})
+		}
+		results[i].Chunks = append(results[i].Chunks, model.ChunkMatch{
+			Index:   c.hit.Index,
+			Snippet: c.hit.Text,
+			Score:   c.hit.Score,
+			Start:   c.hit.Start,
+			End:     c.hit.End,
+		})
+	}
+	return results, nil
+}
+
+// retrieve 检索调用方可访问的文件中与 query 最相关的至多 topK 个片段，按相似度降序排列
+func (ss *searchService) retrieve(ctx context.Context, userID uint, query string, topK int, folderID string) ([]retrieved, error) {
+	if !ss.enabled {
+		return nil, ErrSearchDisabled
+	}
+	scope, err := ss.scope(userID, folderID)
+	if err != nil {
+		return nil, err
+	}
+	// 空的过滤条件会匹配全部记录，文件夹中没有文件时直接返回
+	if scope.folder != nil && len(scope.filter.FileIDs) == 0 {
+		return nil, nil
+	}
+	vectors, err := ss.pipeline.Embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("生成查询向量失败: %w", err)
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("生成查询向量失败: 期望 1 个向量，实际 %d 个", len(vectors))
+	}
+
+	for candidates := topK * searchOversample; ; candidates *= 2 {
+		candidates = min(candidates, maxSearchCandidates)
+		hits, err := ss.pipeline.Store.Search(ctx, vectors[0], candidates, scope.filter)
+		if err != nil {
+			return nil, fmt.Errorf("检索失败: %w", err)
+		}
+		result, err := ss.accessible(scope, hits, topK)
+		if err != nil {
+			return nil, err
+		}
+		// 候选已全部取出，或过滤后数量足够
+		if len(result) >= topK || len(hits) < candidates || candidates >= maxSearchCandidates {
+			return result, nil
+		}
+	}
+}
+
+// accessible 按检索范围过滤命中的片段，排除已删除的文件，返回前 topK 个
+func (ss *searchService) accessible(scope *searchScope, hits []rag.Hit, topK int) ([]retrieved, error) {
+	ids := make([]string, 0, len(hits))
+	seen := make(map[string]bool, len(hits))
+	for _, h := range hits {
+		if !seen[h.FileID] {
+			seen[h.FileID] = true
+			ids = append(ids, h.FileID)
+		}
+	}
+	// 回收站中的文件不会被查出
+	files, err := ss.fileDao.GetFilesByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %w", err)
+	}
+	byID := make(map[string]*model.File, len(files))
+	for i := range files {
+		if scope.allows(&files[i]) {
+			byID[files[i].ID] = &files[i]
+		}
+	}
+	result := make([]retrieved, 0, topK)
+	for _, h := range hits {
+		if file, ok := byID[h.FileID]; ok {
+			result = append(result, retrieved{file: file, hit: h})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].hit.Score > result[j].hit.Score })
+	if len(result) > topK {
+		result = result[:topK]
+	}
+	return result, nil
+}
+
+// scope 确定调用方的检索范围，并据此构造向量库的过滤条件
+func (ss *searchService) scope(userID uint, folderID string) (*searchScope, error) {
+	scope := &searchScope{userID: userID}
+	if folderID != "" {
+		folder, err := ss.permissions.Authorize(userID, folderID, model.RoleViewer)
+		if err != nil {
+			return nil, err
+		}
+		if !folder.IsDir {
+			return nil, ErrNotFolder
+		}
+		// 按子树内的文件过滤，而不是按所有者过滤后再排除子树之外的命中：
+		// 后者在所有者的其他文件更相关时，候选数达到上限也可能取不到子树中的片段
+		subtree, err := ss.fileDao.GetSubtree(folder)
+		if err != nil {
+			return nil, fmt.Errorf("获取文件夹内容失败: %w", err)
+		}
+		scope.folder = folder
+		for _, f := range subtree {
+			if !f.IsDir {
+				scope.filter.FileIDs = append(scope.filter.FileIDs, f.ID)
+			}
+		}
+		return scope, nil
+	}
+	permissions, err := ss.permissionDao.ListPermissionsByGrantee(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取授权信息失败: %w", err)
+	}
+	scope.granted = make(map[string]bool, len(permissions))
+	owners := []uint{userID}
+	seen := map[uint]bool{userID: true}
+	for _, p := range permissions {
+		scope.granted[p.FileID] = true
+		if !seen[p.OwnerID] {
+			seen[p.OwnerID] = true
+			owners = append(owners, p.OwnerID)
+		}
+	}
+	scope.filter = rag.Filter{UserIDs: owners}
+	return scope, nil
+}
+
+func NewSearchService(fileDao dao.FileDao, permissionDao dao.PermissionDao, permissions PermissionService, pipeline *rag.Pipeline) SearchService {
+	return &searchService{
+		fileDao:       fileDao,
+		permissionDao: permissionDao,
+		permissions:   permissions,
+		pipeline:      pipeline,
+		enabled:       config.AppConfigInstance.RAG.Enabled,
+	}
+}
@@ -0,0 +1,450 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/middleware"
+	"llmcloud/internal/model"
+	"llmcloud/internal/storage"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrShareNotFound         = errors.New("分享链接不存在")
+	ErrShareExpired          = errors.New("分享链接已过期")
+	ErrSharePasswordRequired = errors.New("需要提取码")
+	ErrSharePasswordInvalid  = errors.New("提取码错误")
+	ErrShareDownloadLimit    = errors.New("下载次数已达上限")
+	ErrShareReadOnly         = errors.New("该分享不允许上传")
+	ErrShareOutOfScope       = errors.New("文件不在分享范围内")
+)
+
+const (
+	shareAccessTokenTTL    = 2 * time.Hour
+	shareDownloadTicketTTL = 24 * time.Hour
+	// 续传时允许重复发送的字节数上限：连接中断时已写出但客户端未收到的数据会被再次请求
+	maxShareResumeSlack = 256 << 10
+)
+
+// ShareService 公开分享链接服务
+type ShareService interface {
+	CreateShare(userID uint, req *model.ShareLinkReq) (*model.ShareLinkResp, error)
+	ListShares(userID uint) ([]model.ShareLinkResp, error)
+	UpdateShare(userID uint, req *model.ShareLinkReq) error
+	RevokeShare(userID uint, shareID string) error
+
+	// 以下为访客接口，accessToken 为通过提取码校验后获得的访问令牌
+	GetShareMeta(token string) (*model.ShareMetaResp, error)
+	AuthShare(token string, password string) (string, error)
+	ListShareFiles(token string, accessToken string, parentID string, page int, pageSize int) (int64, []model.File, error)
+	OpenShareFile(ctx context.Context, token string, accessToken string, fileID string) (*model.ShareLink, *model.File, io.ReadSeekCloser, error)
+	// StartShareDownload 在发送 length 字节前调用：ticket 为有效的下载票据且续传未超出文件大小时不计入下载次数，
+	// 否则计入一次下载并签发新的票据；返回本次使用的票据
+	StartShareDownload(share *model.ShareLink, file *model.File, ticket string, length int64) (string, error)
+	// FinishShareDownload 发送结束后调用，归还预占但未发送的 unserved 字节
+	FinishShareDownload(ticket string, unserved int64)
+	UploadToShare(ctx context.Context, token string, accessToken string, parentID string, name string, reader io.Reader, size int64) (*model.File, error)
+}
+
+type shareService struct {
+	shareDao      dao.ShareDao
+	fileDao       dao.FileDao
+	permissions   PermissionService
+	storageDriver storage.Driver
+	blobs         *blobStore
+	committer     *fileCommitter
+	quota         QuotaService
+}
+
+// CreateShare 为用户拥有所有者权限的文件或文件夹创建分享链接
+func (ss *shareService) CreateShare(userID uint, req *model.ShareLinkReq) (*model.ShareLinkResp, error) {
+	file, err := ss.permissions.Authorize(userID, req.FileID, model.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+	share := &model.ShareLink{
+		ID:      GenerateUUID(),
+		Token:   token,
+		UserID:  userID,
+		OwnerID: file.UserID,
+		FileID:  file.ID,
+	}
+	if err := applyShareOptions(share, req, file); err != nil {
+		return nil, err
+	}
+	if err := ss.shareDao.CreateShare(share); err != nil {
+		return nil, fmt.Errorf("创建分享失败: %w", err)
+	}
+	return buildShareResp(share, file), nil
+}
+
+// ListShares 列出用户的全部分享链接及访问统计
+func (ss *shareService) ListShares(userID uint) ([]model.ShareLinkResp, error) {
+	shares, err := ss.shareDao.ListSharesByUser(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取分享列表失败: %w", err)
+	}
+	result := make([]model.ShareLinkResp, 0, len(shares))
+	for i := range shares {
+		file, err := ss.fileDao.GetFileMetaByFileID(shares[i].FileID)
+		if err != nil {
+			return nil, fmt.Errorf("获取文件信息失败: %w", err)
+		}
+		result = append(result, *buildShareResp(&shares[i], file))
+	}
+	return result, nil
+}
+
+// UpdateShare 修改分享链接的密码、有效期、下载上限与模式
+func (ss *shareService) UpdateShare(userID uint, req *model.ShareLinkReq) error {
+	share, err := ss.getOwnedShare(userID, req.ShareID)
+	if err != nil {
+		return err
+	}
+	// 创建后所有者权限可能已被收回，修改时重新检查
+	file, err := ss.permissions.Authorize(userID, share.FileID, model.RoleOwner)
+	if err != nil {
+		return err
+	}
+	if err := applyShareOptions(share, req, file); err != nil {
+		return err
+	}
+	return ss.shareDao.UpdateShare(share)
+}
+
+// RevokeShare 撤销分享链接
+func (ss *shareService) RevokeShare(userID uint, shareID string) error {
+	share, err := ss.getOwnedShare(userID, shareID)
+	if err != nil {
+		return err
+	}
+	return ss.shareDao.DeleteShare(share.ID)
+}
+
+// GetShareMeta 获取分享链接的公开信息，无需提取码
+func (ss *shareService) GetShareMeta(token string) (*model.ShareMetaResp, error) {
+	share, root, err := ss.resolve(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := ss.shareDao.IncrAccessCount(share.ID); err != nil {
+		return nil, fmt.Errorf("更新访问次数失败: %w", err)
+	}
+	remaining := int64(-1)
+	if share.MaxDownloads > 0 {
+		remaining = share.MaxDownloads - share.DownloadCount
+		if remaining < 0 {
+			remaining = 0
+		}
+	}
+	return &model.ShareMetaResp{
+		Name:               root.Name,
+		IsDir:              root.IsDir,
+		Size:               root.Size,
+		RootID:             root.ID,
+		HasPassword:        share.PasswordHash != "",
+		ExpiresAt:          share.ExpiresAt,
+		Mode:               share.Mode,
+		RemainingDownloads: remaining,
+	}, nil
+}
+
+// AuthShare 校验提取码并签发访客访问令牌
+func (ss *shareService) AuthShare(token string, password string) (string, error) {
+	share, _, err := ss.resolve(token)
+	if err != nil {
+		return "", err
+	}
+	if share.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(share.PasswordHash), []byte(password)); err != nil {
+			return "", ErrSharePasswordInvalid
+		}
+	}
+	return middleware.GenerateShareToken(share.ID, passwordTag(share.PasswordHash), shareAccessTokenTTL)
+}
+
+// ListShareFiles 浏览分享的文件夹，parentID 为空时列出分享根目录
+func (ss *shareService) ListShareFiles(token string, accessToken string, parentID string, page int, pageSize int) (int64, []model.File, error) {
+	share, root, err := ss.authorize(token, accessToken)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !root.IsDir {
+		return 1, []model.File{*root}, nil
+	}
+	folder, err := ss.fileInShare(share, root, parentID)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !folder.IsDir {
+		return 0, nil, errors.New("目标不是文件夹")
+	}
+	total, err := ss.fileDao.CountFilesByParentID(&folder.ID, share.OwnerID)
+	if err != nil {
+		return 0, nil, err
+	}
+	files, err := ss.fileDao.ListFiles(share.OwnerID, &folder.ID, page, pageSize, "name:asc")
+	if err != nil {
+		return 0, nil, err
+	}
+	return total, files, nil
+}
+
+// OpenShareFile 打开分享范围内的文件，下载次数由 StartShareDownload 计入
+func (ss *shareService) OpenShareFile(ctx context.Context, token string, accessToken string, fileID string) (*model.ShareLink, *model.File, io.ReadSeekCloser, error) {
+	share, root, err := ss.authorize(token, accessToken)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	file, err := ss.fileInShare(share, root, fileID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if file.IsDir {
+		return nil, nil, nil, errors.New("不能直接下载文件夹")
+	}
+	reader, err := storage.OpenSeeker(ctx, ss.storageDriver, file.StorageKey, file.Size)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("文件下载失败: %w", err)
+	}
+	return share, file, reader, nil
+}
+
+// StartShareDownload 按实际发送的字节数计数：同一票据累计发送的数据不能超过一个完整文件（另有少量余量），
+// 因此无论 Range 如何构造，取得完整内容都至少计入一次下载
+func (ss *shareService) StartShareDownload(share *model.ShareLink, file *model.File, ticket string, length int64) (string, error) {
+	now := time.Now()
+	if ticket != "" {
+		limit := file.Size + min(maxShareResumeSlack, file.Size/4)
+		ok, err := ss.shareDao.ReserveDownload(ticket, share.ID, file.ID, length, limit, now)
+		if err != nil {
+			return "", fmt.Errorf("更新下载记录失败: %w", err)
+		}
+		if ok {
+			return ticket, nil
+		}
+	}
+	ok, err := ss.shareDao.TryIncrDownloadCount(share.ID)
+	if err != nil {
+		return "", fmt.Errorf("更新下载次数失败: %w", err)
+	}
+	if !ok {
+		return "", ErrShareDownloadLimit
+	}
+	if err := ss.shareDao.DeleteExpiredDownloads(now); err != nil {
+		log.Printf("清理过期下载票据失败: %v", err)
+	}
+	download := &model.ShareDownload{
+		ID:        GenerateUUID(),
+		ShareID:   share.ID,
+		FileID:    file.ID,
+		Served:    length,
+		ExpiresAt: now.Add(shareDownloadTicketTTL),
+	}
+	if err := ss.shareDao.CreateDownload(download); err != nil {
+		return "", fmt.Errorf("创建下载记录失败: %w", err)
+	}
+	return download.ID, nil
+}
+
+func (ss *shareService) FinishShareDownload(ticket string, unserved int64) {
+	if unserved <= 0 {
+		return
+	}
+	if err := ss.shareDao.ReleaseDownload(ticket, unserved); err != nil {
+		log.Printf("更新下载记录失败(%s): %v", ticket, err)
+	}
+}
+
+// UploadToShare 访客向允许上传的共享文件夹上传文件，文件归属于文件夹的所有者
+func (ss *shareService) UploadToShare(ctx context.Context, token string, accessToken string, parentID string, name string, reader io.Reader, size int64) (*model.File, error) {
+	share, root, err := ss.authorize(token, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	if share.Mode != model.ShareModeUpload || !root.IsDir {
+		return nil, ErrShareReadOnly
+	}
+	folder, err := ss.fileInShare(share, root, parentID)
+	if err != nil {
+		return nil, err
+	}
+	if !folder.IsDir {
+		return nil, errors.New("目标不是文件夹")
+	}
+	if err := ss.quota.CheckQuota(share.OwnerID, size); err != nil {
+		return nil, err
+	}
+	blob, err := ss.blobs.put(ctx, share.OwnerID, reader, size)
+	if err != nil {
+		return nil, err
+	}
+	// 访客上传不覆盖所有者已有的文件，同名时自动重命名
+	return ss.committer.commit(ctx, share.OwnerID, share.OwnerID, name, &folder.ID, blob, model.ConflictRename)
+}
+
+// resolve 根据令牌查找有效的分享链接及其根文件
+func (ss *shareService) resolve(token string) (*model.ShareLink, *model.File, error) {
+	share, err := ss.shareDao.GetShareByToken(token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取分享链接失败: %w", err)
+	}
+	if share == nil {
+		return nil, nil, ErrShareNotFound
+	}
+	if share.ExpiresAt != nil && time.Now().After(*share.ExpiresAt) {
+		return nil, nil, ErrShareExpired
+	}
+	root, err := ss.fileDao.GetFileMetaByFileID(share.FileID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取文件信息失败: %w", err)
+	}
+	if root == nil {
+		// 被分享的文件已删除
+		return nil, nil, ErrShareNotFound
+	}
+	if share.OwnerID == 0 {
+		// 早期创建的链接未记录所有者，分享者即所有者
+		share.OwnerID = share.UserID
+	}
+	return share, root, nil
+}
+
+// authorize 在 resolve 的基础上校验访客访问令牌
+func (ss *shareService) authorize(token string, accessToken string) (*model.ShareLink, *model.File, error) {
+	share, root, err := ss.resolve(token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if share.PasswordHash == "" {
+		return share, root, nil
+	}
+	if accessToken == "" {
+		return nil, nil, ErrSharePasswordRequired
+	}
+	claims, err := middleware.ParseShareToken(accessToken)
+	if err != nil || claims.ShareID != share.ID || claims.PasswordTag != passwordTag(share.PasswordHash) {
+		return nil, nil, ErrSharePasswordRequired
+	}
+	return share, root, nil
+}
+
+// fileInShare 获取分享范围内的文件，fileID 为空时返回分享根
+func (ss *shareService) fileInShare(share *model.ShareLink, root *model.File, fileID string) (*model.File, error) {
+	if fileID == "" || fileID == root.ID {
+		return root, nil
+	}
+	file, err := ss.fileDao.GetFileMetaByFileID(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %w", err)
+	}
+	if file == nil || file.UserID != share.OwnerID {
+		return nil, ErrShareOutOfScope
+	}
+	// 分享根的后代的祖先路径都以分享根为前缀
+	if root.IsDir && strings.HasPrefix(file.Path, model.ChildPath(root)) {
+		return file, nil
+	}
+	return nil, ErrShareOutOfScope
+}
+
+func (ss *shareService) getOwnedShare(userID uint, shareID string) (*model.ShareLink, error) {
+	share, err := ss.shareDao.GetShareByID(shareID)
+	if err != nil {
+		return nil, fmt.Errorf("获取分享链接失败: %w", err)
+	}
+	if share == nil || share.UserID != userID {
+		return nil, ErrShareNotFound
+	}
+	return share, nil
+}
+
+// applyShareOptions 将请求中的选项写入分享链接
+func applyShareOptions(share *model.ShareLink, req *model.ShareLinkReq, file *model.File) error {
+	if req.Password != nil {
+		share.PasswordHash = ""
+		if *req.Password != "" {
+			hashed, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return errors.New("密码加密失败")
+			}
+			share.PasswordHash = string(hashed)
+		}
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return errors.New("过期时间不能早于当前时间")
+	}
+	share.ExpiresAt = req.ExpiresAt
+	share.MaxDownloads = req.MaxDownloads
+	share.Mode = req.Mode
+	if share.Mode == "" {
+		share.Mode = model.ShareModeReadOnly
+	}
+	if share.Mode == model.ShareModeUpload && !file.IsDir {
+		return errors.New("只有文件夹可以开启上传")
+	}
+	return nil
+}
+
+func buildShareResp(share *model.ShareLink, file *model.File) *model.ShareLinkResp {
+	resp := &model.ShareLinkResp{
+		ShareID:       share.ID,
+		Token:         share.Token,
+		FileID:        share.FileID,
+		HasPassword:   share.PasswordHash != "",
+		ExpiresAt:     share.ExpiresAt,
+		MaxDownloads:  share.MaxDownloads,
+		DownloadCount: share.DownloadCount,
+		AccessCount:   share.AccessCount,
+		Mode:          share.Mode,
+		CreatedAt:     share.CreatedAt,
+	}
+	if file != nil {
+		resp.FileName = file.Name
+		resp.IsDir = file.IsDir
+	}
+	return resp
+}
+
+// generateShareToken 生成链接中使用的随机令牌
+func generateShareToken() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成分享令牌失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// passwordTag 返回提取码哈希的摘要，用于使修改密码前签发的访问令牌失效
+func passwordTag(passwordHash string) string {
+	sum := sha256.Sum256([]byte(passwordHash))
+	return hex.EncodeToString(sum[:8])
+}
+
+func NewShareService(shareDao dao.ShareDao, fileDao dao.FileDao, permissions PermissionService, blobDao dao.BlobDao, versionDao dao.VersionDao, tx dao.Transactor, ingest IngestService, quota QuotaService, driver storage.Driver) ShareService {
+	blobs := newBlobStore(blobDao, driver)
+	return &shareService{
+		shareDao:      shareDao,
+		fileDao:       fileDao,
+		permissions:   permissions,
+		storageDriver: driver,
+		blobs:         blobs,
+		committer:     newFileCommitter(fileDao, versionDao, tx, ingest, blobs, quota),
+		quota:         quota,
+	}
+}
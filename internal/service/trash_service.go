@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"llmcloud/internal/storage"
+	"log"
+	"time"
+)
+
+var ErrTrashItemNotFound = errors.New("回收站条目不存在")
+
+const (
+	defaultTrashRetention = 30 * 24 * time.Hour
+	defaultPurgeInterval  = time.Hour
+	purgeBatchSize        = 100
+)
+
+// TrashService 回收站服务
+type TrashService interface {
+	ListTrash(userID uint, page int, pageSize int) (int64, []model.TrashItem, error)
+	RestoreTrash(userID uint, trashIDs []string) error
+	DeleteTrash(ctx context.Context, userID uint, trashIDs []string) error
+	EmptyTrash(ctx context.Context, userID uint) error
+	PurgeExpired(ctx context.Context) (int, error)
+	RunPurger(ctx context.Context)
+}
+
+type trashService struct {
+	trashDao      dao.TrashDao
+	fileDao       dao.FileDao
+	versionDao    dao.VersionDao
+	tx            dao.Transactor
+	ingest        IngestService
+	blobs         *blobStore
+	quota         QuotaService
+	retention     time.Duration
+	purgeInterval time.Duration
+}
+
+func (ts *trashService) ListTrash(userID uint, page int, pageSize int) (int64, []model.TrashItem, error) {
+	total, err := ts.trashDao.CountTrashItems(userID)
+	if err != nil {
+		return 0, nil, err
+	}
+	items, err := ts.trashDao.ListTrashItems(userID, page, pageSize)
+	if err != nil {
+		return 0, nil, err
+	}
+	return total, items, nil
+}
+
+// RestoreTrash 将条目恢复到原目录；原目录已不存在时恢复到根目录，目标位置有同名文件时自动重命名
+func (ts *trashService) RestoreTrash(userID uint, trashIDs []string) error {
+	for _, trashID := range trashIDs {
+		item, err := ts.getTrashItem(userID, trashID)
+		if err != nil {
+			return err
+		}
+
+		parentID := item.OriginalParentID
+		if parentID != nil {
+			parent, err := ts.fileDao.GetFileMetaByFileID(*parentID)
+			if err != nil {
+				return fmt.Errorf("获取原目录失败: %w", err)
+			}
+			if parent == nil {
+				parentID = nil
+			}
+		}
+
+		existing, err := ts.fileDao.GetFilesByParentID(userID, parentID)
+		if err != nil {
+			return fmt.Errorf("获取目标目录内容失败: %w", err)
+		}
+		existingNames := make(map[string]bool, len(existing))
+		for _, f := range existing {
+			existingNames[f.Name] = true
+		}
+
+		var restored *model.File
+		err = ts.tx.Transaction(func(tx *dao.Tx) error {
+			if err := tx.Trash.RestoreTrashItem(item, uniqueName(item.Name, existingNames), parentID); err != nil {
+				return fmt.Errorf("恢复失败: %w", err)
+			}
+			if restored, err = tx.Files.GetFileMetaByFileID(item.FileID); err != nil {
+				return fmt.Errorf("获取恢复的文件失败: %w", err)
+			}
+			if restored == nil {
+				return nil
+			}
+			// 恢复的文件夹只记录其本身，同步客户端需要重新列举其子树
+			if err := tx.Changes.Record(changeOf(model.ChangeCreate, restored)); err != nil {
+				return err
+			}
+			return newFolderStats(tx.Files).apply(parentID, statsOf(restored))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteTrash 彻底删除回收站条目
+func (ts *trashService) DeleteTrash(ctx context.Context, userID uint, trashIDs []string) error {
+	for _, trashID := range trashIDs {
+		item, err := ts.getTrashItem(userID, trashID)
+		if err != nil {
+			return err
+		}
+		if err := ts.purge(ctx, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmptyTrash 清空回收站
+func (ts *trashService) EmptyTrash(ctx context.Context, userID uint) error {
+	items, err := ts.trashDao.ListAllTrashItems(userID)
+	if err != nil {
+		return fmt.Errorf("获取回收站条目失败: %w", err)
+	}
+	for i := range items {
+		if err := ts.purge(ctx, &items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeExpired 彻底删除超过保留期的条目，返回删除数量；单个条目删除失败时记录日志并跳过，留待下次清理
+func (ts *trashService) PurgeExpired(ctx context.Context) (int, error) {
+	purged, failed := 0, 0
+	before := time.Now().Add(-ts.retention)
+	for {
+		// 删除成功的条目不再出现在结果中，只需跳过删除失败的条目
+		items, err := ts.trashDao.ListExpiredTrashItems(before, failed, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for i := range items {
+			if err := ts.purge(ctx, &items[i]); err != nil {
+				if ctx.Err() != nil {
+					return purged, ctx.Err()
+				}
+				log.Printf("清理回收站条目失败(%s): %v", items[i].ID, err)
+				failed++
+				continue
+			}
+			purged++
+		}
+		if len(items) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// RunPurger 按配置的间隔周期性清理过期条目，直到 ctx 结束
+func (ts *trashService) RunPurger(ctx context.Context) {
+	ticker := time.NewTicker(ts.purgeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := ts.PurgeExpired(ctx); err != nil {
+				log.Printf("清理回收站失败: %v", err)
+			} else if n > 0 {
+				log.Printf("已清理 %d 个过期回收站条目", n)
+			}
+		}
+	}
+}
+
+func (ts *trashService) getTrashItem(userID uint, trashID string) (*model.TrashItem, error) {
+	item, err := ts.trashDao.GetTrashItem(trashID)
+	if err != nil {
+		return nil, fmt.Errorf("获取回收站条目失败: %w", err)
+	}
+	if item == nil || item.UserID != userID {
+		return nil, ErrTrashItemNotFound
+	}
+	return item, nil
+}
+
+// purge 先删除数据库记录再释放存储对象：
+// 释放失败只会遗留存储对象，而不会因重试导致引用计数被重复扣减
+func (ts *trashService) purge(ctx context.Context, item *model.TrashItem) error {
+	files, err := ts.trashDao.ListTrashedFiles(item.ID)
+	if err != nil {
+		return fmt.Errorf("获取回收站文件失败: %w", err)
+	}
+	if err := ts.trashDao.DeleteTrashItem(item); err != nil {
+		return fmt.Errorf("彻底删除失败: %w", err)
+	}
+	for i := range files {
+		if files[i].IsDir {
+			continue
+		}
+		ts.quota.RecordUsage(files[i].UserID, files[i].MIMEType, -files[i].Size, -1)
+		if err := ts.blobs.release(ctx, files[i].Hash, files[i].StorageKey); err != nil {
+			log.Printf("释放存储对象失败(%s): %v", files[i].ID, err)
+		}
+		ts.purgeVersions(ctx, files[i].UserID, files[i].ID)
+		ts.ingest.Remove(ctx, files[i].ID)
+	}
+	return nil
+}
+
+// purgeVersions 删除文件的全部历史版本并释放其存储对象
+func (ts *trashService) purgeVersions(ctx context.Context, ownerID uint, fileID string) {
+	versions, err := ts.versionDao.ListVersions(fileID)
+	if err != nil {
+		log.Printf("获取历史版本失败(%s): %v", fileID, err)
+		return
+	}
+	if err := ts.versionDao.DeleteVersionsByFileID(fileID); err != nil {
+		log.Printf("删除历史版本失败(%s): %v", fileID, err)
+		return
+	}
+	for _, v := range versions {
+		ts.quota.RecordUsage(ownerID, v.MIMEType, -v.Size, 0)
+		if err := ts.blobs.release(ctx, v.Hash, v.StorageKey); err != nil {
+			log.Printf("释放历史版本存储失败(%s): %v", v.ID, err)
+		}
+	}
+}
+
+func NewTrashService(trashDao dao.TrashDao, fileDao dao.FileDao, blobDao dao.BlobDao, versionDao dao.VersionDao, tx dao.Transactor, ingest IngestService, quota QuotaService, driver storage.Driver) TrashService {
+	cfg := config.AppConfigInstance.Trash
+	return &trashService{
+		trashDao:      trashDao,
+		fileDao:       fileDao,
+		versionDao:    versionDao,
+		tx:            tx,
+		ingest:        ingest,
+		blobs:         newBlobStore(blobDao, driver),
+		quota:         quota,
+		retention:     parseDuration(cfg.Retention, defaultTrashRetention),
+		purgeInterval: parseDuration(cfg.PurgeInterval, defaultPurgeInterval),
+	}
+}
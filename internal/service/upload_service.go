@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"llmcloud/config"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"llmcloud/internal/storage"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUploadSessionNotFound = errors.New("上传会话不存在")
+	ErrUploadSessionExpired  = errors.New("上传会话已过期")
+	ErrUploadChunkInvalid    = errors.New("分片参数无效")
+	ErrUploadIncomplete      = errors.New("分片尚未全部上传")
+	ErrFileHashMismatch      = errors.New("文件哈希校验失败")
+)
+
+const (
+	defaultChunkSize       = 8 << 20 // 8MB
+	defaultSessionTTL      = 24 * time.Hour
+	defaultCleanupInterval = 10 * time.Minute
+	maxChunkCount          = 10000 // MinIO/OSS 单次分片上传的分片数上限
+	cleanupBatchSize       = 100
+)
+
+// UploadService 分片（断点续传）上传服务
+type UploadService interface {
+	InitUpload(ctx context.Context, userID uint, req *model.InitUploadReq) (*model.UploadSessionResp, error)
+	UploadChunk(ctx context.Context, userID uint, uploadID string, index int, reader io.Reader, size int64) error
+	GetUploadStatus(userID uint, uploadID string) (*model.UploadSessionResp, error)
+	CompleteUpload(ctx context.Context, userID uint, uploadID string) (*model.File, error)
+	AbortUpload(ctx context.Context, userID uint, uploadID string) error
+	CleanExpiredSessions(ctx context.Context) (int, error)
+	RunCleaner(ctx context.Context)
+}
+
+type uploadService struct {
+	sessionDao    dao.UploadSessionDao
+	fileDao       dao.FileDao
+	permissions   PermissionService
+	quota         QuotaService
+	storageDriver storage.Driver
+	blobs         *blobStore
+	committer     *fileCommitter
+	chunkSize     int64
+	sessionTTL    time.Duration
+	cleanInterval time.Duration
+}
+
+// InitUpload 创建分片上传会话并在存储端初始化分片上传
+func (us *uploadService) InitUpload(ctx context.Context, userID uint, req *model.InitUploadReq) (*model.UploadSessionResp, error) {
+	if err := validateName(req.FileName); err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil && *req.ParentID == "" {
+		req.ParentID = nil
+	}
+	parent, err := us.permissions.AuthorizeFolder(userID, req.ParentID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	// 未完成的上传会话视为已预留的空间
+	pending, err := us.sessionDao.SumPendingSize(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取上传会话失败: %w", err)
+	}
+	if err := us.quota.CheckQuota(folderOwner(userID, parent), req.Size+pending); err != nil {
+		return nil, err
+	}
+
+	chunkSize := us.chunkSize
+	chunkCount := int((req.Size + chunkSize - 1) / chunkSize)
+	if chunkCount > maxChunkCount {
+		// 文件过大时放大分片，保证分片数不超过存储端上限
+		chunkSize = (req.Size + maxChunkCount - 1) / maxChunkCount
+		chunkCount = int((req.Size + chunkSize - 1) / chunkSize)
+	}
+
+	sessionID := GenerateUUID()
+	storageKey := GenerateStorageKey(userID, sessionID)
+	storageUploadID, err := us.storageDriver.InitMultipart(ctx, storageKey)
+	if err != nil {
+		return nil, fmt.Errorf("初始化分片上传失败: %w", err)
+	}
+
+	session := &model.UploadSession{
+		ID:          sessionID,
+		UserID:      userID,
+		FileName:    req.FileName,
+		Size:        req.Size,
+		Hash:        strings.ToLower(req.Hash),
+		ParentID:    req.ParentID,
+		ChunkSize:   chunkSize,
+		ChunkCount:  chunkCount,
+		StorageType: config.AppConfigInstance.Storage.Type,
+		StorageKey:  storageKey,
+		UploadID:    storageUploadID,
+		OnConflict:  req.OnConflict,
+		Status:      model.UploadStatusUploading,
+		ExpiresAt:   time.Now().Add(us.sessionTTL),
+	}
+	if err := us.sessionDao.CreateSession(session); err != nil {
+		_ = us.storageDriver.AbortMultipart(ctx, storageKey, storageUploadID)
+		return nil, fmt.Errorf("创建上传会话失败: %w", err)
+	}
+	return buildSessionResp(session, nil), nil
+}
+
+// UploadChunk 上传单个分片，分片可以任意顺序上传，重复上传会覆盖
+func (us *uploadService) UploadChunk(ctx context.Context, userID uint, uploadID string, index int, reader io.Reader, size int64) error {
+	session, err := us.getActiveSession(userID, uploadID)
+	if err != nil {
+		return err
+	}
+	if session.Status != model.UploadStatusUploading {
+		return ErrUploadSessionNotFound
+	}
+	if index < 0 || index >= session.ChunkCount || size != expectedChunkSize(session, index) {
+		return ErrUploadChunkInvalid
+	}
+
+	etag, err := us.storageDriver.UploadPart(ctx, session.StorageKey, session.UploadID, index+1, reader, size)
+	if err != nil {
+		return fmt.Errorf("分片上传失败: %w", err)
+	}
+	if err := us.sessionDao.SaveChunk(&model.UploadChunk{
+		SessionID:  session.ID,
+		ChunkIndex: index,
+		Size:       size,
+		ETag:       etag,
+	}); err != nil {
+		return fmt.Errorf("记录分片失败: %w", err)
+	}
+	// 有活动的会话顺延有效期
+	return us.sessionDao.TouchSession(session.ID, time.Now().Add(us.sessionTTL))
+}
+
+// GetUploadStatus 查询会话已接收的分片
+func (us *uploadService) GetUploadStatus(userID uint, uploadID string) (*model.UploadSessionResp, error) {
+	session, err := us.getActiveSession(userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	chunks, err := us.sessionDao.ListChunks(session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取分片列表失败: %w", err)
+	}
+	return buildSessionResp(session, chunks), nil
+}
+
+// CompleteUpload 合并分片并创建文件记录
+func (us *uploadService) CompleteUpload(ctx context.Context, userID uint, uploadID string) (*model.File, error) {
+	session, err := us.getActiveSession(userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	// 上传期间目标目录的授权可能已被撤销，合并前重新校验
+	parent, err := us.permissions.AuthorizeFolder(userID, session.ParentID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := us.sessionDao.CompareAndSetStatus(session.ID, model.UploadStatusUploading, model.UploadStatusCompleting)
+	if err != nil {
+		return nil, fmt.Errorf("更新会话状态失败: %w", err)
+	}
+	if !ok {
+		return nil, errors.New("上传会话正在合并中")
+	}
+	// 合并失败时回退状态，允许客户端补传后重试
+	rollback := func() {
+		if _, err := us.sessionDao.CompareAndSetStatus(session.ID, model.UploadStatusCompleting, model.UploadStatusUploading); err != nil {
+			log.Printf("回退上传会话状态失败: %v", err)
+		}
+	}
+
+	chunks, err := us.sessionDao.ListChunks(session.ID)
+	if err != nil {
+		rollback()
+		return nil, fmt.Errorf("获取分片列表失败: %w", err)
+	}
+	if len(chunks) != session.ChunkCount {
+		rollback()
+		return nil, ErrUploadIncomplete
+	}
+	parts := make([]storage.Part, 0, len(chunks))
+	for _, chunk := range chunks {
+		parts = append(parts, storage.Part{Number: chunk.ChunkIndex + 1, ETag: chunk.ETag, Size: chunk.Size})
+	}
+	if err := us.storageDriver.CompleteMultipart(ctx, session.StorageKey, session.UploadID, parts); err != nil {
+		rollback()
+		return nil, fmt.Errorf("合并分片失败: %w", err)
+	}
+
+	// 分片可能乱序到达，合并后读取一遍对象计算哈希，并与客户端声明的哈希比对
+	hash, err := us.blobs.hashObject(ctx, session.StorageKey)
+	if err != nil {
+		// 分片已合并，无法重试，清理合并后的对象
+		_ = us.storageDriver.Delete(ctx, session.StorageKey)
+		_ = us.sessionDao.DeleteSession(session.ID)
+		return nil, err
+	}
+	if session.Hash != "" && hash != session.Hash {
+		_ = us.storageDriver.Delete(ctx, session.StorageKey)
+		_ = us.sessionDao.DeleteSession(session.ID)
+		return nil, ErrFileHashMismatch
+	}
+	blob, err := us.blobs.commit(ctx, hash, session.Size, session.StorageKey)
+	if err != nil {
+		_ = us.sessionDao.DeleteSession(session.ID)
+		return nil, err
+	}
+
+	newFile, err := us.committer.commit(ctx, folderOwner(userID, parent), userID, session.FileName, session.ParentID, blob, session.OnConflict)
+	if err != nil {
+		_ = us.sessionDao.DeleteSession(session.ID)
+		return nil, err
+	}
+	if err := us.sessionDao.DeleteSession(session.ID); err != nil {
+		log.Printf("删除上传会话失败: %v", err)
+	}
+	return newFile, nil
+}
+
+// AbortUpload 取消上传并清理已上传的分片
+func (us *uploadService) AbortUpload(ctx context.Context, userID uint, uploadID string) error {
+	session, err := us.sessionDao.GetSession(uploadID)
+	if err != nil {
+		return fmt.Errorf("获取上传会话失败: %w", err)
+	}
+	if session == nil || session.UserID != userID {
+		return ErrUploadSessionNotFound
+	}
+	return us.removeSession(ctx, session)
+}
+
+// CleanExpiredSessions 清理过期会话及其残留分片，返回清理的会话数量
+func (us *uploadService) CleanExpiredSessions(ctx context.Context) (int, error) {
+	cleaned := 0
+	for {
+		sessions, err := us.sessionDao.ListExpiredSessions(time.Now(), cleanupBatchSize)
+		if err != nil {
+			return cleaned, err
+		}
+		for i := range sessions {
+			if err := us.removeSession(ctx, &sessions[i]); err != nil {
+				return cleaned, err
+			}
+			cleaned++
+		}
+		if len(sessions) < cleanupBatchSize {
+			return cleaned, nil
+		}
+	}
+}
+
+// RunCleaner 按配置的间隔周期性清理过期会话，直到 ctx 结束
+func (us *uploadService) RunCleaner(ctx context.Context) {
+	ticker := time.NewTicker(us.cleanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if n, err := us.CleanExpiredSessions(ctx); err != nil {
+				log.Printf("清理过期上传会话失败: %v", err)
+			} else if n > 0 {
+				log.Printf("已清理 %d 个过期上传会话", n)
+			}
+		}
+	}
+}
+
+func (us *uploadService) getActiveSession(userID uint, uploadID string) (*model.UploadSession, error) {
+	session, err := us.sessionDao.GetSession(uploadID)
+	if err != nil {
+		return nil, fmt.Errorf("获取上传会话失败: %w", err)
+	}
+	if session == nil || session.UserID != userID {
+		return nil, ErrUploadSessionNotFound
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrUploadSessionExpired
+	}
+	return session, nil
+}
+
+func (us *uploadService) removeSession(ctx context.Context, session *model.UploadSession) error {
+	if err := us.storageDriver.AbortMultipart(ctx, session.StorageKey, session.UploadID); err != nil {
+		// 存储端分片可能已被清理，记录后继续删除会话
+		log.Printf("取消分片上传失败(%s): %v", session.ID, err)
+	}
+	return us.sessionDao.DeleteSession(session.ID)
+}
+
+// expectedChunkSize 计算指定分片应有的大小，只有最后一片可以小于分片大小
+func expectedChunkSize(session *model.UploadSession, index int) int64 {
+	if index == session.ChunkCount-1 {
+		return session.Size - int64(index)*session.ChunkSize
+	}
+	return session.ChunkSize
+}
+
+func buildSessionResp(session *model.UploadSession, chunks []model.UploadChunk) *model.UploadSessionResp {
+	uploaded := make([]int, 0, len(chunks))
+	for _, chunk := range chunks {
+		uploaded = append(uploaded, chunk.ChunkIndex)
+	}
+	return &model.UploadSessionResp{
+		UploadID:       session.ID,
+		FileName:       session.FileName,
+		Size:           session.Size,
+		ChunkSize:      session.ChunkSize,
+		ChunkCount:     session.ChunkCount,
+		UploadedChunks: uploaded,
+		ExpiresAt:      session.ExpiresAt,
+	}
+}
+
+// parseDuration 解析配置中的时间字符串，非法或为空时使用默认值
+func parseDuration(value string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
+func NewUploadService(sessionDao dao.UploadSessionDao, fileDao dao.FileDao, blobDao dao.BlobDao, versionDao dao.VersionDao, tx dao.Transactor, ingest IngestService,
+	permissions PermissionService, quota QuotaService, driver storage.Driver) UploadService {
+	cfg := config.AppConfigInstance.Upload
+	chunkSize := cfg.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	blobs := newBlobStore(blobDao, driver)
+	return &uploadService{
+		sessionDao:    sessionDao,
+		fileDao:       fileDao,
+		permissions:   permissions,
+		quota:         quota,
+		storageDriver: driver,
+		blobs:         blobs,
+		committer:     newFileCommitter(fileDao, versionDao, tx, ingest, blobs, quota),
+		chunkSize:     chunkSize,
+		sessionTTL:    parseDuration(cfg.SessionTTL, defaultSessionTTL),
+		cleanInterval: parseDuration(cfg.CleanupInterval, defaultCleanupInterval),
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"llmcloud/internal/dao"
+	"llmcloud/internal/model"
+	"llmcloud/internal/storage"
+	"time"
+)
+
+var ErrFileVersionNotFound = errors.New("文件版本不存在")
+
+const defaultMaxVersions = 10
+
+// VersionService 文件版本服务
+type VersionService interface {
+	ListVersions(userID uint, fileID string) ([]model.FileVersionResp, error)
+	OpenVersion(ctx context.Context, userID uint, fileID string, versionID string) (*model.File, *model.FileVersion, io.ReadSeekCloser, error)
+	RestoreVersion(ctx context.Context, userID uint, fileID string, versionID string) (*model.File, error)
+	DeleteVersion(ctx context.Context, userID uint, fileID string, versionID string) error
+	GetPolicy(userID uint) (*model.VersionPolicy, error)
+	UpdatePolicy(userID uint, req *model.VersionPolicyReq) error
+}
+
+type versionService struct {
+	versionDao    dao.VersionDao
+	permissions   PermissionService
+	storageDriver storage.Driver
+	blobs         *blobStore
+	committer     *fileCommitter
+	quota         QuotaService
+}
+
+// ListVersions 列出文件的全部版本，第一项为当前版本
+func (vs *versionService) ListVersions(userID uint, fileID string) ([]model.FileVersionResp, error) {
+	file, err := vs.getFile(userID, fileID)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := vs.versionDao.ListVersions(file.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取历史版本失败: %w", err)
+	}
+	current := snapshotVersion(file)
+	result := make([]model.FileVersionResp, 0, len(versions)+1)
+	result = append(result, model.FileVersionResp{
+		Version:    current.Version,
+		Size:       current.Size,
+		Hash:       current.Hash,
+		UploaderID: current.UploaderID,
+		CreatedAt:  current.CreatedAt,
+		IsCurrent:  true,
+	})
+	for _, v := range versions {
+		result = append(result, model.FileVersionResp{
+			VersionID:  v.ID,
+			Version:    v.Version,
+			Size:       v.Size,
+			Hash:       v.Hash,
+			UploaderID: v.UploaderID,
+			CreatedAt:  v.CreatedAt,
+		})
+	}
+	return result, nil
+}
+
+// OpenVersion 打开指定历史版本的文件流，调用方负责关闭
+func (vs *versionService) OpenVersion(ctx context.Context, userID uint, fileID string, versionID string) (*model.File, *model.FileVersion, io.ReadSeekCloser, error) {
+	file, version, err := vs.getVersion(userID, fileID, versionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	reader, err := storage.OpenSeeker(ctx, vs.storageDriver, version.StorageKey, version.Size)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("文件下载失败: %w", err)
+	}
+	return file, version, reader, nil
+}
+
+// RestoreVersion 将历史版本恢复为当前版本，原当前版本作为新的历史版本保留
+func (vs *versionService) RestoreVersion(ctx context.Context, userID uint, fileID string, versionID string) (*model.File, error) {
+	file, version, err := vs.getVersion(userID, fileID, versionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 历史版本继续保留，因此当前版本需要额外持有一个引用；
+	// 对象未登记引用计数时无法共享，改为把该历史版本转移为当前版本
+	consumedVersionID := version.ID
+	if version.Hash != "" {
+		if err := vs.quota.CheckQuota(file.UserID, version.Size); err != nil {
+			return nil, err
+		}
+		blob, err := vs.blobs.acquire(version.Hash, version.Size)
+		if err != nil {
+			return nil, fmt.Errorf("获取存储对象失败: %w", err)
+		}
+		if blob != nil {
+			consumedVersionID = ""
+		}
+	}
+
+	snapshot := snapshotVersion(file)
+	file.Version = snapshot.Version + 1
+	file.Size = version.Size
+	file.Hash = version.Hash
+	file.MIMEType = version.MIMEType
+	file.StorageType = version.StorageType
+	file.StorageKey = version.StorageKey
+	file.UploaderID = userID
+	file.UpdatedAt = time.Now()
+	if err := writeChange(vs.committer.tx, model.ChangeUpdate, file, func(tx *dao.Tx) error {
+		if err := tx.Versions.ArchiveAndUpdate(snapshot, file, consumedVersionID); err != nil {
+			return err
+		}
+		return newFolderStats(tx.Files).apply(file.ParentID, model.FolderStats{Size: file.Size - snapshot.Size})
+	}); err != nil {
+		if consumedVersionID == "" {
+			_ = vs.blobs.release(ctx, version.Hash, version.StorageKey)
+		}
+		return nil, fmt.Errorf("恢复版本失败: %w", err)
+	}
+	// 历史版本被转移为当前版本时总用量不变，否则多出一份当前版本
+	if consumedVersionID == "" {
+		vs.quota.RecordUsage(file.UserID, file.MIMEType, file.Size, 0)
+	}
+	vs.committer.ingest.Schedule(file)
+
+	// 版本保留数量按文件所有者的策略
+	policy, err := vs.committer.policy(file.UserID)
+	if err != nil {
+		return nil, err
+	}
+	vs.committer.prune(ctx, file.UserID, file.ID, policy.MaxVersions)
+	return file, nil
+}
+
+// DeleteVersion 删除历史版本，当前版本不能删除
+func (vs *versionService) DeleteVersion(ctx context.Context, userID uint, fileID string, versionID string) error {
+	file, version, err := vs.getVersion(userID, fileID, versionID)
+	if err != nil {
+		return err
+	}
+	if err := vs.versionDao.DeleteVersion(version.ID); err != nil {
+		return fmt.Errorf("删除版本失败: %w", err)
+	}
+	vs.quota.RecordUsage(file.UserID, version.MIMEType, -version.Size, 0)
+	return vs.blobs.release(ctx, version.Hash, version.StorageKey)
+}
+
+func (vs *versionService) GetPolicy(userID uint) (*model.VersionPolicy, error) {
+	return vs.committer.policy(userID)
+}
+
+// UpdatePolicy 更新用户的版本策略，超出上限的旧版本在下次产生新版本时清理
+func (vs *versionService) UpdatePolicy(userID uint, req *model.VersionPolicyReq) error {
+	return vs.versionDao.SavePolicy(&model.VersionPolicy{
+		UserID:      userID,
+		Enabled:     *req.Enabled,
+		MaxVersions: req.MaxVersions,
+	})
+}
+
+// getFile 获取用户拥有编辑权限的文件：能上传新版本的用户同样可以查看与恢复历史版本
+func (vs *versionService) getFile(userID uint, fileID string) (*model.File, error) {
+	file, err := vs.permissions.Authorize(userID, fileID, model.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	if file.IsDir {
+		return nil, ErrFileNotFound
+	}
+	return file, nil
+}
+
+func (vs *versionService) getVersion(userID uint, fileID string, versionID string) (*model.File, *model.FileVersion, error) {
+	file, err := vs.getFile(userID, fileID)
+	if err != nil {
+		return nil, nil, err
+	}
+	version, err := vs.versionDao.GetVersion(versionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取文件版本失败: %w", err)
+	}
+	if version == nil || version.FileID != file.ID {
+		return nil, nil, ErrFileVersionNotFound
+	}
+	return file, version, nil
+}
+
+func NewVersionService(fileDao dao.FileDao, versionDao dao.VersionDao, permissions PermissionService, blobDao dao.BlobDao, tx dao.Transactor, ingest IngestService, quota QuotaService, driver storage.Driver) VersionService {
+	blobs := newBlobStore(blobDao, driver)
+	return &versionService{
+		versionDao:    versionDao,
+		permissions:   permissions,
+		storageDriver: driver,
+		blobs:         blobs,
+		committer:     newFileCommitter(fileDao, versionDao, tx, ingest, blobs, quota),
+		quota:         quota,
+	}
+}
@@ -1,9 +1,15 @@
 package storage
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 )
 
 // LocalStorage 本地存储驱动结构体
@@ -20,26 +26,167 @@ func NewLocalStorage(baseDir string) (*LocalStorage, error) {
 }
 
 // Upload 上传文件到本地
-func (s *LocalStorage) Upload(data []byte, key string) error {
+// 先写入同目录下的临时文件，写入完成且大小校验通过后再重命名，避免留下残缺文件
+func (s *LocalStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64) error {
 	fullPath := filepath.Join(s.baseDir, key)
 	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
 		return fmt.Errorf("failed to create parent dir: %v", err)
 	}
-	return os.WriteFile(fullPath, data, 0644)
+	tmp, err := os.CreateTemp(filepath.Dir(fullPath), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %v", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	written, err := io.Copy(tmp, withContext(ctx, reader))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("size mismatch: expected %d, wrote %d", size, written)
+	}
+	return os.Rename(tmpPath, fullPath)
 }
 
-// Download 从本地下载文件
-func (s *LocalStorage) Download(key string) ([]byte, error) {
+// Download 从本地打开文件流
+func (s *LocalStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
 	fullPath := filepath.Join(s.baseDir, key)
-	return os.ReadFile(fullPath)
+	return os.Open(fullPath)
+}
+
+// DownloadRange 通过文件偏移读取指定区间
+func (s *LocalStorage) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	f, err := os.Open(filepath.Join(s.baseDir, key))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to seek file: %v", err)
+	}
+	if length < 0 {
+		return f, nil
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(f, length), Closer: f}, nil
 }
 
-func (s *LocalStorage) Delete(key string) error {
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
 	fullPath := filepath.Join(s.baseDir, key)
 	return os.Remove(fullPath)
 }
 
+// Copy 复制本地文件，复用 Upload 的临时文件写入逻辑
+func (s *LocalStorage) Copy(ctx context.Context, srcKey, dstKey string) error {
+	src, err := os.Open(filepath.Join(s.baseDir, srcKey))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %v", err)
+	}
+	return s.Upload(ctx, dstKey, src, info.Size())
+}
+
 // GetURL 获取本地文件路径（仅返回相对路径）
-func (s *LocalStorage) GetURL(key string) (string, error) {
+func (s *LocalStorage) GetURL(ctx context.Context, key string) (string, error) {
 	return filepath.Join(s.baseDir, key), nil
 }
+
+// multipartDir 返回本地分片暂存目录
+func (s *LocalStorage) multipartDir(uploadID string) string {
+	return filepath.Join(s.baseDir, ".multipart", uploadID)
+}
+
+// InitMultipart 在本地创建分片暂存目录
+func (s *LocalStorage) InitMultipart(ctx context.Context, key string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate upload id: %v", err)
+	}
+	uploadID := hex.EncodeToString(buf)
+	if err := os.MkdirAll(s.multipartDir(uploadID), 0755); err != nil {
+		return "", fmt.Errorf("failed to create multipart dir: %v", err)
+	}
+	return uploadID, nil
+}
+
+// UploadPart 将分片写入暂存目录，重复上传同一分片会覆盖旧数据
+func (s *LocalStorage) UploadPart(ctx context.Context, key, uploadID string, partNumber int, reader io.Reader, size int64) (string, error) {
+	dir := s.multipartDir(uploadID)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("multipart upload not found: %v", err)
+	}
+	partKey := filepath.Join(".multipart", uploadID, strconv.Itoa(partNumber))
+	if err := s.Upload(ctx, partKey, reader, size); err != nil {
+		return "", err
+	}
+	return strconv.Itoa(partNumber), nil
+}
+
+// CompleteMultipart 按顺序拼接分片到目标文件并清理暂存目录
+func (s *LocalStorage) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) error {
+	sorted := append([]Part(nil), parts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Number < sorted[j].Number })
+
+	var total int64
+	for _, part := range sorted {
+		total += part.Size
+	}
+	reader := &partReader{dir: s.multipartDir(uploadID), parts: sorted}
+	defer reader.Close()
+	if err := s.Upload(ctx, key, reader, total); err != nil {
+		return err
+	}
+	return os.RemoveAll(s.multipartDir(uploadID))
+}
+
+// AbortMultipart 删除分片暂存目录
+func (s *LocalStorage) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	return os.RemoveAll(s.multipartDir(uploadID))
+}
+
+// partReader 依次打开并读取各分片文件，同一时刻只持有一个文件句柄
+type partReader struct {
+	dir     string
+	parts   []Part
+	current *os.File
+}
+
+func (r *partReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(filepath.Join(r.dir, strconv.Itoa(r.parts[0].Number)))
+			if err != nil {
+				return 0, fmt.Errorf("failed to open part %d: %v", r.parts[0].Number, err)
+			}
+			r.current = f
+			r.parts = r.parts[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *partReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
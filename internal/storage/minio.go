@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"llmcloud/config"
+	"sort"
 	"strings"
 	"time"
 
@@ -59,10 +60,9 @@ func NewMinioStorage(cfg config.MinioConfig) (Driver, error) {
 	}, nil
 }
 
-// Upload 上传文件到 Minio
-func (m *MinioStorage) Upload(data []byte, key string) error {
-	reader := bytes.NewReader(data)
-	_, err := m.client.PutObject(context.Background(), m.bucket, key, reader, int64(len(data)), minio.PutObjectOptions{
+// Upload 流式上传文件到 Minio
+func (m *MinioStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64) error {
+	_, err := m.client.PutObject(ctx, m.bucket, key, reader, size, minio.PutObjectOptions{
 		ContentType: "application/octet-stream",
 	})
 	if err != nil {
@@ -71,41 +71,120 @@ func (m *MinioStorage) Upload(data []byte, key string) error {
 	return nil
 }
 
-// Download 从 Minio 下载文件
-func (m *MinioStorage) Download(key string) ([]byte, error) {
-	obj, err := m.client.GetObject(context.Background(), m.bucket, key, minio.GetObjectOptions{})
+// Download 从 Minio 获取文件流
+func (m *MinioStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := m.client.GetObject(ctx, m.bucket, key, minio.GetObjectOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get object: %v", err)
 	}
-	defer obj.Close()
+	// GetObject 是惰性的，先 Stat 一次以便尽早暴露对象不存在等错误
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, fmt.Errorf("failed to stat object: %v", err)
+	}
+	return obj, nil
+}
 
-	data, err := io.ReadAll(obj)
+// DownloadRange 使用 Range 请求读取对象的指定区间
+func (m *MinioStorage) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if offset == 0 && length < 0 {
+		return m.Download(ctx, key)
+	}
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	opts := minio.GetObjectOptions{}
+	end := int64(0) // end 为 0 表示读到末尾
+	if length > 0 {
+		end = offset + length - 1
+	}
+	if err := opts.SetRange(offset, end); err != nil {
+		return nil, fmt.Errorf("invalid range: %v", err)
+	}
+	obj, err := m.client.GetObject(ctx, m.bucket, key, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read object data: %v", err)
+		return nil, fmt.Errorf("failed to get object: %v", err)
 	}
-	return data, nil
+	return obj, nil
 }
 
 // Delete 从 Minio 删除文件
-func (m *MinioStorage) Delete(key string) error {
-	err := m.client.RemoveObject(context.Background(), m.bucket, key, minio.RemoveObjectOptions{})
+func (m *MinioStorage) Delete(ctx context.Context, key string) error {
+	err := m.client.RemoveObject(ctx, m.bucket, key, minio.RemoveObjectOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to delete object: %v", err)
 	}
 	return nil
 }
 
+// Copy 使用服务端复制，ComposeObject 在对象超过单次复制上限时自动分片复制
+func (m *MinioStorage) Copy(ctx context.Context, srcKey, dstKey string) error {
+	_, err := m.client.ComposeObject(ctx,
+		minio.CopyDestOptions{Bucket: m.bucket, Object: dstKey},
+		minio.CopySrcOptions{Bucket: m.bucket, Object: srcKey},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy object: %v", err)
+	}
+	return nil
+}
+
 // GetURL 获取文件的访问URL
-func (m *MinioStorage) GetURL(key string) (string, error) {
+func (m *MinioStorage) GetURL(ctx context.Context, key string) (string, error) {
 	// 生成预签名URL，有效期1小时
 	expiry := time.Second * 3600 // 1小时
-	presignedURL, err := m.client.PresignedGetObject(context.Background(), m.bucket, key, expiry, nil)
+	presignedURL, err := m.client.PresignedGetObject(ctx, m.bucket, key, expiry, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %v", err)
 	}
 	return presignedURL.String(), nil
 }
 
+// InitMultipart 初始化 Minio 原生分片上传
+func (m *MinioStorage) InitMultipart(ctx context.Context, key string) (string, error) {
+	core := minio.Core{Client: m.client}
+	uploadID, err := core.NewMultipartUpload(ctx, m.bucket, key, minio.PutObjectOptions{
+		ContentType: "application/octet-stream",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to init multipart upload: %v", err)
+	}
+	return uploadID, nil
+}
+
+// UploadPart 上传单个分片
+func (m *MinioStorage) UploadPart(ctx context.Context, key, uploadID string, partNumber int, reader io.Reader, size int64) (string, error) {
+	core := minio.Core{Client: m.client}
+	part, err := core.PutObjectPart(ctx, m.bucket, key, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+	return part.ETag, nil
+}
+
+// CompleteMultipart 合并分片
+func (m *MinioStorage) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) error {
+	core := minio.Core{Client: m.client}
+	completeParts := make([]minio.CompletePart, 0, len(parts))
+	for _, part := range parts {
+		completeParts = append(completeParts, minio.CompletePart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	sort.Slice(completeParts, func(i, j int) bool { return completeParts[i].PartNumber < completeParts[j].PartNumber })
+	if _, err := core.CompleteMultipartUpload(ctx, m.bucket, key, uploadID, completeParts, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+	return nil
+}
+
+// AbortMultipart 取消分片上传
+func (m *MinioStorage) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	core := minio.Core{Client: m.client}
+	if err := core.AbortMultipartUpload(ctx, m.bucket, key, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %v", err)
+	}
+	return nil
+}
+
 // CreateDirectory 创建目录（通过上传空对象实现）
 func (m *MinioStorage) CreateDirectory(dirPath string) error {
 	// 确保路径以 / 结尾
@@ -1,9 +1,12 @@
 package storage
 
 import (
-	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"llmcloud/config"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -31,33 +34,98 @@ func NewOSSStorage(cfg config.OSSConfig) (*OSSStorage, error) {
 	return &OSSStorage{bucket: bucket}, nil
 }
 
-// Upload 上传文件到OSS
-func (s *OSSStorage) Upload(data []byte, key string) error {
-	return s.bucket.PutObject(key, bytes.NewReader(data))
+// Upload 流式上传文件到OSS
+func (s *OSSStorage) Upload(ctx context.Context, key string, reader io.Reader, size int64) error {
+	options := []oss.Option{oss.WithContext(ctx)}
+	if size >= 0 {
+		options = append(options, oss.ContentLength(size))
+	}
+	return s.bucket.PutObject(key, reader, options...)
 }
 
-// Download 从OSS下载文件
-func (s *OSSStorage) Download(key string) ([]byte, error) {
-	reader, err := s.bucket.GetObject(key)
+// Download 从OSS获取文件流
+func (s *OSSStorage) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	reader, err := s.bucket.GetObject(key, oss.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("failed to download from OSS: %v", err)
 	}
-	defer reader.Close()
+	return reader, nil
+}
 
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(reader); err != nil {
-		return nil, fmt.Errorf("failed to read OSS data: %v", err)
+// DownloadRange 使用 Range 请求读取对象的指定区间
+func (s *OSSStorage) DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if length == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	rangeOption := oss.NormalizedRange(fmt.Sprintf("%d-", offset))
+	if length > 0 {
+		rangeOption = oss.Range(offset, offset+length-1)
 	}
-	return buf.Bytes(), nil
+	reader, err := s.bucket.GetObject(key, rangeOption, oss.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download range from OSS: %v", err)
+	}
+	return reader, nil
 }
 
 // Delete 删除OSS文件
-func (s *OSSStorage) Delete(key string) error {
-	return s.bucket.DeleteObject(key)
+func (s *OSSStorage) Delete(ctx context.Context, key string) error {
+	return s.bucket.DeleteObject(key, oss.WithContext(ctx))
+}
+
+// Copy 在同一 Bucket 内服务端复制对象
+func (s *OSSStorage) Copy(ctx context.Context, srcKey, dstKey string) error {
+	_, err := s.bucket.CopyObject(srcKey, dstKey, oss.WithContext(ctx))
+	return err
 }
 
 // GetURL 生成带签名的临时访问URL（有效期1小时）
-func (s *OSSStorage) GetURL(key string) (string, error) {
+func (s *OSSStorage) GetURL(ctx context.Context, key string) (string, error) {
 	expired := time.Now().Add(1 * time.Hour)
 	return s.bucket.SignURL(key, oss.HTTPGet, int64(expired.Unix()))
 }
+
+// multipartResult 构造 OSS SDK 所需的分片上传标识
+func (s *OSSStorage) multipartResult(key, uploadID string) oss.InitiateMultipartUploadResult {
+	return oss.InitiateMultipartUploadResult{
+		Bucket:   s.bucket.BucketName,
+		Key:      key,
+		UploadID: uploadID,
+	}
+}
+
+// InitMultipart 初始化 OSS 原生分片上传
+func (s *OSSStorage) InitMultipart(ctx context.Context, key string) (string, error) {
+	imur, err := s.bucket.InitiateMultipartUpload(key, oss.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to init OSS multipart upload: %v", err)
+	}
+	return imur.UploadID, nil
+}
+
+// UploadPart 上传单个分片
+func (s *OSSStorage) UploadPart(ctx context.Context, key, uploadID string, partNumber int, reader io.Reader, size int64) (string, error) {
+	part, err := s.bucket.UploadPart(s.multipartResult(key, uploadID), reader, size, partNumber, oss.WithContext(ctx))
+	if err != nil {
+		return "", fmt.Errorf("failed to upload OSS part %d: %v", partNumber, err)
+	}
+	return part.ETag, nil
+}
+
+// CompleteMultipart 合并分片
+func (s *OSSStorage) CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) error {
+	uploadParts := make([]oss.UploadPart, 0, len(parts))
+	for _, part := range parts {
+		uploadParts = append(uploadParts, oss.UploadPart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	sort.Slice(uploadParts, func(i, j int) bool { return uploadParts[i].PartNumber < uploadParts[j].PartNumber })
+	if _, err := s.bucket.CompleteMultipartUpload(s.multipartResult(key, uploadID), uploadParts, oss.WithContext(ctx)); err != nil {
+		return fmt.Errorf("failed to complete OSS multipart upload: %v", err)
+	}
+	return nil
+}
+
+// AbortMultipart 取消分片上传
+func (s *OSSStorage) AbortMultipart(ctx context.Context, key, uploadID string) error {
+	return s.bucket.AbortMultipartUpload(s.multipartResult(key, uploadID), oss.WithContext(ctx))
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// limitedReadCloser 限制读取长度，同时保留底层的 Close
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// objectSeeker 将存储对象包装为 io.ReadSeekCloser
+// Seek 只记录偏移量，下次 Read 时才通过 DownloadRange 从新的位置重新打开流，
+// 因此可以直接交给 http.ServeContent 处理 Range 请求而不必把对象读入内存
+type objectSeeker struct {
+	ctx     context.Context
+	driver  Driver
+	key     string
+	size    int64
+	offset  int64
+	current io.ReadCloser
+}
+
+// OpenSeeker 打开对象并返回可 Seek 的流，初始流从头开始读取以便尽早暴露对象不存在等错误
+func OpenSeeker(ctx context.Context, driver Driver, key string, size int64) (io.ReadSeekCloser, error) {
+	reader, err := driver.Download(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &objectSeeker{ctx: ctx, driver: driver, key: key, size: size, current: reader}, nil
+}
+
+func (o *objectSeeker) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.current == nil {
+		reader, err := o.driver.DownloadRange(o.ctx, o.key, o.offset, -1)
+		if err != nil {
+			return 0, err
+		}
+		o.current = reader
+	}
+	n, err := o.current.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *objectSeeker) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = o.offset + offset
+	case io.SeekEnd:
+		target = o.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if target < 0 {
+		return 0, fmt.Errorf("negative position: %d", target)
+	}
+	if target != o.offset && o.current != nil {
+		o.current.Close()
+		o.current = nil
+	}
+	o.offset = target
+	return target, nil
+}
+
+func (o *objectSeeker) Close() error {
+	if o.current != nil {
+		err := o.current.Close()
+		o.current = nil
+		return err
+	}
+	return nil
+}
+
+// ReaderAtCloser 可随机读取并需要关闭的对象
+type ReaderAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// objectReaderAt 基于 objectSeeker 实现 io.ReaderAt
+// 连续的 ReadAt 会复用同一个流，只有跳跃读取时才重新发起区间请求，适合 archive/zip 这类顺序读取条目的场景
+type objectReaderAt struct {
+	mu     sync.Mutex
+	seeker *objectSeeker
+}
+
+// OpenReaderAt 打开对象并返回 io.ReaderAt
+func OpenReaderAt(ctx context.Context, driver Driver, key string, size int64) (ReaderAtCloser, error) {
+	seeker, err := OpenSeeker(ctx, driver, key, size)
+	if err != nil {
+		return nil, err
+	}
+	return &objectReaderAt{seeker: seeker.(*objectSeeker)}, nil
+}
+
+func (o *objectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, err := o.seeker.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(o.seeker, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (o *objectReaderAt) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.seeker.Close()
+}
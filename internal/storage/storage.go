@@ -1,16 +1,34 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"llmcloud/config"
 )
 
 // Driver 定义存储驱动接口
+// 所有读写均以流的方式进行，避免将整个文件读入内存
 type Driver interface {
-	Upload(data []byte, key string) error // 上传文件
-	Download(key string) ([]byte, error)  // 下载文件
-	Delete(key string) error              // 删除文件
-	GetURL(key string) (string, error)    // 获取访问URL
+	Upload(ctx context.Context, key string, reader io.Reader, size int64) error                 // 流式上传文件，size 为数据总长度
+	Download(ctx context.Context, key string) (io.ReadCloser, error)                            // 流式下载文件，调用方负责关闭
+	DownloadRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) // 从 offset 开始读取 length 字节，length<0 表示读到末尾
+	Delete(ctx context.Context, key string) error                                               // 删除文件
+	Copy(ctx context.Context, srcKey, dstKey string) error                                      // 在存储端复制对象，不经过应用服务器中转
+	GetURL(ctx context.Context, key string) (string, error)                                     // 获取访问URL
+
+	// 分片上传：本地驱动在磁盘上暂存分片，MinIO/OSS 使用原生 multipart upload
+	InitMultipart(ctx context.Context, key string) (string, error)                                                      // 初始化分片上传，返回 uploadID
+	UploadPart(ctx context.Context, key, uploadID string, partNumber int, reader io.Reader, size int64) (string, error) // 上传分片（partNumber 从 1 开始），返回 ETag
+	CompleteMultipart(ctx context.Context, key, uploadID string, parts []Part) error                                    // 按 partNumber 顺序合并分片
+	AbortMultipart(ctx context.Context, key, uploadID string) error                                                     // 取消分片上传并清理已上传分片
+}
+
+// Part 描述一个已上传的分片
+type Part struct {
+	Number int    // 分片序号，从 1 开始
+	ETag   string // 存储端返回的分片标识
+	Size   int64  // 分片大小
 }
 
 // NewDriver 根据配置初始化存储驱动
@@ -26,3 +44,21 @@ func NewDriver(cfg config.StorageConfig) (Driver, error) {
 		return nil, fmt.Errorf("unsupported storage type: %s", cfg.Type)
 	}
 }
+
+// contextReader 在每次读取前检查 context，使长时间的流式拷贝可以被取消
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// withContext 包装 reader，使其感知 context 取消
+func withContext(ctx context.Context, reader io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, reader: reader}
+}
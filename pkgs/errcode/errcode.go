@@ -37,6 +37,27 @@ const (
 	FileParseFailed  = 21006 // 文件解析失败
 	FileListFailed   = 21007 // 文杰列表获取失败
 	FileSearchFailed = 21008 // 文件搜索失败
+
+	UploadSessionNotFound = 21009 // 上传会话不存在
+	UploadSessionExpired  = 21010 // 上传会话已过期
+	UploadChunkInvalid    = 21011 // 分片参数无效
+	UploadIncomplete      = 21012 // 分片未全部上传
+	FileHashMismatch      = 21013 // 文件哈希校验失败
+	TrashItemNotFound     = 21014 // 回收站条目不存在
+	FileVersionNotFound   = 21015 // 文件版本不存在
+	ShareLinkNotFound     = 21016 // 分享链接不存在
+	ShareLinkExpired      = 21017 // 分享链接已过期
+	SharePasswordRequired = 21018 // 需要提取码
+	SharePasswordInvalid  = 21019 // 提取码错误
+	ShareDownloadLimit    = 21020 // 下载次数已达上限
+	PermissionNotFound    = 21021 // 授权不存在
+	JobNotFound           = 21022 // 任务不存在
+	ArchiveTooLarge       = 21023 // 打包内容超过大小上限
+	ArchiveFormatInvalid  = 21024 // 不支持的压缩包格式
+	FileNameExists        = 21025 // 目标位置已存在同名条目
+	JobFinished           = 21026 // 任务已结束
+	SearchDisabled        = 21027 // 未开启文档检索
+	FileNameInvalid       = 21028 // 文件名不合法
 	// 订单模块 (22000-22999)
 	// 可后续扩展...
 )